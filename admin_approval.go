@@ -0,0 +1,205 @@
+package oauth2server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// decides which scopes are sensitive, these must be approved for a client by
+// an organization admin before any user can grant them.
+type ScopeClassifier interface {
+	IsSensitive(ctx context.Context, scope string) bool
+}
+
+type sensitiveScopes struct {
+	scopes []string
+}
+
+// a fixed list of sensitive scopes, eg `directory:write` or `billing`
+func SensitiveScopes(scopes ...string) ScopeClassifier {
+	return &sensitiveScopes{
+		scopes: scopes,
+	}
+}
+
+func (c *sensitiveScopes) IsSensitive(ctx context.Context, scope string) bool {
+	return slices.Contains(c.scopes, scope)
+}
+
+// the sensitive scopes an admin pre-approved for a client.
+type AdminApproval struct {
+	ClientID string
+
+	Scope []string
+
+	// the admin that approved the client, informational only
+	ApprovedBy string
+
+	ApprovedAt time.Time
+}
+
+// storage for admin approvals, one per client.
+type AdminApprovalRepository interface {
+	// Get the approval for the client, return a `nil` approval if an admin has
+	// not approved the client. Any errors returned here will be propagated as
+	// server errors.
+	Get(ctx context.Context, clientId string) (*AdminApproval, error)
+
+	// store the approval, replacing any existing approval for the client
+	Save(ctx context.Context, approval *AdminApproval) error
+
+	// remove the approval for the client, if any
+	Revoke(ctx context.Context, clientId string) error
+}
+
+type InMemoryAdminApprovalRepository struct {
+	lock      sync.RWMutex
+	approvals map[string]*AdminApproval
+}
+
+func NewInMemoryAdminApprovalRepository() *InMemoryAdminApprovalRepository {
+	return &InMemoryAdminApprovalRepository{
+		approvals: make(map[string]*AdminApproval),
+	}
+}
+
+func (r *InMemoryAdminApprovalRepository) Get(ctx context.Context, clientId string) (*AdminApproval, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	approval, _ := r.approvals[clientId]
+
+	return approval, nil
+}
+
+func (r *InMemoryAdminApprovalRepository) Save(ctx context.Context, approval *AdminApproval) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.approvals[approval.ClientID] = approval
+
+	return nil
+}
+
+func (r *InMemoryAdminApprovalRepository) Revoke(ctx context.Context, clientId string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.approvals, clientId)
+
+	return nil
+}
+
+// the cause of the `access_denied` error for requests with sensitive scopes
+// that an admin has not approved. Servers that would rather defer the request
+// than redirect back to the client can check for this with errors.As and ask
+// an admin to approve the scopes.
+type AdminApprovalRequiredError struct {
+	ClientID string
+
+	// the sensitive scopes that are not approved
+	Scope []string
+}
+
+func (e *AdminApprovalRequiredError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrAdminApprovalRequired.Error(), strings.Join(e.Scope, spaceSeparator))
+}
+
+func (e *AdminApprovalRequiredError) Is(target error) bool {
+	return target == ErrAdminApprovalRequired
+}
+
+// require an admin to approve sensitive scopes for a client before users can
+// grant them. Authorization requests for unapproved scopes fail validation
+// with an `access_denied` error caused by *AdminApprovalRequiredError.
+func WithAdminApproval(classifier ScopeClassifier, approvals AdminApprovalRepository) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.scopeClassifier = classifier
+		opts.adminApprovals = approvals
+	}
+}
+
+func (s *defaultAuthorizationServer) checkAdminApproval(ctx context.Context, client Client, scope []string) *OAuthError {
+	unapproved, err := unapprovedScopes(ctx, s.scopeClassifier, s.adminApprovals, s.scopeValidator, client, scope)
+	if err != nil {
+		return ServerError(err)
+	}
+	if len(unapproved) == 0 {
+		return nil
+	}
+
+	return &OAuthError{
+		ErrorType: ErrorTypeAccessDenied,
+		ErrorDescription: fmt.Sprintf(
+			"an administrator must approve this application before it can be granted: %s",
+			strings.Join(unapproved, spaceSeparator),
+		),
+		Cause: &AdminApprovalRequiredError{ClientID: client.ID(), Scope: unapproved},
+	}
+}
+
+// token requests are checked again so revoking an approval stops sensitive
+// scopes from being issued with existing codes and refresh tokens.
+func (r *AccessTokenRequest) checkAdminApproval(ctx context.Context, client Client, scope []string) *OAuthError {
+	unapproved, err := unapprovedScopes(ctx, r.scopeClassifier, r.adminApprovals, r.scopeValidator, client, scope)
+	if err != nil {
+		return ServerError(err)
+	}
+	if len(unapproved) == 0 {
+		return nil
+	}
+
+	e := InvalidScope(unapproved)
+	e.Cause = &AdminApprovalRequiredError{ClientID: client.ID(), Scope: unapproved}
+
+	return e
+}
+
+// the sensitive scopes an admin has not approved for the client. Implied
+// scopes are checked too, eg `directory` implying a sensitive `directory:write`
+// must be approved.
+func unapprovedScopes(
+	ctx context.Context,
+	classifier ScopeClassifier,
+	approvals AdminApprovalRepository,
+	validator ScopeValidator,
+	client Client,
+	scope []string,
+) ([]string, error) {
+	if classifier == nil {
+		return nil, nil
+	}
+
+	var sensitive []string
+	for _, sc := range expandScopes(validator, scope) {
+		if classifier.IsSensitive(ctx, sc) {
+			sensitive = append(sensitive, sc)
+		}
+	}
+	if len(sensitive) == 0 {
+		return nil, nil
+	}
+
+	approval, err := approvals.Get(ctx, client.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	var approved []string
+	if approval != nil {
+		approved = expandScopes(validator, approval.Scope)
+	}
+
+	var unapproved []string
+	for _, sc := range sensitive {
+		if !slices.Contains(approved, sc) {
+			unapproved = append(unapproved, sc)
+		}
+	}
+
+	return unapproved, nil
+}
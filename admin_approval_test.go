@@ -0,0 +1,201 @@
+package oauth2server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+type adminApprovalTestCase struct {
+	*authorizationServerTestCase
+	approvals *oauth2server.InMemoryAdminApprovalRepository
+}
+
+func startAdminApprovalTest(t *testing.T) *adminApprovalTestCase {
+	t.Helper()
+
+	approvals := oauth2server.NewInMemoryAdminApprovalRepository()
+	tc := startAuthorizationServerTest(
+		t,
+		oauth2server.WithAuthorizationHandler(&spyAuthorizationHandler{responseType: "code"}),
+		oauth2server.WithAdminApproval(oauth2server.SensitiveScopes("directory:write", "billing"), approvals),
+	)
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+
+	return &adminApprovalTestCase{
+		authorizationServerTestCase: tc,
+		approvals:                   approvals,
+	}
+}
+
+func (tc *adminApprovalTestCase) validate(t *testing.T, scope string) (*oauth2server.AuthorizationRequest, *oauth2server.OAuthError) {
+	t.Helper()
+
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamResponseType: "code",
+		oauth2server.ParamClientID:     testClientId,
+		oauth2server.ParamScope:        scope,
+	})
+
+	return tc.server.ValidateAuthorizationRequest(req.Context(), req)
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_AllowsScopesThatAreNotSensitive(t *testing.T) {
+	tc := startAdminApprovalTest(t)
+
+	_, err := tc.validate(t, "profile directory:read")
+
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_DeniesUnapprovedSensitiveScopes(t *testing.T) {
+	tc := startAdminApprovalTest(t)
+	tc.approvals.Save(context.Background(), &oauth2server.AdminApproval{
+		ClientID: testClientId,
+		Scope:    []string{"billing"},
+	})
+
+	authReq, err := tc.validate(t, "profile billing directory:write")
+
+	tc.assertNotNilAuthRequest(t, authReq)
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeAccessDenied || !errors.Is(err, oauth2server.ErrAdminApprovalRequired) {
+		t.Fatalf("expected access_denied caused by ErrAdminApprovalRequired, got %v", err)
+	}
+	if !strings.Contains(err.ErrorDescription, "directory:write") || strings.Contains(err.ErrorDescription, "billing") {
+		t.Errorf("expected description to name the unapproved scope, got %q", err.ErrorDescription)
+	}
+
+	var approvalErr *oauth2server.AdminApprovalRequiredError
+	if !errors.As(err, &approvalErr) {
+		t.Fatalf("expected *AdminApprovalRequiredError, got %T", err.Cause)
+	}
+	if approvalErr.ClientID != testClientId || !cmp.Equal([]string{"directory:write"}, approvalErr.Scope) {
+		t.Errorf("unexpected approval error: %+v", approvalErr)
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_AllowsApprovedSensitiveScopes(t *testing.T) {
+	tc := startAdminApprovalTest(t)
+	tc.approvals.Save(context.Background(), &oauth2server.AdminApproval{
+		ClientID: testClientId,
+		Scope:    []string{"billing", "directory:write"},
+	})
+
+	_, err := tc.validate(t, "billing directory:write")
+
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_ChecksScopesImpliedByTheRegistry(t *testing.T) {
+	approvals := oauth2server.NewInMemoryAdminApprovalRepository()
+	tc := &adminApprovalTestCase{
+		authorizationServerTestCase: startAuthorizationServerTest(
+			t,
+			oauth2server.WithAuthorizationHandler(&spyAuthorizationHandler{responseType: "code"}),
+			oauth2server.WithAdminApproval(oauth2server.SensitiveScopes("directory:write"), approvals),
+			oauth2server.WithScopeValidator(oauth2server.NewScopeRegistry(
+				oauth2server.WithRegisteredScope("directory", "directory:read", "directory:write"),
+				oauth2server.WithScopeAlias("dir", "directory"),
+			)),
+		),
+		approvals: approvals,
+	}
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+
+	for _, scope := range []string{"directory", "dir"} {
+		_, err := tc.validate(t, scope)
+
+		if err == nil || !errors.Is(err, oauth2server.ErrAdminApprovalRequired) {
+			t.Errorf("%s: expected admin approval to be required, got %v", scope, err)
+		}
+	}
+
+	tc.approvals.Save(context.Background(), &oauth2server.AdminApproval{
+		ClientID: testClientId,
+		Scope:    []string{"directory"},
+	})
+	if _, err := tc.validate(t, "dir"); err != nil {
+		t.Errorf("expected the approved parent scope to cover implied scopes, got %v", err)
+	}
+}
+
+func startRevokedAdminApprovalTest(t *testing.T) (oauth2server.AuthorizationServer, *oauth2server.InMemoryAdminApprovalRepository) {
+	t.Helper()
+
+	clients := oauth2server.NewInMemoryClientRepository()
+	clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	approvals := oauth2server.NewInMemoryAdminApprovalRepository()
+	approvals.Save(context.Background(), &oauth2server.AdminApproval{
+		ClientID: testClientId,
+		Scope:    []string{"billing"},
+	})
+	issuer := oauth2server.NewTokenIssuer(oauth2server.NewInMemoryAccessTokenRepository())
+	server := oauth2server.NewAuthorizationServer(
+		clients,
+		oauth2server.WithGrant(oauth2server.NewAuthorizationCodeGrant(clients, oauth2server.NewInMemoryAuthorizationCodeRepository(), issuer)),
+		oauth2server.WithAuthorizationRecords(oauth2server.NewInMemoryAuthorizationRecordRepository()),
+		oauth2server.WithAdminApproval(oauth2server.SensitiveScopes("billing"), approvals),
+	)
+
+	return server, approvals
+}
+
+func completeAdminApprovalRequest(server oauth2server.AuthorizationServer, includeGranted bool, scope ...string) (string, *oauth2server.OAuthError) {
+	params, err := server.CompleteAuthorizationRequest(context.Background(), &oauth2server.AuthorizationRequest{
+		ClientID:             testClientId,
+		RedirectURI:          testRedirectUri,
+		ResponseType:         []string{oauth2server.ResponseTypeCode},
+		Scope:                scope,
+		IncludeGrantedScopes: includeGranted,
+	}, &testUser{id: "user"})
+
+	return params.Get(oauth2server.ResponseTypeCode), err
+}
+
+func TestDefaultAuthorizationServer_Token_DeniesSensitiveScopesAfterApprovalIsRevoked(t *testing.T) {
+	server, approvals := startRevokedAdminApprovalTest(t)
+	code, err := completeAdminApprovalRequest(server, false, "billing")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	approvals.Revoke(context.Background(), testClientId)
+	req := createRequestWithFormBody(http.MethodPost, "/token", map[string]string{
+		oauth2server.ParamGrantType:   oauth2server.GrantTypeAuthorizationCode,
+		oauth2server.ParamCode:        code,
+		oauth2server.ParamRedirectURI: testRedirectUri,
+	})
+	req.SetBasicAuth(testClientId, testClientSecret)
+
+	resp, tokenErr := server.Token(context.Background(), req)
+
+	if resp != nil {
+		t.Errorf("expected no token, got %+v", resp)
+	}
+	if tokenErr == nil || tokenErr.ErrorType != oauth2server.ErrorTypeInvalidScope || !errors.Is(tokenErr, oauth2server.ErrAdminApprovalRequired) {
+		t.Errorf("expected invalid_scope caused by ErrAdminApprovalRequired, got %v", tokenErr)
+	}
+}
+
+func TestDefaultAuthorizationServer_CompleteAuthorizationRequest_DeniesGrantedScopesAfterApprovalIsRevoked(t *testing.T) {
+	server, approvals := startRevokedAdminApprovalTest(t)
+	if _, err := completeAdminApprovalRequest(server, false, "billing"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	approvals.Revoke(context.Background(), testClientId)
+
+	_, err := completeAdminApprovalRequest(server, true, "profile")
+
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeAccessDenied || !errors.Is(err, oauth2server.ErrAdminApprovalRequired) {
+		t.Errorf("expected access_denied caused by ErrAdminApprovalRequired, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package oauth2server
+
+import (
+	"slices"
+	"strconv"
+	"time"
+)
+
+// OpenID Connect authentication request values, see
+// https://openid.net/specs/openid-connect-core-1_0.html#AuthRequest
+const (
+	PromptNone          = "none"
+	PromptLogin         = "login"
+	PromptConsent       = "consent"
+	PromptSelectAccount = "select_account"
+
+	DisplayPage  = "page"
+	DisplayPopup = "popup"
+	DisplayTouch = "touch"
+	DisplayWAP   = "wap"
+)
+
+func parseMaxAge(raw string) (*int, *OAuthError) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	maxAge, err := strconv.Atoi(raw)
+	if err != nil || maxAge < 0 {
+		return nil, InvalidRequestWithCause(ErrInvalidMaxAge, ErrInvalidMaxAge.Error())
+	}
+
+	return &maxAge, nil
+}
+
+func validateAuthenticationParameters(req *AuthorizationRequest) *OAuthError {
+	if slices.Contains(req.Prompt, PromptNone) && len(req.Prompt) > 1 {
+		return InvalidRequestWithCause(ErrInvalidPrompt, ErrInvalidPrompt.Error())
+	}
+
+	switch req.Display {
+	case "", DisplayPage, DisplayPopup, DisplayTouch, DisplayWAP:
+	default:
+		return InvalidRequestWithCause(ErrUnsupportedDisplay, "unsupported %s: %s", ParamDisplay, req.Display)
+	}
+
+	return nil
+}
+
+// true if the request included the given prompt value
+func (r *AuthorizationRequest) HasPrompt(prompt string) bool {
+	return slices.Contains(r.Prompt, prompt)
+}
+
+// true if the end-user must not be shown any pages, errors like LoginRequired
+// should be sent back instead.
+func (r *AuthorizationRequest) IsSilent() bool {
+	return r.HasPrompt(PromptNone)
+}
+
+// true if the end-user must authenticate again given when they last
+// authenticated: `prompt=login` was requested or `max_age` has passed. A zero
+// authTime always requires authentication when either is requested.
+func (r *AuthorizationRequest) RequiresReauthentication(authTime time.Time, now time.Time) bool {
+	if r.HasPrompt(PromptLogin) {
+		return true
+	}
+
+	if r.MaxAge == nil {
+		return false
+	}
+
+	if authTime.IsZero() {
+		return true
+	}
+
+	return now.Sub(authTime) > time.Duration(*r.MaxAge)*time.Second
+}
@@ -0,0 +1,150 @@
+package oauth2server_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+func parseTestAuthenticationRequest(params map[string]string) (*oauth2server.AuthorizationRequest, *oauth2server.OAuthError) {
+	values := url.Values{}
+	values.Set(oauth2server.ParamClientID, testClientId)
+	values.Set(oauth2server.ParamResponseType, oauth2server.ResponseTypeCode)
+	for k, v := range params {
+		values.Set(k, v)
+	}
+
+	return oauth2server.ParseAuthorizationRequest(httptest.NewRequest(http.MethodGet, "/authorize?"+values.Encode(), nil))
+}
+
+func TestParseAuthorizationRequest_ParsesAuthenticationParameters(t *testing.T) {
+	req, err := parseTestAuthenticationRequest(map[string]string{
+		oauth2server.ParamNonce:       "n-0S6_WzA2Mj",
+		oauth2server.ParamPrompt:      "login consent",
+		oauth2server.ParamMaxAge:      "300",
+		oauth2server.ParamLoginHint:   "user@example.com",
+		oauth2server.ParamIDTokenHint: "eyJ.hint",
+		oauth2server.ParamACRValues:   "urn:mace:incommon:iap:silver urn:mace:incommon:iap:bronze",
+		oauth2server.ParamUILocales:   "fr-CA en",
+		oauth2server.ParamDisplay:     oauth2server.DisplayPopup,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	maxAge := 300
+	expected := oauth2server.AuthorizationRequest{
+		Nonce:       "n-0S6_WzA2Mj",
+		Prompt:      []string{oauth2server.PromptLogin, oauth2server.PromptConsent},
+		MaxAge:      &maxAge,
+		LoginHint:   "user@example.com",
+		IDTokenHint: "eyJ.hint",
+		ACRValues:   []string{"urn:mace:incommon:iap:silver", "urn:mace:incommon:iap:bronze"},
+		UILocales:   []string{"fr-CA", "en"},
+		Display:     oauth2server.DisplayPopup,
+	}
+	got := oauth2server.AuthorizationRequest{
+		Nonce:       req.Nonce,
+		Prompt:      req.Prompt,
+		MaxAge:      req.MaxAge,
+		LoginHint:   req.LoginHint,
+		IDTokenHint: req.IDTokenHint,
+		ACRValues:   req.ACRValues,
+		UILocales:   req.UILocales,
+		Display:     req.Display,
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected request (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseAuthorizationRequest_AllowsZeroMaxAge(t *testing.T) {
+	req, err := parseTestAuthenticationRequest(map[string]string{oauth2server.ParamMaxAge: "0"})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.MaxAge == nil || *req.MaxAge != 0 {
+		t.Errorf("expected max age of zero, got %v", req.MaxAge)
+	}
+}
+
+func TestParseAuthorizationRequest_RejectsInvalidAuthenticationParameters(t *testing.T) {
+	cases := []struct {
+		name     string
+		params   map[string]string
+		expected error
+	}{
+		{"prompt none with others", map[string]string{oauth2server.ParamPrompt: "none login"}, oauth2server.ErrInvalidPrompt},
+		{"negative max age", map[string]string{oauth2server.ParamMaxAge: "-1"}, oauth2server.ErrInvalidMaxAge},
+		{"non-numeric max age", map[string]string{oauth2server.ParamMaxAge: "soon"}, oauth2server.ErrInvalidMaxAge},
+		{"unknown display", map[string]string{oauth2server.ParamDisplay: "hologram"}, oauth2server.ErrUnsupportedDisplay},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req, err := parseTestAuthenticationRequest(c.params)
+
+			if req != nil {
+				t.Errorf("expected no request, got %+v", req)
+			}
+			if err == nil || err.ErrorType != oauth2server.ErrorTypeInvalidRequest || !errors.Is(err, c.expected) {
+				t.Errorf("expected invalid_request caused by %v, got %v", c.expected, err)
+			}
+		})
+	}
+}
+
+func TestAuthorizationRequest_IsSilent(t *testing.T) {
+	req := &oauth2server.AuthorizationRequest{Prompt: []string{oauth2server.PromptNone}}
+
+	if !req.IsSilent() {
+		t.Error("expected prompt=none to be silent")
+	}
+	if (&oauth2server.AuthorizationRequest{}).IsSilent() {
+		t.Error("expected request without prompt to not be silent")
+	}
+}
+
+func TestAuthorizationRequest_RequiresReauthentication(t *testing.T) {
+	now := time.Now()
+	fiveMinutes := 300
+	cases := []struct {
+		name     string
+		req      *oauth2server.AuthorizationRequest
+		authTime time.Time
+		expected bool
+	}{
+		{"no prompt or max age", &oauth2server.AuthorizationRequest{}, now.Add(-time.Hour), false},
+		{"prompt login", &oauth2server.AuthorizationRequest{Prompt: []string{oauth2server.PromptLogin}}, now, true},
+		{"within max age", &oauth2server.AuthorizationRequest{MaxAge: &fiveMinutes}, now.Add(-time.Minute), false},
+		{"past max age", &oauth2server.AuthorizationRequest{MaxAge: &fiveMinutes}, now.Add(-time.Hour), true},
+		{"max age without auth time", &oauth2server.AuthorizationRequest{MaxAge: &fiveMinutes}, time.Time{}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.req.RequiresReauthentication(c.authTime, now); got != c.expected {
+				t.Errorf("expected %v, got %v", c.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoginRequired_IsARedirectableError(t *testing.T) {
+	err := oauth2server.LoginRequired()
+
+	if err.Values().Get(oauth2server.ParamError) != oauth2server.ErrorTypeLoginRequired {
+		t.Errorf("unexpected error values: %v", err.Values())
+	}
+	if !errors.Is(err, oauth2server.ErrLoginRequired) {
+		t.Errorf("expected ErrLoginRequired, got %v", err)
+	}
+}
@@ -0,0 +1,378 @@
+package oauth2server
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+
+	DefaultAuthorizationCodeLifetime = 10 * time.Minute
+)
+
+// an issued authorization code along with everything that was approved in the
+// authorization request.
+type AuthorizationCode struct {
+	Code string
+
+	ClientID string
+
+	UserID string
+
+	// the redirect URI from the authorization request, empty if the client did
+	// not send one. The token request must include the same value.
+	RedirectURI string
+
+	Scope []string
+
+	// the resources granted in the authorization request
+	Resource []string
+
+	CodeChallenge string
+
+	CodeChallengeMethod string
+
+	AuthorizationDetails []AuthorizationDetail
+
+	// OpenID Connect details for ID tokens issued with the code
+	Nonce    string
+	AuthTime time.Time
+	ACR      string
+	AMR      []string
+
+	// the user's session at the authorization server, see UserWithSession
+	SessionID string
+
+	// the claims requested with the `claims` parameter, nil if none
+	Claims *ClaimsRequest
+
+	// the AuthorizationRecord the code was issued under, empty if none
+	AuthorizationID string
+
+	IssuedAt time.Time
+
+	ExpiresAt time.Time
+}
+
+// A storage backend for authorization codes
+type AuthorizationCodeRepository interface {
+	// store a newly issued authorization code
+	Create(ctx context.Context, code *AuthorizationCode) error
+
+	// fetch and remove an authorization code so it can only be used once,
+	// returns a `nil` code if not found or expired.
+	Consume(ctx context.Context, code string) (*AuthorizationCode, error)
+
+	// remove every code issued under the authorization record, the ID is
+	// never empty.
+	RevokeAuthorization(ctx context.Context, authorizationId string) error
+
+	// remove every code issued to the client on behalf of the user, an empty
+	// client ID removes the user's codes for every client.
+	RevokeForUser(ctx context.Context, userId string, clientId string) error
+}
+
+type InMemoryAuthorizationCodeRepository struct {
+	lock  sync.Mutex
+	codes map[string]*AuthorizationCode
+}
+
+func NewInMemoryAuthorizationCodeRepository() *InMemoryAuthorizationCodeRepository {
+	return &InMemoryAuthorizationCodeRepository{
+		codes: make(map[string]*AuthorizationCode),
+	}
+}
+
+func (r *InMemoryAuthorizationCodeRepository) Create(ctx context.Context, code *AuthorizationCode) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.codes[code.Code] = code
+
+	return nil
+}
+
+func (r *InMemoryAuthorizationCodeRepository) Consume(ctx context.Context, code string) (*AuthorizationCode, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	c, ok := r.codes[code]
+	if !ok {
+		return nil, nil
+	}
+	delete(r.codes, code)
+
+	if !time.Now().Before(c.ExpiresAt) {
+		return nil, nil
+	}
+
+	return c, nil
+}
+
+func (r *InMemoryAuthorizationCodeRepository) RevokeAuthorization(ctx context.Context, authorizationId string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for value, code := range r.codes {
+		if code.AuthorizationID == authorizationId {
+			delete(r.codes, value)
+		}
+	}
+
+	return nil
+}
+
+func (r *InMemoryAuthorizationCodeRepository) RevokeForUser(ctx context.Context, userId string, clientId string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for value, code := range r.codes {
+		if code.UserID == userId && (clientId == "" || code.ClientID == clientId) {
+			delete(r.codes, value)
+		}
+	}
+
+	return nil
+}
+
+type authorizationCodeGrant struct {
+	clients  ClientRepository
+	codes    AuthorizationCodeRepository
+	issuer   TokenIssuer
+	pkce     PKCE
+	lifetime time.Duration
+	idTokens IDTokenIssuer
+}
+
+type AuthorizationCodeGrantOption func(*authorizationCodeGrant)
+
+func WithAuthorizationCodePKCE(p PKCE) AuthorizationCodeGrantOption {
+	return func(g *authorizationCodeGrant) {
+		g.pkce = p
+	}
+}
+
+func WithAuthorizationCodeLifetime(lifetime time.Duration) AuthorizationCodeGrantOption {
+	return func(g *authorizationCodeGrant) {
+		g.lifetime = lifetime
+	}
+}
+
+// issue ID tokens from the token endpoint when the `openid` scope was granted.
+func WithAuthorizationCodeIDTokens(idTokens IDTokenIssuer) AuthorizationCodeGrantOption {
+	return func(g *authorizationCodeGrant) {
+		g.idTokens = idTokens
+	}
+}
+
+// the authorization code grant, this is also the `code` authorization handler.
+// Public clients are required to use PKCE.
+func NewAuthorizationCodeGrant(
+	clients ClientRepository,
+	codes AuthorizationCodeRepository,
+	issuer TokenIssuer,
+	opts ...AuthorizationCodeGrantOption,
+) Grant {
+	g := &authorizationCodeGrant{
+		clients:  clients,
+		codes:    codes,
+		issuer:   issuer,
+		lifetime: DefaultAuthorizationCodeLifetime,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	if g.pkce == nil {
+		g.pkce = NewDefaultPKCE()
+	}
+
+	return g
+}
+
+func (g *authorizationCodeGrant) GrantType() string {
+	return GrantTypeAuthorizationCode
+}
+
+func (g *authorizationCodeGrant) ResponseType() string {
+	return ResponseTypeCode
+}
+
+func (g *authorizationCodeGrant) ValidateAuthorizationRequest(ctx context.Context, client Client, req *AuthorizationRequest) error {
+	if req.CodeChallenge == "" {
+		if !client.IsConfidential() {
+			return InvalidRequestWithCause(ErrCodeChallengeRequired, ErrCodeChallengeRequired.Error())
+		}
+		return nil
+	}
+
+	if err := ValidateCodeChallenge(ctx, g.pkce, req.CodeChallengeMethod, req.CodeChallenge); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (g *authorizationCodeGrant) IssueAuthorizationResponse(ctx context.Context, client Client, req *AuthorizationRequest, user User) (string, error) {
+	value, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	code := &AuthorizationCode{
+		Code:                 value,
+		ClientID:             client.ID(),
+		UserID:               user.ID(),
+		RedirectURI:          req.RedirectURI,
+		Scope:                req.Scope,
+		Resource:             req.Resource,
+		CodeChallenge:        req.CodeChallenge,
+		CodeChallengeMethod:  req.CodeChallengeMethod,
+		AuthorizationDetails: req.AuthorizationDetails,
+		Nonce:                req.Nonce,
+		Claims:               req.Claims,
+		AuthorizationID:      req.AuthorizationID,
+		IssuedAt:             now,
+		ExpiresAt:            now.Add(g.lifetime),
+	}
+
+	if authn, ok := user.(UserWithAuthentication); ok {
+		code.AuthTime = authn.AuthTime()
+		code.ACR = authn.ACR()
+		code.AMR = authn.AMR()
+	}
+
+	if session, ok := user.(UserWithSession); ok {
+		code.SessionID = session.SessionID()
+	}
+
+	if err := g.codes.Create(ctx, code); err != nil {
+		return "", err
+	}
+
+	return code.Code, nil
+}
+
+func (g *authorizationCodeGrant) Token(ctx context.Context, req *AccessTokenRequest) (*AccessTokenResponse, error) {
+	client, authErr := AuthenticateClient(ctx, g.clients, req.ClientID, req.ClientSecret)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	if allows, ok := client.(ClientAllowsGrantType); ok && !allows.AllowsGrantType(GrantTypeAuthorizationCode) {
+		return nil, &OAuthError{
+			ErrorType:        ErrorTypeUnauthorizedClient,
+			ErrorDescription: "client may not use the authorization_code grant",
+			Cause:            ErrGrantTypeNotAllowed,
+		}
+	}
+
+	codeValue := req.Param(ParamCode)
+	if codeValue == "" {
+		return nil, MissingRequestParameterWithCause(ErrMissingCode, ParamCode)
+	}
+
+	code, err := g.codes.Consume(ctx, codeValue)
+	if err != nil {
+		return nil, err
+	}
+
+	if code == nil {
+		return nil, InvalidGrantWithCause(ErrInvalidAuthorizationCode, ErrInvalidAuthorizationCode.Error())
+	}
+
+	if code.ClientID != client.ID() {
+		return nil, InvalidGrantWithCause(ErrAuthorizationCodeClientMismatch, ErrInvalidAuthorizationCode.Error())
+	}
+
+	// https://datatracker.ietf.org/doc/html/rfc6749#section-4.1.3
+	if code.RedirectURI != req.Param(ParamRedirectURI) {
+		return nil, InvalidGrantWithCause(ErrRedirectURIMismatch, ErrRedirectURIMismatch.Error())
+	}
+
+	if pkceErr := g.verifyCodeVerifier(ctx, code, req.Param(ParamCodeVerifier)); pkceErr != nil {
+		return nil, pkceErr
+	}
+
+	audience, targetErr := tokenAudience(req.Resource, code.Resource)
+	if targetErr != nil {
+		return nil, targetErr
+	}
+
+	// https://datatracker.ietf.org/doc/html/rfc9396#section-6.1 token requests
+	// may narrow the granted authorization details, but not expand them.
+	details := code.AuthorizationDetails
+	if len(req.AuthorizationDetails) > 0 {
+		if !authorizationDetailsSubset(req.AuthorizationDetails, code.AuthorizationDetails) {
+			return nil, InvalidAuthorizationDetailsWithCause(
+				ErrAuthorizationDetailsNotGranted,
+				ErrAuthorizationDetailsNotGranted.Error(),
+			)
+		}
+		details = req.AuthorizationDetails
+	}
+
+	if scopeErr := req.ValidateScopes(ctx, client, code.UserID, code.Scope); scopeErr != nil {
+		return nil, scopeErr
+	}
+
+	resp, err := g.issuer.IssueAccessToken(ctx, &IssueTokenRequest{
+		Client:                      client,
+		UserID:                      code.UserID,
+		Scope:                       code.Scope,
+		Resource:                    code.Resource,
+		Audience:                    audience,
+		AuthorizationDetails:        details,
+		GrantedScope:                code.Scope,
+		GrantedAuthorizationDetails: code.AuthorizationDetails,
+		Claims:                      code.Claims,
+		AuthorizationID:             code.AuthorizationID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if g.idTokens != nil && slices.Contains(code.Scope, ScopeOpenID) {
+		resp.IDToken, err = g.idTokens.IssueIDToken(ctx, &IDTokenRequest{
+			Client:      client,
+			UserID:      code.UserID,
+			Scope:       code.Scope,
+			Nonce:       code.Nonce,
+			AuthTime:    code.AuthTime,
+			ACR:         code.ACR,
+			AMR:         code.AMR,
+			SessionID:   code.SessionID,
+			Claims:      code.Claims,
+			AccessToken: resp.AccessToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+func (g *authorizationCodeGrant) verifyCodeVerifier(ctx context.Context, code *AuthorizationCode, verifier string) *OAuthError {
+	if code.CodeChallenge == "" {
+		return nil
+	}
+
+	if verifier == "" {
+		return InvalidGrantWithCause(ErrMissingCodeVerifier, ErrMissingCodeVerifier.Error())
+	}
+
+	ok, err := g.pkce.VerifyCodeChallenge(ctx, code.CodeChallengeMethod, code.CodeChallenge, verifier)
+	if err != nil {
+		return MaybeWrapError(err)
+	}
+	if !ok {
+		return InvalidGrantWithCause(ErrInvalidCodeVerifier, ErrInvalidCodeVerifier.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,300 @@
+package oauth2server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+const testCodeVerifier = "dBjftJeZ4CVP-mJ92K9xnXXSrc0U0kEuBNE7T4FbU8g"
+const testCodeChallenge = "6qh5Bu2X061FKLznUnB21oGL6RebIshPS76e_eplS_w"
+
+type authorizationCodeTestCase struct {
+	clients *oauth2server.InMemoryClientRepository
+	codes   *oauth2server.InMemoryAuthorizationCodeRepository
+	tokens  *oauth2server.InMemoryAccessTokenRepository
+	grant   oauth2server.Grant
+	handler oauth2server.AuthorizationHandler
+}
+
+func startAuthorizationCodeTest(t *testing.T) *authorizationCodeTestCase {
+	t.Helper()
+
+	clients := oauth2server.NewInMemoryClientRepository()
+	clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	codes := oauth2server.NewInMemoryAuthorizationCodeRepository()
+	tokens := oauth2server.NewInMemoryAccessTokenRepository()
+	grant := oauth2server.NewAuthorizationCodeGrant(clients, codes, oauth2server.NewTokenIssuer(tokens))
+
+	return &authorizationCodeTestCase{
+		clients: clients,
+		codes:   codes,
+		tokens:  tokens,
+		grant:   grant,
+		handler: grant.(oauth2server.AuthorizationHandler),
+	}
+}
+
+func (tc *authorizationCodeTestCase) issueCode(t *testing.T, req *oauth2server.AuthorizationRequest) string {
+	t.Helper()
+
+	client, _ := tc.clients.Get(context.Background(), req.ClientID)
+	code, err := tc.handler.IssueAuthorizationResponse(context.Background(), client, req, &testUser{id: "user"})
+	if err != nil {
+		t.Fatalf("unexpected error issuing code: %v", err)
+	}
+
+	return code
+}
+
+func newCodeTokenRequest(t *testing.T, body map[string]string) *oauth2server.AccessTokenRequest {
+	t.Helper()
+
+	body[oauth2server.ParamGrantType] = oauth2server.GrantTypeAuthorizationCode
+	req := createRequestWithFormBody(http.MethodPost, "/token", body)
+	req.SetBasicAuth(testClientId, testClientSecret)
+
+	tokenReq, err := oauth2server.ParseAccessTokenRequest(req)
+	if err != nil {
+		t.Fatalf("unexpected error parsing token request: %v", err)
+	}
+
+	return tokenReq
+}
+
+func TestInMemoryAuthorizationCodeRepository_CodesCanOnlyBeConsumedOnce(t *testing.T) {
+	repo := oauth2server.NewInMemoryAuthorizationCodeRepository()
+	code := &oauth2server.AuthorizationCode{Code: "code", ExpiresAt: time.Now().Add(time.Minute)}
+	repo.Create(context.Background(), code)
+
+	found, err := repo.Consume(context.Background(), "code")
+	if err != nil || found != code {
+		t.Errorf("expected stored code, got %v %v", found, err)
+	}
+
+	found, err = repo.Consume(context.Background(), "code")
+	if err != nil || found != nil {
+		t.Errorf("expected no code on second consume, got %v %v", found, err)
+	}
+}
+
+func TestInMemoryAuthorizationCodeRepository_ExpiredCodesAreNotReturned(t *testing.T) {
+	repo := oauth2server.NewInMemoryAuthorizationCodeRepository()
+	repo.Create(context.Background(), &oauth2server.AuthorizationCode{Code: "code", ExpiresAt: time.Now().Add(-time.Second)})
+
+	found, err := repo.Consume(context.Background(), "code")
+
+	if err != nil || found != nil {
+		t.Errorf("expected no code once expired, got %v %v", found, err)
+	}
+}
+
+func TestAuthorizationCodeGrant_ValidateAuthorizationRequest_PublicClientsMustUsePKCE(t *testing.T) {
+	tc := startAuthorizationCodeTest(t)
+	client := oauth2server.NewPublicSimpleClient("public", []string{testRedirectUri})
+
+	err := tc.handler.ValidateAuthorizationRequest(context.Background(), client, &oauth2server.AuthorizationRequest{})
+
+	if !errors.Is(err, oauth2server.ErrCodeChallengeRequired) {
+		t.Errorf("expected ErrCodeChallengeRequired, got %v", err)
+	}
+}
+
+func TestAuthorizationCodeGrant_ValidateAuthorizationRequest_ValidatesCodeChallenge(t *testing.T) {
+	tc := startAuthorizationCodeTest(t)
+	client := oauth2server.NewPublicSimpleClient("public", []string{testRedirectUri})
+
+	err := tc.handler.ValidateAuthorizationRequest(context.Background(), client, &oauth2server.AuthorizationRequest{
+		CodeChallenge:       "short",
+		CodeChallengeMethod: oauth2server.CodeChallengeMethodS256,
+	})
+
+	if !errors.Is(err, oauth2server.ErrInvalidCodeChallenge) {
+		t.Errorf("expected ErrInvalidCodeChallenge, got %v", err)
+	}
+}
+
+func TestAuthorizationCodeGrant_Token_ErrorsIfCodeIsMissing(t *testing.T) {
+	tc := startAuthorizationCodeTest(t)
+
+	_, err := tc.grant.Token(context.Background(), newCodeTokenRequest(t, map[string]string{}))
+
+	if !errors.Is(err, oauth2server.ErrMissingCode) {
+		t.Errorf("expected ErrMissingCode, got %v", err)
+	}
+}
+
+func TestAuthorizationCodeGrant_Token_ErrorsIfCodeIsUnknown(t *testing.T) {
+	tc := startAuthorizationCodeTest(t)
+
+	_, err := tc.grant.Token(context.Background(), newCodeTokenRequest(t, map[string]string{
+		oauth2server.ParamCode: "nope",
+	}))
+
+	if !errors.Is(err, oauth2server.ErrInvalidAuthorizationCode) {
+		t.Errorf("expected ErrInvalidAuthorizationCode, got %v", err)
+	}
+}
+
+func TestAuthorizationCodeGrant_Token_ErrorsIfCodeWasIssuedToAnotherClient(t *testing.T) {
+	tc := startAuthorizationCodeTest(t)
+	tc.clients.Add(oauth2server.NewSimpleClient("other", "secret", []string{testRedirectUri}))
+	code := tc.issueCode(t, &oauth2server.AuthorizationRequest{ClientID: "other"})
+
+	_, err := tc.grant.Token(context.Background(), newCodeTokenRequest(t, map[string]string{
+		oauth2server.ParamCode: code,
+	}))
+
+	if !errors.Is(err, oauth2server.ErrAuthorizationCodeClientMismatch) {
+		t.Errorf("expected ErrAuthorizationCodeClientMismatch, got %v", err)
+	}
+}
+
+func TestAuthorizationCodeGrant_Token_ErrorsIfRedirectURIDoesNotMatch(t *testing.T) {
+	tc := startAuthorizationCodeTest(t)
+	code := tc.issueCode(t, &oauth2server.AuthorizationRequest{ClientID: testClientId, RedirectURI: testRedirectUri})
+
+	_, err := tc.grant.Token(context.Background(), newCodeTokenRequest(t, map[string]string{
+		oauth2server.ParamCode: code,
+	}))
+
+	if !errors.Is(err, oauth2server.ErrRedirectURIMismatch) {
+		t.Errorf("expected ErrRedirectURIMismatch, got %v", err)
+	}
+}
+
+func TestAuthorizationCodeGrant_Token_ErrorsIfCodeVerifierIsMissing(t *testing.T) {
+	tc := startAuthorizationCodeTest(t)
+	code := tc.issueCode(t, &oauth2server.AuthorizationRequest{
+		ClientID:            testClientId,
+		CodeChallenge:       testCodeChallenge,
+		CodeChallengeMethod: oauth2server.CodeChallengeMethodS256,
+	})
+
+	_, err := tc.grant.Token(context.Background(), newCodeTokenRequest(t, map[string]string{
+		oauth2server.ParamCode: code,
+	}))
+
+	if !errors.Is(err, oauth2server.ErrMissingCodeVerifier) {
+		t.Errorf("expected ErrMissingCodeVerifier, got %v", err)
+	}
+}
+
+func TestAuthorizationCodeGrant_Token_ErrorsIfCodeVerifierDoesNotMatch(t *testing.T) {
+	tc := startAuthorizationCodeTest(t)
+	code := tc.issueCode(t, &oauth2server.AuthorizationRequest{
+		ClientID:            testClientId,
+		CodeChallenge:       testCodeChallenge,
+		CodeChallengeMethod: oauth2server.CodeChallengeMethodS256,
+	})
+
+	_, err := tc.grant.Token(context.Background(), newCodeTokenRequest(t, map[string]string{
+		oauth2server.ParamCode:         code,
+		oauth2server.ParamCodeVerifier: "wrong-" + testCodeVerifier,
+	}))
+
+	if !errors.Is(err, oauth2server.ErrInvalidCodeVerifier) {
+		t.Errorf("expected ErrInvalidCodeVerifier, got %v", err)
+	}
+}
+
+func TestAuthorizationCodeGrant_Token_IssuesTokenWithGrantedAuthorizationDetails(t *testing.T) {
+	tc := startAuthorizationCodeTest(t)
+	details, _ := oauth2server.ParseAuthorizationDetails(testPaymentDetails)
+	code := tc.issueCode(t, &oauth2server.AuthorizationRequest{
+		ClientID:             testClientId,
+		RedirectURI:          testRedirectUri,
+		Scope:                []string{"read", "write"},
+		CodeChallenge:        testCodeChallenge,
+		CodeChallengeMethod:  oauth2server.CodeChallengeMethodS256,
+		AuthorizationDetails: details,
+	})
+
+	resp, err := tc.grant.Token(context.Background(), newCodeTokenRequest(t, map[string]string{
+		oauth2server.ParamCode:         code,
+		oauth2server.ParamRedirectURI:  testRedirectUri,
+		oauth2server.ParamCodeVerifier: testCodeVerifier,
+	}))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.TokenType != oauth2server.TokenTypeBearer || resp.Scope != "read write" {
+		t.Errorf("unexpected token response: %+v", resp)
+	}
+	if len(resp.AuthorizationDetails) != 1 || !resp.AuthorizationDetails[0].Equal(details[0]) {
+		t.Errorf("expected granted authorization details in response, got %+v", resp.AuthorizationDetails)
+	}
+
+	token, _ := tc.tokens.Get(context.Background(), resp.AccessToken)
+	if token == nil || token.UserID != "user" || len(token.AuthorizationDetails) != 1 {
+		t.Errorf("expected stored token with authorization details, got %+v", token)
+	}
+
+	_, err = tc.grant.Token(context.Background(), newCodeTokenRequest(t, map[string]string{
+		oauth2server.ParamCode:         code,
+		oauth2server.ParamRedirectURI:  testRedirectUri,
+		oauth2server.ParamCodeVerifier: testCodeVerifier,
+	}))
+	if !errors.Is(err, oauth2server.ErrInvalidAuthorizationCode) {
+		t.Errorf("expected codes to be single use, got %v", err)
+	}
+}
+
+func TestAuthorizationCodeGrant_Token_ErrorsIfAuthorizationDetailsWereNotGranted(t *testing.T) {
+	tc := startAuthorizationCodeTest(t)
+	code := tc.issueCode(t, &oauth2server.AuthorizationRequest{ClientID: testClientId})
+
+	_, err := tc.grant.Token(context.Background(), newCodeTokenRequest(t, map[string]string{
+		oauth2server.ParamCode:                 code,
+		oauth2server.ParamAuthorizationDetails: testPaymentDetails,
+	}))
+
+	if !errors.Is(err, oauth2server.ErrAuthorizationDetailsNotGranted) {
+		t.Errorf("expected ErrAuthorizationDetailsNotGranted, got %v", err)
+	}
+}
+
+func TestAuthorizationCodeGrant_Token_RefreshTokensKeepEverythingGranted(t *testing.T) {
+	tc := startAuthorizationCodeTest(t)
+	refreshTokens := oauth2server.NewInMemoryRefreshTokenRepository()
+	grant := oauth2server.NewAuthorizationCodeGrant(
+		tc.clients,
+		tc.codes,
+		oauth2server.NewTokenIssuer(tc.tokens, oauth2server.WithRefreshTokens(refreshTokens, 0)),
+	)
+	details, _ := oauth2server.ParseAuthorizationDetails(`[
+		{"type":"payment_initiation","actions":["initiate"],"instructedAmount":{"currency":"EUR","amount":"45.00"},"creditorAccount":{"iban":"DE02100100109307118603"}},
+		{"type":"account_information","actions":["read"]}
+	]`)
+	code := tc.issueCode(t, &oauth2server.AuthorizationRequest{
+		ClientID:             testClientId,
+		RedirectURI:          testRedirectUri,
+		Scope:                []string{"read"},
+		CodeChallenge:        testCodeChallenge,
+		CodeChallengeMethod:  oauth2server.CodeChallengeMethodS256,
+		AuthorizationDetails: details,
+	})
+
+	resp, err := grant.Token(context.Background(), newCodeTokenRequest(t, map[string]string{
+		oauth2server.ParamCode:                 code,
+		oauth2server.ParamRedirectURI:          testRedirectUri,
+		oauth2server.ParamCodeVerifier:         testCodeVerifier,
+		oauth2server.ParamAuthorizationDetails: testPaymentDetails,
+	}))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.AuthorizationDetails) != 1 {
+		t.Errorf("expected the access token to be narrowed, got %+v", resp.AuthorizationDetails)
+	}
+	refreshToken, _ := refreshTokens.Get(context.Background(), resp.RefreshToken)
+	if refreshToken == nil || len(refreshToken.AuthorizationDetails) != 2 {
+		t.Errorf("expected the refresh token to keep every granted detail, got %+v", refreshToken)
+	}
+}
@@ -0,0 +1,173 @@
+package oauth2server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// Rich Authorization Requests, see https://datatracker.ietf.org/doc/html/rfc9396
+const (
+	ParamAuthorizationDetails = "authorization_details"
+)
+
+// A single authorization details object. The common fields from the spec are
+// pulled out, type specific fields can be decoded from the raw JSON with
+// `Decode`.
+type AuthorizationDetail struct {
+	Type       string   `json:"type"`
+	Locations  []string `json:"locations,omitempty"`
+	Actions    []string `json:"actions,omitempty"`
+	DataTypes  []string `json:"datatypes,omitempty"`
+	Identifier string   `json:"identifier,omitempty"`
+	Privileges []string `json:"privileges,omitempty"`
+
+	// the full JSON object including any type specific fields.
+	Raw json.RawMessage `json:"-"`
+}
+
+// the alias drops the JSON methods below to avoid recursion
+type authorizationDetailFields AuthorizationDetail
+
+func (d AuthorizationDetail) MarshalJSON() ([]byte, error) {
+	if len(d.Raw) > 0 {
+		return d.Raw, nil
+	}
+
+	return json.Marshal(authorizationDetailFields(d))
+}
+
+func (d *AuthorizationDetail) UnmarshalJSON(b []byte) error {
+	var fields authorizationDetailFields
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+
+	*d = AuthorizationDetail(fields)
+	d.Raw = bytes.Clone(b)
+
+	return nil
+}
+
+// decode the full authorization detail object into a type specific struct
+func (d AuthorizationDetail) Decode(v any) error {
+	raw, err := d.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, v)
+}
+
+// true if the other authorization detail is the exact same object.
+func (d AuthorizationDetail) Equal(other AuthorizationDetail) bool {
+	a, errA := d.MarshalJSON()
+	b, errB := other.MarshalJSON()
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	var compactA, compactB bytes.Buffer
+	if json.Compact(&compactA, a) != nil || json.Compact(&compactB, b) != nil {
+		return false
+	}
+
+	return bytes.Equal(compactA.Bytes(), compactB.Bytes())
+}
+
+// parse the JSON array from an `authorization_details` parameter. An empty
+// value returns nil details.
+func ParseAuthorizationDetails(rawValue string) ([]AuthorizationDetail, *OAuthError) {
+	if rawValue == "" {
+		return nil, nil
+	}
+
+	var details []AuthorizationDetail
+	if err := json.Unmarshal([]byte(rawValue), &details); err != nil {
+		return nil, InvalidAuthorizationDetailsWithCause(
+			fmt.Errorf("%w: %w", ErrMalformedAuthorizationDetails, err),
+			"%s must be a JSON array of objects",
+			ParamAuthorizationDetails,
+		)
+	}
+
+	for _, d := range details {
+		if d.Type == "" {
+			return nil, InvalidAuthorizationDetailsWithCause(
+				ErrMalformedAuthorizationDetails,
+				"all %s must have a type",
+				ParamAuthorizationDetails,
+			)
+		}
+	}
+
+	return details, nil
+}
+
+// validates authorization details of a single type. Like scopes there are no
+// authorization detail entities, this is the extension point.
+type AuthorizationDetailsValidator interface {
+	// the authorization details type validated
+	Type() string
+
+	// Check the authorization detail and return an error if it's invalid. This
+	// should return an invalid_authorization_details error. Any other, non
+	// OAuthError returned will be transformed to a server_error
+	ValidateAuthorizationDetail(ctx context.Context, client Client, detail AuthorizationDetail) error
+}
+
+// extension point to let clients allowlist authorization details types, see
+// https://datatracker.ietf.org/doc/html/rfc9396#section-10
+type ClientAllowsAuthorizationDetailsTypes interface {
+	AuthorizationDetailsTypes() []string
+}
+
+// check authorization details against the client's allowed types and the
+// validator registered for each type. Types without a validator are not supported.
+func ValidateAuthorizationDetails(
+	ctx context.Context,
+	validators map[string]AuthorizationDetailsValidator,
+	client Client,
+	details []AuthorizationDetail,
+) *OAuthError {
+	allows, hasAllowlist := client.(ClientAllowsAuthorizationDetailsTypes)
+
+	for _, d := range details {
+		validator, ok := validators[d.Type]
+		if !ok {
+			return InvalidAuthorizationDetailsWithCause(
+				ErrUnsupportedAuthorizationDetailsType,
+				"%s authorization details are not supported",
+				d.Type,
+			)
+		}
+
+		if hasAllowlist && !slices.Contains(allows.AuthorizationDetailsTypes(), d.Type) {
+			return InvalidAuthorizationDetailsWithCause(
+				ErrAuthorizationDetailsTypeNotAllowed,
+				"client %s may not use %s authorization details",
+				client.ID(),
+				d.Type,
+			)
+		}
+
+		if err := validator.ValidateAuthorizationDetail(ctx, client, d); err != nil {
+			return MaybeWrapError(err)
+		}
+	}
+
+	return nil
+}
+
+// true if every requested detail is one of the granted details.
+func authorizationDetailsSubset(requested []AuthorizationDetail, granted []AuthorizationDetail) bool {
+	for _, r := range requested {
+		if !slices.ContainsFunc(granted, r.Equal) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,184 @@
+package oauth2server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+const testPaymentDetails = `[{"type":"payment_initiation","actions":["initiate"],"instructedAmount":{"currency":"EUR","amount":"45.00"},"creditorAccount":{"iban":"DE02100100109307118603"}}]`
+
+func TestParseAuthorizationDetails_ReturnsNilForEmptyValue(t *testing.T) {
+	details, err := oauth2server.ParseAuthorizationDetails("")
+
+	if err != nil || details != nil {
+		t.Errorf("expected no details or error, got %v %v", details, err)
+	}
+}
+
+func TestParseAuthorizationDetails_ErrorsOnInvalidJSON(t *testing.T) {
+	_, err := oauth2server.ParseAuthorizationDetails(`{"type":"nope"}`)
+
+	if !errors.Is(err, oauth2server.ErrMalformedAuthorizationDetails) {
+		t.Errorf("expected ErrMalformedAuthorizationDetails, got %v", err)
+	}
+	if err.ErrorType != oauth2server.ErrorTypeInvalidAuthorizationDetails {
+		t.Errorf("expected invalid_authorization_details error, got %q", err.ErrorType)
+	}
+}
+
+func TestParseAuthorizationDetails_ErrorsIfTypeIsMissing(t *testing.T) {
+	_, err := oauth2server.ParseAuthorizationDetails(`[{"actions":["read"]}]`)
+
+	if !errors.Is(err, oauth2server.ErrMalformedAuthorizationDetails) {
+		t.Errorf("expected ErrMalformedAuthorizationDetails, got %v", err)
+	}
+}
+
+func TestParseAuthorizationDetails_KeepsTypeSpecificFields(t *testing.T) {
+	details, err := oauth2server.ParseAuthorizationDetails(testPaymentDetails)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(details) != 1 || details[0].Type != "payment_initiation" || details[0].Actions[0] != "initiate" {
+		t.Fatalf("unexpected details: %+v", details)
+	}
+
+	var payment struct {
+		InstructedAmount struct {
+			Currency string `json:"currency"`
+			Amount   string `json:"amount"`
+		} `json:"instructedAmount"`
+	}
+	if err := details[0].Decode(&payment); err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if payment.InstructedAmount.Amount != "45.00" || payment.InstructedAmount.Currency != "EUR" {
+		t.Errorf("expected type specific fields to decode, got %+v", payment)
+	}
+
+	encoded, _ := json.Marshal(details)
+	roundTripped, _ := oauth2server.ParseAuthorizationDetails(string(encoded))
+	if !roundTripped[0].Equal(details[0]) {
+		t.Errorf("expected details to survive a JSON round trip, got %s", encoded)
+	}
+}
+
+func TestValidateAuthorizationDetails_ErrorsOnUnsupportedType(t *testing.T) {
+	client := oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri})
+	details, _ := oauth2server.ParseAuthorizationDetails(testPaymentDetails)
+
+	err := oauth2server.ValidateAuthorizationDetails(context.Background(), nil, client, details)
+
+	if !errors.Is(err, oauth2server.ErrUnsupportedAuthorizationDetailsType) {
+		t.Errorf("expected ErrUnsupportedAuthorizationDetailsType, got %v", err)
+	}
+}
+
+func TestValidateAuthorizationDetails_ErrorsIfClientDoesNotAllowType(t *testing.T) {
+	client := &detailsTypesClient{
+		Client: oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}),
+		types:  []string{"account_information"},
+	}
+	validator := &spyAuthorizationDetailsValidator{detailsType: "payment_initiation"}
+	details, _ := oauth2server.ParseAuthorizationDetails(testPaymentDetails)
+
+	err := oauth2server.ValidateAuthorizationDetails(
+		context.Background(),
+		map[string]oauth2server.AuthorizationDetailsValidator{validator.Type(): validator},
+		client,
+		details,
+	)
+
+	if !errors.Is(err, oauth2server.ErrAuthorizationDetailsTypeNotAllowed) {
+		t.Errorf("expected ErrAuthorizationDetailsTypeNotAllowed, got %v", err)
+	}
+	if len(validator.calls) != 0 {
+		t.Errorf("expected validator not to be called, got %d calls", len(validator.calls))
+	}
+}
+
+func TestValidateAuthorizationDetails_PropagatesValidatorErrors(t *testing.T) {
+	client := oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri})
+	validatorErr := oauth2server.InvalidAuthorizationDetails("amount too large")
+	validator := &spyAuthorizationDetailsValidator{detailsType: "payment_initiation", err: validatorErr}
+	details, _ := oauth2server.ParseAuthorizationDetails(testPaymentDetails)
+
+	err := oauth2server.ValidateAuthorizationDetails(
+		context.Background(),
+		map[string]oauth2server.AuthorizationDetailsValidator{validator.Type(): validator},
+		client,
+		details,
+	)
+
+	if err != validatorErr {
+		t.Errorf("expected validator error, got %v", err)
+	}
+}
+
+func TestValidateAuthorizationDetails_AcceptsValidDetails(t *testing.T) {
+	client := &detailsTypesClient{
+		Client: oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}),
+		types:  []string{"payment_initiation"},
+	}
+	validator := &spyAuthorizationDetailsValidator{detailsType: "payment_initiation"}
+	details, _ := oauth2server.ParseAuthorizationDetails(testPaymentDetails)
+
+	err := oauth2server.ValidateAuthorizationDetails(
+		context.Background(),
+		map[string]oauth2server.AuthorizationDetailsValidator{validator.Type(): validator},
+		client,
+		details,
+	)
+
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(validator.calls) != 1 || validator.calls[0].Type != "payment_initiation" {
+		t.Errorf("expected validator to be called with the detail, got %+v", validator.calls)
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_ValidatesAuthorizationDetails(t *testing.T) {
+	tc := startAuthorizationServerTest(t, oauth2server.WithAuthorizationHandler(&spyAuthorizationHandler{responseType: "code"}))
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamResponseType:         "code",
+		oauth2server.ParamClientID:             testClientId,
+		oauth2server.ParamAuthorizationDetails: testPaymentDetails,
+	})
+
+	authReq, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	tc.assertNotNilAuthRequest(t, authReq)
+	if !errors.Is(err, oauth2server.ErrUnsupportedAuthorizationDetailsType) {
+		t.Errorf("expected ErrUnsupportedAuthorizationDetailsType, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_IncludesValidAuthorizationDetails(t *testing.T) {
+	tc := startAuthorizationServerTest(
+		t,
+		oauth2server.WithAuthorizationHandler(&spyAuthorizationHandler{responseType: "code"}),
+		oauth2server.WithAuthorizationDetailsValidator(&spyAuthorizationDetailsValidator{detailsType: "payment_initiation"}),
+	)
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamResponseType:         "code",
+		oauth2server.ParamClientID:             testClientId,
+		oauth2server.ParamAuthorizationDetails: testPaymentDetails,
+	})
+
+	authReq, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(authReq.AuthorizationDetails) != 1 || authReq.AuthorizationDetails[0].Type != "payment_initiation" {
+		t.Errorf("expected authorization details on the request, got %+v", authReq.AuthorizationDetails)
+	}
+}
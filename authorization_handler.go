@@ -7,6 +7,13 @@ import (
 	"net/url"
 )
 
+const (
+	ResponseTypeCode    = "code"
+	ResponseTypeToken   = "token"
+	ResponseTypeIDToken = "id_token"
+	ResponseTypeNone    = "none"
+)
+
 // Represents a valid authorization request, this is meant to be able to be
 // serialized to JSON to be stored in something like a session so the /authorize
 // endpoint of a server can redirect a user for approval if necessary.
@@ -37,10 +44,68 @@ type AuthorizationRequest struct {
 	// the response types that were requested
 	ResponseType []string `json:"response_type,omitempty"`
 
+	// OpenID Connect nonce, included in issued ID tokens
+	Nonce string `json:"nonce,omitempty"`
+
+	// OpenID Connect individually requested claims, nil if not requested
+	Claims *ClaimsRequest `json:"claims,omitempty"`
+
+	// OpenID Connect prompt values, `none` is never combined with others
+	Prompt []string `json:"prompt,omitempty"`
+
+	// OpenID Connect max authentication age in seconds, nil if not requested
+	MaxAge *int `json:"max_age,omitempty"`
+
+	// OpenID Connect hint about the end-user's login identifier
+	LoginHint string `json:"login_hint,omitempty"`
+
+	// a previously issued ID token passed as a hint about the end-user's session
+	IDTokenHint string `json:"id_token_hint,omitempty"`
+
+	// requested authentication context class references in order of preference
+	ACRValues []string `json:"acr_values,omitempty"`
+
+	// the end-user's preferred languages for the UI as BCP47 tags
+	UILocales []string `json:"ui_locales,omitempty"`
+
+	// how the authorization server should display its pages, eg `page` or `popup`
+	Display string `json:"display,omitempty"`
+
+	// the resources the client wants to access, see https://datatracker.ietf.org/doc/html/rfc8707
+	Resource []string `json:"resource,omitempty"`
+
+	// rich authorization request details, see https://datatracker.ietf.org/doc/html/rfc9396
+	AuthorizationDetails []AuthorizationDetail `json:"authorization_details,omitempty"`
+
+	// the response mode included in the authorization request, empty if the
+	// client did not request a specific mode.
+	ResponseMode string `json:"response_mode,omitempty"`
+
+	// The response mode that's used to send the authorization response. This
+	// is either the requested response mode or the default for the requested
+	// response types.
+	FinalResponseMode string `json:"final_response_mode,omitempty"`
+
+	// whether the client asked for everything the end-user previously granted
+	// it to be included, see WithAuthorizationRecords
+	IncludeGrantedScopes bool `json:"include_granted_scopes,omitempty"`
+
+	// the ID of the AuthorizationRecord the request was merged into, this is
+	// set when the request is approved.
+	AuthorizationID string `json:"authorization_id,omitempty"`
+
+	// the `request_uri` of a pushed authorization request or request object
+	// this request was resolved from, empty if not used.
+	RequestURI string `json:"request_uri,omitempty"`
+
 	// the query string values
 	QueryString url.Values `json:"-"`
 }
 
+// parse an authorization request from the query string. If the request
+// contains a `request` or `request_uri` only the client ID and request URI are
+// parsed, the rest of the request is resolved from the pushed authorization
+// request or request object by the authorization server.
 func ParseAuthorizationRequest(req *http.Request) (*AuthorizationRequest, *OAuthError) {
 	if req.Method != http.MethodGet {
 		return nil, InvalidRequestWithCause(
@@ -58,35 +123,91 @@ func ParseAuthorizationRequest(req *http.Request) (*AuthorizationRequest, *OAuth
 		)
 	}
 
-	responseType := queryString.Get(ParamResponseType)
+	if queryString.Has(ParamRequest) || queryString.Has(ParamRequestURI) {
+		clientId := queryString.Get(ParamClientID)
+		if clientId == "" {
+			return nil, MissingRequestParameterWithCause(ErrMissingClientID, ParamClientID)
+		}
+
+		return &AuthorizationRequest{
+			ClientID:    clientId,
+			RequestURI:  queryString.Get(ParamRequestURI),
+			QueryString: queryString,
+		}, nil
+	}
+
+	return parseAuthorizationRequestValues(queryString)
+}
+
+func parseAuthorizationRequestValues(values url.Values) (*AuthorizationRequest, *OAuthError) {
+	responseType := values.Get(ParamResponseType)
 	if responseType == "" {
 		return nil, MissingRequestParameterWithCause(ErrMissingResponseType, ParamResponseType)
 	}
 
-	clientId := queryString.Get(ParamClientID)
+	clientId := values.Get(ParamClientID)
 	if clientId == "" {
 		// should this be an `invalid_client` error :thinking:
 		return nil, MissingRequestParameterWithCause(ErrMissingClientID, ParamClientID)
 	}
 
-	codeChallenge := queryString.Get(ParamCodeChallenge)
-	challengeMethod := queryString.Get(ParamCodeChallengeMethod)
+	authorizationDetails, detailsErr := ParseAuthorizationDetails(values.Get(ParamAuthorizationDetails))
+	if detailsErr != nil {
+		return nil, detailsErr
+	}
+
+	resources, resourceErr := ParseResourceParameter(values[ParamResource])
+	if resourceErr != nil {
+		return nil, resourceErr
+	}
+
+	claims, claimsErr := ParseClaimsRequest(values.Get(ParamClaims))
+	if claimsErr != nil {
+		return nil, claimsErr
+	}
+
+	maxAge, maxAgeErr := parseMaxAge(values.Get(ParamMaxAge))
+	if maxAgeErr != nil {
+		return nil, maxAgeErr
+	}
+
+	codeChallenge := values.Get(ParamCodeChallenge)
+	challengeMethod := values.Get(ParamCodeChallengeMethod)
 	// https://datatracker.ietf.org/doc/html/rfc7636#section-4.3
 	// defaults to plain if no present in the request
 	if codeChallenge != "" && challengeMethod == "" {
 		challengeMethod = CodeChallengeMethodPlain
 	}
 
-	return &AuthorizationRequest{
-		ClientID:            clientId,
-		ResponseType:        ParseSpaceSeparatedParameter(responseType),
-		RedirectURI:         queryString.Get(ParamRedirectURI),
-		Scope:               ParseSpaceSeparatedParameter(queryString.Get(ParamScope)),
-		State:               queryString.Get(ParamState),
-		CodeChallenge:       codeChallenge,
-		CodeChallengeMethod: challengeMethod,
-		QueryString:         queryString,
-	}, nil
+	authReq := &AuthorizationRequest{
+		ClientID:             clientId,
+		ResponseType:         ParseSpaceSeparatedParameter(responseType),
+		ResponseMode:         values.Get(ParamResponseMode),
+		RedirectURI:          values.Get(ParamRedirectURI),
+		Scope:                ParseSpaceSeparatedParameter(values.Get(ParamScope)),
+		State:                values.Get(ParamState),
+		Nonce:                values.Get(ParamNonce),
+		Claims:               claims,
+		Prompt:               ParseSpaceSeparatedParameter(values.Get(ParamPrompt)),
+		MaxAge:               maxAge,
+		LoginHint:            values.Get(ParamLoginHint),
+		IDTokenHint:          values.Get(ParamIDTokenHint),
+		ACRValues:            ParseSpaceSeparatedParameter(values.Get(ParamACRValues)),
+		UILocales:            ParseSpaceSeparatedParameter(values.Get(ParamUILocales)),
+		Display:              values.Get(ParamDisplay),
+		CodeChallenge:        codeChallenge,
+		CodeChallengeMethod:  challengeMethod,
+		Resource:             resources,
+		AuthorizationDetails: authorizationDetails,
+		IncludeGrantedScopes: values.Get(ParamIncludeGrantedScopes) == "true",
+		QueryString:          values,
+	}
+
+	if err := validateAuthenticationParameters(authReq); err != nil {
+		return nil, err
+	}
+
+	return authReq, nil
 }
 
 // Able to respond to requested `GrantType` in authorization requests. Eg a
@@ -113,3 +234,18 @@ type AuthorizationHandler interface {
 		user User,
 	) (string, error)
 }
+
+// authorization handlers that need the values issued by other handlers in the
+// same response implement this, eg `id_token` includes a hash of the `code`.
+// These are called after all other handlers instead of IssueAuthorizationResponse.
+type DependentAuthorizationHandler interface {
+	AuthorizationHandler
+
+	IssueDependentAuthorizationResponse(
+		ctx context.Context,
+		client Client,
+		req *AuthorizationRequest,
+		user User,
+		issued url.Values,
+	) (string, error)
+}
@@ -93,6 +93,7 @@ func TestParseAuthorizationRequest_ReturnsExpectedResponseIfValid(t *testing.T)
 	vals.Set(oauth2server.ParamCodeChallengeMethod, "plain")
 	vals.Set(oauth2server.ParamRedirectURI, "http://example.com")
 	vals.Set(oauth2server.ParamScope, "one  two ")
+	vals.Set(oauth2server.ParamResponseMode, "form_post")
 
 	httpReq := httptest.NewRequest(http.MethodGet, "/authorize?"+vals.Encode(), nil)
 	req, err := oauth2server.ParseAuthorizationRequest(httpReq)
@@ -126,6 +127,9 @@ func TestParseAuthorizationRequest_ReturnsExpectedResponseIfValid(t *testing.T)
 	if !slices.Equal(req.Scope, expectedScope) {
 		t.Errorf("bad scope: %+v != %+v", req.Scope, expectedScope)
 	}
+	if req.ResponseMode != "form_post" {
+		t.Errorf(`bad response mode: %q != "form_post"`, req.ResponseMode)
+	}
 }
 
 func TestParseAuthorizationRequest_MinimalRequestIsValid(t *testing.T) {
@@ -185,3 +189,37 @@ func TestParseAuthorizationRequest_SetsPlainCodeChallengeMethodIfMissing(t *test
 		t.Errorf("expected to default to %q code challenge method, got %q", oauth2server.CodeChallengeMethodPlain, req.CodeChallengeMethod)
 	}
 }
+
+func TestParseAuthorizationRequest_OnlyRequiresClientIDWithRequestURI(t *testing.T) {
+	vals := make(url.Values)
+	vals.Set(oauth2server.ParamClientID, "abc123")
+	vals.Set(oauth2server.ParamRequestURI, oauth2server.RequestURIPrefix+"abc")
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/authorize?"+vals.Encode(), nil)
+	req, err := oauth2server.ParseAuthorizationRequest(httpReq)
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if req.ClientID != "abc123" {
+		t.Errorf(`bad client id: %q != "abc123"`, req.ClientID)
+	}
+	if req.RequestURI != oauth2server.RequestURIPrefix+"abc" {
+		t.Errorf("bad request URI: %q", req.RequestURI)
+	}
+}
+
+func TestParseAuthorizationRequest_RequiresClientIDWithRequestURI(t *testing.T) {
+	vals := make(url.Values)
+	vals.Set(oauth2server.ParamRequestURI, oauth2server.RequestURIPrefix+"abc")
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/authorize?"+vals.Encode(), nil)
+	req, err := oauth2server.ParseAuthorizationRequest(httpReq)
+
+	if req != nil {
+		t.Errorf("expected request to be nil, got %T %v", req, req)
+	}
+	if !errors.Is(err, oauth2server.ErrMissingClientID) {
+		t.Errorf("expected an ErrMissingClientID: %+v", err)
+	}
+}
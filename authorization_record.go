@@ -0,0 +1,356 @@
+package oauth2server
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// everything an end-user has granted a client over time, there is one record
+// for each user and client. Approved authorization requests are merged into
+// it, and every code and token issued for the user and client carries its ID
+// so revoking the record revokes all of them.
+type AuthorizationRecord struct {
+	// the `authorization_id`
+	ID string
+
+	UserID string
+
+	ClientID string
+
+	// the union of every approved scope, resource and authorization detail
+	Scope                []string
+	Resource             []string
+	AuthorizationDetails []AuthorizationDetail
+
+	CreatedAt time.Time
+
+	// when an authorization request was last merged into the record
+	UpdatedAt time.Time
+}
+
+// merge an approved authorization request into the record
+func (r *AuthorizationRecord) merge(req *AuthorizationRequest) {
+	r.Scope = union(r.Scope, req.Scope)
+	r.Resource = union(r.Resource, req.Resource)
+	r.AuthorizationDetails = unionAuthorizationDetails(r.AuthorizationDetails, req.AuthorizationDetails)
+}
+
+func unionAuthorizationDetails(a []AuthorizationDetail, b []AuthorizationDetail) []AuthorizationDetail {
+	merged := slices.Clone(a)
+	for _, d := range b {
+		if !slices.ContainsFunc(merged, d.Equal) {
+			merged = append(merged, d)
+		}
+	}
+
+	return merged
+}
+
+// durable storage for authorization records.
+type AuthorizationRecordRepository interface {
+	// Get a record by its ID, return a `nil` record if not found. Any errors
+	// returned here will be propagated as server errors.
+	Get(ctx context.Context, id string) (*AuthorizationRecord, error)
+
+	// Find the record for the user and client, return a `nil` record if the
+	// user has not authorized the client.
+	Find(ctx context.Context, userId string, clientId string) (*AuthorizationRecord, error)
+
+	// every record for the user
+	ListForUser(ctx context.Context, userId string) ([]*AuthorizationRecord, error)
+
+	// store a new or updated record
+	Save(ctx context.Context, record *AuthorizationRecord) error
+
+	// remove the record, this does not remove codes or tokens. See
+	// AuthorizationRevoker for that.
+	Delete(ctx context.Context, id string) error
+}
+
+type InMemoryAuthorizationRecordRepository struct {
+	lock    sync.RWMutex
+	records map[string]*AuthorizationRecord
+}
+
+func NewInMemoryAuthorizationRecordRepository() *InMemoryAuthorizationRecordRepository {
+	return &InMemoryAuthorizationRecordRepository{
+		records: make(map[string]*AuthorizationRecord),
+	}
+}
+
+func (r *InMemoryAuthorizationRecordRepository) Get(ctx context.Context, id string) (*AuthorizationRecord, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	record, _ := r.records[id]
+
+	return record, nil
+}
+
+func (r *InMemoryAuthorizationRecordRepository) Find(ctx context.Context, userId string, clientId string) (*AuthorizationRecord, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, record := range r.records {
+		if record.UserID == userId && record.ClientID == clientId {
+			return record, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *InMemoryAuthorizationRecordRepository) ListForUser(ctx context.Context, userId string) ([]*AuthorizationRecord, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var records []*AuthorizationRecord
+	for _, record := range r.records {
+		if record.UserID == userId {
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
+func (r *InMemoryAuthorizationRecordRepository) Save(ctx context.Context, record *AuthorizationRecord) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.records[record.ID] = record
+
+	return nil
+}
+
+func (r *InMemoryAuthorizationRecordRepository) Delete(ctx context.Context, id string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.records, id)
+
+	return nil
+}
+
+// keep a record of what each user granted each client. Authorization requests
+// with `include_granted_scopes=true` are issued codes and tokens for
+// everything in the record, not just what was approved in the request.
+func WithAuthorizationRecords(records AuthorizationRecordRepository) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.authorizationRecords = records
+	}
+}
+
+// merge the approved request into the user's record for the client and tie
+// the request to the record.
+func (s *defaultAuthorizationServer) recordAuthorization(ctx context.Context, client Client, req *AuthorizationRequest, user User) error {
+	if s.authorizationRecords == nil {
+		return nil
+	}
+
+	existing, err := s.authorizationRecords.Find(ctx, user.ID(), client.ID())
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var record AuthorizationRecord
+	if existing != nil {
+		record = *existing
+	} else {
+		id, err := generateRandomToken()
+		if err != nil {
+			return err
+		}
+		record = AuthorizationRecord{
+			ID:        id,
+			UserID:    user.ID(),
+			ClientID:  client.ID(),
+			CreatedAt: now,
+		}
+	}
+
+	record.merge(req)
+	record.UpdatedAt = now
+	if err := s.authorizationRecords.Save(ctx, &record); err != nil {
+		return err
+	}
+
+	req.AuthorizationID = record.ID
+	if req.IncludeGrantedScopes {
+		req.Scope = record.Scope
+		req.Resource = record.Resource
+		req.AuthorizationDetails = record.AuthorizationDetails
+	}
+
+	return nil
+}
+
+// what a revocation removes: everything the user granted the client, or
+// every client if the client ID is empty. An authorization ID narrows it to
+// what was issued under that one authorization record.
+type revocation struct {
+	userId          string
+	clientId        string
+	authorizationId string
+}
+
+// the repositories checked by AuthorizationRevoker and ConnectedApps, nil
+// repositories are skipped.
+type revocationCascade struct {
+	records       AuthorizationRecordRepository
+	codes         AuthorizationCodeRepository
+	refreshTokens RefreshTokenRepository
+	accessTokens  AccessTokenRepository
+	consents      ConsentRepository
+}
+
+// codes and tokens, which can be revoked in the same ways
+type issuedGrantRepository interface {
+	RevokeAuthorization(ctx context.Context, authorizationId string) error
+	RevokeForUser(ctx context.Context, userId string, clientId string) error
+}
+
+func (c *revocationCascade) revoke(ctx context.Context, rev revocation) error {
+	// records and consent go first so nothing new can be issued while the
+	// codes and tokens are removed.
+	if err := c.revokeRecords(ctx, rev); err != nil {
+		return err
+	}
+	if err := c.revokeConsent(ctx, rev); err != nil {
+		return err
+	}
+
+	for _, issued := range []issuedGrantRepository{c.codes, c.refreshTokens, c.accessTokens} {
+		if issued == nil {
+			continue
+		}
+
+		var err error
+		if rev.authorizationId != "" {
+			err = issued.RevokeAuthorization(ctx, rev.authorizationId)
+		} else {
+			err = issued.RevokeForUser(ctx, rev.userId, rev.clientId)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *revocationCascade) revokeRecords(ctx context.Context, rev revocation) error {
+	if c.records == nil {
+		return nil
+	}
+	if rev.authorizationId != "" {
+		return c.records.Delete(ctx, rev.authorizationId)
+	}
+
+	records, err := c.records.ListForUser(ctx, rev.userId)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if rev.clientId != "" && record.ClientID != rev.clientId {
+			continue
+		}
+		if err := c.records.Delete(ctx, record.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *revocationCascade) revokeConsent(ctx context.Context, rev revocation) error {
+	if c.consents == nil || rev.userId == "" {
+		return nil
+	}
+	if rev.clientId != "" {
+		return c.consents.Revoke(ctx, rev.userId, rev.clientId)
+	}
+
+	consents, err := c.consents.ListForUser(ctx, rev.userId)
+	if err != nil {
+		return err
+	}
+	for _, consent := range consents {
+		if err := c.consents.Revoke(ctx, rev.userId, consent.ClientID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Revokes authorization records along with everything issued under them.
+type AuthorizationRevoker interface {
+	// remove the record and every authorization code, refresh token, and
+	// access token issued under it.
+	Revoke(ctx context.Context, authorizationId string) error
+}
+
+type authorizationRevoker struct {
+	revocationCascade
+}
+
+type AuthorizationRevokerOption func(*authorizationRevoker)
+
+// forget the user's remembered consent for the client as well so they are
+// asked again the next time the client requests authorization.
+func WithRevokedConsent(consents ConsentRepository) AuthorizationRevokerOption {
+	return func(r *authorizationRevoker) {
+		r.consents = consents
+	}
+}
+
+// revoke from the given repositories, nil repositories are skipped.
+func NewAuthorizationRevoker(
+	records AuthorizationRecordRepository,
+	codes AuthorizationCodeRepository,
+	refreshTokens RefreshTokenRepository,
+	accessTokens AccessTokenRepository,
+	opts ...AuthorizationRevokerOption,
+) AuthorizationRevoker {
+	r := &authorizationRevoker{
+		revocationCascade: revocationCascade{
+			records:       records,
+			codes:         codes,
+			refreshTokens: refreshTokens,
+			accessTokens:  accessTokens,
+		},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+func (r *authorizationRevoker) Revoke(ctx context.Context, authorizationId string) error {
+	if authorizationId == "" {
+		return nil
+	}
+
+	// without records codes and tokens are still revoked by authorization ID,
+	// but there is no user or client to forget consent for.
+	rev := revocation{authorizationId: authorizationId}
+	if r.records == nil {
+		return r.revoke(ctx, rev)
+	}
+
+	record, err := r.records.Get(ctx, authorizationId)
+	if err != nil {
+		return err
+	}
+	if record != nil {
+		rev.userId = record.UserID
+		rev.clientId = record.ClientID
+	}
+
+	return r.revoke(ctx, rev)
+}
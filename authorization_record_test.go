@@ -0,0 +1,222 @@
+package oauth2server_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+type authorizationRecordTestCase struct {
+	clients       *oauth2server.InMemoryClientRepository
+	codes         *oauth2server.InMemoryAuthorizationCodeRepository
+	accessTokens  *oauth2server.InMemoryAccessTokenRepository
+	refreshTokens *oauth2server.InMemoryRefreshTokenRepository
+	records       *oauth2server.InMemoryAuthorizationRecordRepository
+	consents      *oauth2server.InMemoryConsentRepository
+	codeGrant     oauth2server.Grant
+	refreshGrant  oauth2server.Grant
+	server        oauth2server.AuthorizationServer
+}
+
+func startAuthorizationRecordTest(t *testing.T) *authorizationRecordTestCase {
+	t.Helper()
+
+	clients := oauth2server.NewInMemoryClientRepository()
+	clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	codes := oauth2server.NewInMemoryAuthorizationCodeRepository()
+	accessTokens := oauth2server.NewInMemoryAccessTokenRepository()
+	refreshTokens := oauth2server.NewInMemoryRefreshTokenRepository()
+	records := oauth2server.NewInMemoryAuthorizationRecordRepository()
+	consents := oauth2server.NewInMemoryConsentRepository()
+	issuer := oauth2server.NewTokenIssuer(accessTokens, oauth2server.WithRefreshTokens(refreshTokens, 0))
+	codeGrant := oauth2server.NewAuthorizationCodeGrant(clients, codes, issuer)
+
+	return &authorizationRecordTestCase{
+		clients:       clients,
+		codes:         codes,
+		accessTokens:  accessTokens,
+		refreshTokens: refreshTokens,
+		records:       records,
+		consents:      consents,
+		codeGrant:     codeGrant,
+		refreshGrant:  oauth2server.NewRefreshTokenGrant(clients, refreshTokens, issuer, oauth2server.WithRefreshTokenAuthorizationRecords(records)),
+		server: oauth2server.NewAuthorizationServer(
+			clients,
+			oauth2server.WithGrant(codeGrant),
+			oauth2server.WithAuthorizationRecords(records),
+			oauth2server.WithConsent(consents, 0),
+		),
+	}
+}
+
+func (tc *authorizationRecordTestCase) authorize(t *testing.T, includeGranted bool, scope ...string) string {
+	t.Helper()
+
+	params, err := tc.server.CompleteAuthorizationRequest(context.Background(), &oauth2server.AuthorizationRequest{
+		ClientID:             testClientId,
+		RedirectURI:          testRedirectUri,
+		ResponseType:         []string{oauth2server.ResponseTypeCode},
+		Scope:                scope,
+		IncludeGrantedScopes: includeGranted,
+	}, &testUser{id: "user"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return params.Get(oauth2server.ResponseTypeCode)
+}
+
+func (tc *authorizationRecordTestCase) exchange(t *testing.T, code string) *oauth2server.AccessTokenResponse {
+	t.Helper()
+
+	resp, err := tc.codeGrant.Token(context.Background(), newCodeTokenRequest(t, map[string]string{
+		oauth2server.ParamCode:        code,
+		oauth2server.ParamRedirectURI: testRedirectUri,
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return resp
+}
+
+func (tc *authorizationRecordTestCase) record(t *testing.T) *oauth2server.AuthorizationRecord {
+	t.Helper()
+
+	record, _ := tc.records.Find(context.Background(), "user", testClientId)
+	if record == nil {
+		t.Fatal("expected an authorization record for the user and client")
+	}
+
+	return record
+}
+
+func TestParseAuthorizationRequest_ParsesIncludeGrantedScopes(t *testing.T) {
+	req, err := parseTestAuthenticationRequest(map[string]string{oauth2server.ParamIncludeGrantedScopes: "true"})
+
+	if err != nil || !req.IncludeGrantedScopes {
+		t.Errorf("expected include_granted_scopes to be parsed, got %+v %v", req, err)
+	}
+}
+
+func TestDefaultAuthorizationServer_ApproveAuthorizationRequest_MergesIntoAuthorizationRecord(t *testing.T) {
+	tc := startAuthorizationRecordTest(t)
+	tc.authorize(t, false, "read")
+	first := tc.record(t)
+
+	resp := tc.exchange(t, tc.authorize(t, false, "write"))
+
+	record := tc.record(t)
+	if record.ID != first.ID || !cmp.Equal([]string{"read", "write"}, record.Scope) {
+		t.Errorf("expected approved scopes merged into the same record, got %+v", record)
+	}
+	if resp.Scope != "write" {
+		t.Errorf("expected only the approved scope without include_granted_scopes, got %q", resp.Scope)
+	}
+	token, _ := tc.accessTokens.Get(context.Background(), resp.AccessToken)
+	if token.AuthorizationID != record.ID {
+		t.Errorf("expected token to be tied to the record, got %q", token.AuthorizationID)
+	}
+}
+
+func TestDefaultAuthorizationServer_ApproveAuthorizationRequest_IncludesGrantedScopes(t *testing.T) {
+	tc := startAuthorizationRecordTest(t)
+	tc.authorize(t, false, "read")
+
+	resp := tc.exchange(t, tc.authorize(t, true, "write"))
+
+	if resp.Scope != "read write" {
+		t.Errorf("expected previously granted scopes to be included, got %q", resp.Scope)
+	}
+}
+
+func TestRefreshTokenGrant_Token_UsesAuthorizationRecord(t *testing.T) {
+	tc := startAuthorizationRecordTest(t)
+	first := tc.exchange(t, tc.authorize(t, false, "read"))
+	tc.authorize(t, false, "write")
+
+	resp, err := tc.refreshGrant.Token(context.Background(), newRefreshTokenRequest(t, map[string]string{
+		oauth2server.ParamRefreshToken: first.RefreshToken,
+	}))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Scope != "read write" {
+		t.Errorf("expected everything in the record, got %q", resp.Scope)
+	}
+}
+
+func TestAuthorizationRevoker_Revoke_RemovesEverythingIssuedUnderTheRecord(t *testing.T) {
+	tc := startAuthorizationRecordTest(t)
+	resp := tc.exchange(t, tc.authorize(t, false, "read"))
+	code := tc.authorize(t, false, "write")
+	other := &oauth2server.AccessToken{Token: "other", ClientID: testClientId, AuthorizationID: "other"}
+	tc.accessTokens.Create(context.Background(), other)
+	revoker := oauth2server.NewAuthorizationRevoker(
+		tc.records,
+		tc.codes,
+		tc.refreshTokens,
+		tc.accessTokens,
+		oauth2server.WithRevokedConsent(tc.consents),
+	)
+
+	if err := revoker.Revoke(context.Background(), tc.record(t).ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if record, _ := tc.records.Find(context.Background(), "user", testClientId); record != nil {
+		t.Errorf("expected record to be removed, got %+v", record)
+	}
+	if c, _ := tc.codes.Consume(context.Background(), code); c != nil {
+		t.Errorf("expected code to be revoked, got %+v", c)
+	}
+	if token, _ := tc.accessTokens.Get(context.Background(), resp.AccessToken); token != nil {
+		t.Errorf("expected access token to be revoked, got %+v", token)
+	}
+	if token, _ := tc.refreshTokens.Get(context.Background(), resp.RefreshToken); token != nil {
+		t.Errorf("expected refresh token to be revoked, got %+v", token)
+	}
+	if consent, _ := tc.consents.Get(context.Background(), "user", testClientId); consent != nil {
+		t.Errorf("expected consent to be forgotten, got %+v", consent)
+	}
+	if token, _ := tc.accessTokens.Get(context.Background(), "other"); token != other {
+		t.Errorf("expected tokens from other records to be left alone, got %+v", token)
+	}
+}
+
+func TestAuthorizationRevoker_Revoke_SkipsMissingRecordRepository(t *testing.T) {
+	tc := startAuthorizationRecordTest(t)
+	resp := tc.exchange(t, tc.authorize(t, false, "read"))
+	record := tc.record(t)
+	revoker := oauth2server.NewAuthorizationRevoker(nil, tc.codes, tc.refreshTokens, tc.accessTokens)
+
+	if err := revoker.Revoke(context.Background(), record.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if token, _ := tc.accessTokens.Get(context.Background(), resp.AccessToken); token != nil {
+		t.Errorf("expected access token to be revoked, got %+v", token)
+	}
+	if token, _ := tc.refreshTokens.Get(context.Background(), resp.RefreshToken); token != nil {
+		t.Errorf("expected refresh token to be revoked, got %+v", token)
+	}
+}
+
+func TestRefreshTokenGrant_Token_ErrorsIfAuthorizationRecordWasRemoved(t *testing.T) {
+	tc := startAuthorizationRecordTest(t)
+	resp := tc.exchange(t, tc.authorize(t, false, "read"))
+	tc.records.Delete(context.Background(), tc.record(t).ID)
+
+	_, err := tc.refreshGrant.Token(context.Background(), newRefreshTokenRequest(t, map[string]string{
+		oauth2server.ParamRefreshToken: resp.RefreshToken,
+	}))
+
+	if !errors.Is(err, oauth2server.ErrAuthorizationRevoked) {
+		t.Errorf("expected ErrAuthorizationRevoked, got %v", err)
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 var (
@@ -24,6 +26,8 @@ type AuthorizationServer interface {
 	// return it. If both an authorization requset AND error are returned here, the user
 	// may be redirected with an error. If only an error is returned, the user _must not_
 	// be redirected and the error should be show on the authorization server.
+	// Once the end-user is known, use RequiresConsent to decide whether they
+	// need to approve the request.
 	ValidateAuthorizationRequest(ctx context.Context, req *http.Request) (*AuthorizationRequest, *OAuthError)
 
 	// Deny the given authorization request. This can be for whatever reason: a user denied
@@ -34,13 +38,64 @@ type AuthorizationServer interface {
 	// complete the authorization request and returns a set of `url.Values` that can be used
 	// to redirect to the user or an error that can be used to redirect with an error
 	CompleteAuthorizationRequest(ctx context.Context, req *AuthorizationRequest, user User) (url.Values, *OAuthError)
+
+	// like CompleteAuthorizationRequest, but only what the end-user approved
+	// in the decision is granted. Codes and tokens are issued for the approved
+	// scopes, resources, and authorization details.
+	ApproveAuthorizationRequest(ctx context.Context, req *AuthorizationRequest, user User, decision *ConsentDecision) (url.Values, *OAuthError)
+
+	// whether the end-user must approve the validated authorization request,
+	// call this once the end-user is known. Requests with `prompt=consent`
+	// always need consent, first party clients and requests covered by
+	// remembered consent do not. If consent is needed but the request is
+	// silent the `consent_required` error to send back is returned as well.
+	RequiresConsent(ctx context.Context, req *AuthorizationRequest, user User) (bool, *OAuthError)
+
+	// send the values from `CompleteAuthorizationRequest` back to the client
+	// using the authorization request's response mode.
+	RespondToAuthorizationRequest(ctx context.Context, w http.ResponseWriter, req *AuthorizationRequest, params url.Values) error
+
+	// send a redirectable error back to the client using the authorization
+	// request's response mode. This should only be used if
+	// `ValidateAuthorizationRequest` returned an authorization request along
+	// with its error.
+	RespondWithAuthorizationError(ctx context.Context, w http.ResponseWriter, req *AuthorizationRequest, err *OAuthError) error
+
+	// authenticate the client and validate a pushed authorization request,
+	// storing it to be used later via its `request_uri`. Errors here should be
+	// sent back as JSON via `RespondWithError`.
+	PushAuthorizationRequest(ctx context.Context, req *http.Request) (*PushedAuthorizationResponse, *OAuthError)
+
+	// authenticate the caller and introspect the token in the request. Only
+	// resource servers and confidential clients may introspect tokens, clients
+	// only their own.
+	Introspect(ctx context.Context, req *http.Request) (*IntrospectionResponse, *OAuthError)
 }
 
 type ServerOptions struct {
 	grants                map[string]Grant
 	authorizationHandlers map[string]AuthorizationHandler
+	responseModes         map[string]ResponseModeHandler
 	scopeValidator        ScopeValidator
 	pkce                  PKCE
+	pushedRequests        PushedAuthorizationRequestRepository
+	pushedRequestLifetime time.Duration
+	requirePushedRequests bool
+	requestObjects        bool
+	requestObjectIssuer   string
+	requestObjectFetcher  Fetcher
+	strictRequestObjects  bool
+	authorizationDetails  map[string]AuthorizationDetailsValidator
+	introspectionTokens   AccessTokenRepository
+	resourceValidator     ResourceValidator
+	resourceServers       ResourceServerRepository
+	users                 UserRepository
+	subjects              SubjectIdentifierStrategy
+	consents              ConsentRepository
+	consentLifetime       time.Duration
+	authorizationRecords  AuthorizationRecordRepository
+	scopeClassifier       ScopeClassifier
+	adminApprovals        AdminApprovalRepository
 }
 
 type ServerOption func(*ServerOptions)
@@ -60,30 +115,87 @@ func WithAuthorizationHandler(handler AuthorizationHandler) ServerOption {
 	}
 }
 
+// add or replace a response mode handler. The `query`, `fragment`, and
+// `form_post` response modes are supported by default.
+func WithResponseModeHandler(handler ResponseModeHandler) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.responseModes[handler.ResponseMode()] = handler
+	}
+}
+
 func WithScopeValidator(s ScopeValidator) ServerOption {
 	return func(opts *ServerOptions) {
 		opts.scopeValidator = s
 	}
 }
 
+// add or replace the validator for an authorization details type, requests
+// with authorization details types that have no validator are rejected.
+func WithAuthorizationDetailsValidator(v AuthorizationDetailsValidator) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.authorizationDetails[v.Type()] = v
+	}
+}
+
 func WithPKCE(p PKCE) ServerOption {
 	return func(opts *ServerOptions) {
 		opts.pkce = p
 	}
 }
 
+// enable pushed authorization requests, requests are stored in the repository
+// for lifetime. A zero lifetime uses DefaultPushedAuthorizationRequestLifetime.
+func WithPushedAuthorizationRequests(repo PushedAuthorizationRequestRepository, lifetime time.Duration) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.pushedRequests = repo
+		opts.pushedRequestLifetime = lifetime
+	}
+}
+
+// require all authorization requests to be pushed, without this clients can
+// opt in via `ClientRequiresPushedAuthorizationRequests`.
+func WithRequirePushedAuthorizationRequests() ServerOption {
+	return func(opts *ServerOptions) {
+		opts.requirePushedRequests = true
+	}
+}
+
 type defaultAuthorizationServer struct {
 	clients               ClientRepository
 	scopeValidator        ScopeValidator
 	pkce                  PKCE
 	grants                map[string]Grant
 	authorizationHandlers map[string]AuthorizationHandler
+	responseModes         map[string]ResponseModeHandler
+	pushedRequests        PushedAuthorizationRequestRepository
+	pushedRequestLifetime time.Duration
+	requirePushedRequests bool
+	requestObjects        bool
+	requestObjectIssuer   string
+	requestObjectFetcher  Fetcher
+	strictRequestObjects  bool
+	authorizationDetails  map[string]AuthorizationDetailsValidator
+	introspectionTokens   AccessTokenRepository
+	resourceValidator     ResourceValidator
+	resourceServers       ResourceServerRepository
+	users                 UserRepository
+	subjects              SubjectIdentifierStrategy
+	consents              ConsentRepository
+	consentLifetime       time.Duration
+	authorizationRecords  AuthorizationRecordRepository
+	scopeClassifier       ScopeClassifier
+	adminApprovals        AdminApprovalRepository
 }
 
 func NewAuthorizationServer(clients ClientRepository, config ...ServerOption) AuthorizationServer {
 	options := &ServerOptions{
 		grants:                make(map[string]Grant),
 		authorizationHandlers: make(map[string]AuthorizationHandler),
+		responseModes:         make(map[string]ResponseModeHandler),
+		authorizationDetails:  make(map[string]AuthorizationDetailsValidator),
+	}
+	for _, m := range []ResponseModeHandler{QueryResponseMode(), FragmentResponseMode(), FormPostResponseMode()} {
+		options.responseModes[m.ResponseMode()] = m
 	}
 	for _, c := range config {
 		c(options)
@@ -97,12 +209,39 @@ func NewAuthorizationServer(clients ClientRepository, config ...ServerOption) Au
 		options.pkce = NewDefaultPKCE()
 	}
 
+	if options.resourceValidator == nil && options.resourceServers != nil {
+		options.resourceValidator = ValidateResourcesWithRepository(options.resourceServers)
+	}
+
+	if options.pushedRequestLifetime <= 0 {
+		options.pushedRequestLifetime = DefaultPushedAuthorizationRequestLifetime
+	}
+
 	return &defaultAuthorizationServer{
 		clients:               clients,
 		scopeValidator:        options.scopeValidator,
 		pkce:                  options.pkce,
 		grants:                options.grants,
 		authorizationHandlers: options.authorizationHandlers,
+		responseModes:         options.responseModes,
+		pushedRequests:        options.pushedRequests,
+		pushedRequestLifetime: options.pushedRequestLifetime,
+		requirePushedRequests: options.requirePushedRequests,
+		requestObjects:        options.requestObjects,
+		requestObjectIssuer:   options.requestObjectIssuer,
+		requestObjectFetcher:  options.requestObjectFetcher,
+		strictRequestObjects:  options.strictRequestObjects,
+		authorizationDetails:  options.authorizationDetails,
+		introspectionTokens:   options.introspectionTokens,
+		resourceValidator:     options.resourceValidator,
+		resourceServers:       options.resourceServers,
+		users:                 options.users,
+		subjects:              options.subjects,
+		consents:              options.consents,
+		consentLifetime:       options.consentLifetime,
+		authorizationRecords:  options.authorizationRecords,
+		scopeClassifier:       options.scopeClassifier,
+		adminApprovals:        options.adminApprovals,
 	}
 }
 
@@ -117,6 +256,37 @@ func (s *defaultAuthorizationServer) ValidateAuthorizationRequest(ctx context.Co
 		return nil, clientErr
 	}
 
+	switch {
+	case strings.HasPrefix(authReq.RequestURI, RequestURIPrefix):
+		authReq, err = s.resolvePushedAuthorizationRequest(ctx, client, authReq)
+		if err != nil {
+			return nil, err
+		}
+	case s.requiresPushedAuthorizationRequests(client):
+		return nil, InvalidRequestWithCause(
+			ErrPushedAuthorizationRequired,
+			"client %s must use pushed authorization requests",
+			client.ID(),
+		)
+	case authReq.RequestURI != "" || authReq.QueryString.Has(ParamRequest):
+		values, objErr := s.resolveRequestObject(ctx, client, authReq.QueryString)
+		if objErr != nil {
+			return nil, objErr
+		}
+
+		requestUri := authReq.RequestURI
+		authReq, err = parseAuthorizationRequestValues(values)
+		if err != nil {
+			return nil, err
+		}
+		authReq.RequestURI = requestUri
+	}
+
+	return s.validateAuthorizationRequest(ctx, client, authReq)
+}
+
+// the validation shared by the authorization and pushed authorization endpoints
+func (s *defaultAuthorizationServer) validateAuthorizationRequest(ctx context.Context, client Client, authReq *AuthorizationRequest) (*AuthorizationRequest, *OAuthError) {
 	finalRedirectUri, reErr := ValidateRedirectURI(ctx, client, authReq.RedirectURI)
 	if reErr != nil {
 		return nil, reErr
@@ -130,6 +300,26 @@ func (s *defaultAuthorizationServer) ValidateAuthorizationRequest(ctx context.Co
 		return authReq, err
 	}
 
+	if err := s.checkAuthorizationResponseMode(client, authReq); err != nil {
+		return authReq, err
+	}
+
+	if err := s.validateAuthorizationScopes(ctx, client, authReq); err != nil {
+		return authReq, err
+	}
+
+	if err := ValidateAuthorizationDetails(ctx, s.authorizationDetails, client, authReq.AuthorizationDetails); err != nil {
+		return authReq, err
+	}
+
+	if err := s.validateResources(ctx, client, authReq.Resource, authReq.Scope); err != nil {
+		return authReq, err
+	}
+
+	if err := s.checkAdminApproval(ctx, client, authReq.Scope); err != nil {
+		return authReq, err
+	}
+
 	for _, k := range authReq.ResponseType {
 		if err := s.authorizationHandlers[k].ValidateAuthorizationRequest(ctx, client, authReq); err != nil {
 			return authReq, MaybeWrapError(err)
@@ -147,8 +337,196 @@ func (s *defaultAuthorizationServer) DenyAuthorizationRequest(ctx context.Contex
 }
 
 func (s *defaultAuthorizationServer) CompleteAuthorizationRequest(ctx context.Context, req *AuthorizationRequest, user User) (url.Values, *OAuthError) {
-	// TODO
-	return nil, nil
+	return s.ApproveAuthorizationRequest(ctx, req, user, ApproveAll(req))
+}
+
+func (s *defaultAuthorizationServer) ApproveAuthorizationRequest(ctx context.Context, req *AuthorizationRequest, user User, decision *ConsentDecision) (url.Values, *OAuthError) {
+	client, clientErr := GetClient(ctx, s.clients, req.ClientID)
+	if clientErr != nil {
+		return nil, clientErr
+	}
+
+	req, decisionErr := decision.apply(req)
+	if decisionErr != nil {
+		return nil, decisionErr
+	}
+
+	if err := ValidateScopesFor(ctx, s.scopeValidator, client, user, req.Scope); err != nil {
+		return nil, err
+	}
+
+	if err := s.recordAuthorization(ctx, client, req, user); err != nil {
+		return nil, MaybeWrapError(err)
+	}
+
+	// include_granted_scopes may have added scopes from the record that are no
+	// longer approved
+	if err := s.checkAdminApproval(ctx, client, req.Scope); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	var dependents []DependentAuthorizationHandler
+	for _, k := range req.ResponseType {
+		handler, ok := s.authorizationHandlers[k]
+		if !ok {
+			return nil, UnsupportedResponseType([]string{k})
+		}
+
+		if dependent, ok := handler.(DependentAuthorizationHandler); ok {
+			dependents = append(dependents, dependent)
+			continue
+		}
+
+		value, err := handler.IssueAuthorizationResponse(ctx, client, req, user)
+		if err != nil {
+			return nil, MaybeWrapError(err)
+		}
+
+		// eg the `none` response type issues nothing
+		if value != "" {
+			params.Set(k, value)
+		}
+	}
+
+	for _, handler := range dependents {
+		value, err := handler.IssueDependentAuthorizationResponse(ctx, client, req, user, params)
+		if err != nil {
+			return nil, MaybeWrapError(err)
+		}
+
+		if value != "" {
+			params.Set(handler.ResponseType(), value)
+		}
+	}
+
+	if err := s.recordConsent(ctx, client, req, user); err != nil {
+		return nil, MaybeWrapError(err)
+	}
+
+	if req.State != "" {
+		params.Set(ParamState, req.State)
+	}
+
+	return params, nil
+}
+
+func (s *defaultAuthorizationServer) RespondToAuthorizationRequest(ctx context.Context, w http.ResponseWriter, req *AuthorizationRequest, params url.Values) error {
+	if req.FinalRedirectURI == "" {
+		return ErrNoAuthorizationRedirectURI
+	}
+
+	responseMode := req.FinalResponseMode
+	if responseMode == "" {
+		// errors that happen before the response mode is validated are sent
+		// with the default for the response type
+		responseMode = DefaultResponseMode(req.ResponseType)
+	}
+
+	handler, ok := s.responseModes[responseMode]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedResponseMode, responseMode)
+	}
+
+	client, err := s.clients.Get(ctx, req.ClientID)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return fmt.Errorf("%w: %s", ErrClientNotFound, req.ClientID)
+	}
+
+	return handler.Respond(ctx, w, client, req, params)
+}
+
+func (s *defaultAuthorizationServer) RespondWithAuthorizationError(ctx context.Context, w http.ResponseWriter, req *AuthorizationRequest, err *OAuthError) error {
+	params := err.Values()
+	if req.State != "" {
+		params.Set(ParamState, req.State)
+	}
+
+	return s.RespondToAuthorizationRequest(ctx, w, req, params)
+}
+
+func (s *defaultAuthorizationServer) PushAuthorizationRequest(ctx context.Context, req *http.Request) (*PushedAuthorizationResponse, *OAuthError) {
+	if s.pushedRequests == nil {
+		return nil, ServerError(ErrPushedAuthorizationRequestsNotSet)
+	}
+
+	if req.Method != http.MethodPost {
+		return nil, InvalidRequestWithCause(
+			ErrInvalidRequestMethod,
+			"pushed authorization requests must be %s requests",
+			http.MethodPost,
+		)
+	}
+
+	if err := req.ParseForm(); err != nil {
+		return nil, InvalidRequestWithCause(
+			fmt.Errorf("%w: %w", ErrCouldNotParseRequestBody, err),
+			ErrCouldNotParseRequestBody.Error(),
+		)
+	}
+
+	clientId, clientSecret, _ := clientCredentials(req)
+	client, authErr := AuthenticateClient(ctx, s.clients, clientId, clientSecret)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	// client authentication is not part of the authorization request, it must
+	// not be merged into the request or count against strict request objects.
+	values := maps.Clone(req.PostForm)
+	for _, p := range clientAuthenticationParams {
+		values.Del(p)
+	}
+
+	if values.Has(ParamRequestURI) {
+		return nil, InvalidRequestWithCause(ErrRequestURINotAllowed, ErrRequestURINotAllowed.Error())
+	}
+
+	// https://datatracker.ietf.org/doc/html/rfc9126#section-2.1 the client_id
+	// in the body, if present, must match the authenticated client.
+	if bodyClientId := values.Get(ParamClientID); bodyClientId != "" && bodyClientId != client.ID() {
+		return nil, InvalidRequest("%s does not match the authenticated client", ParamClientID)
+	}
+	values.Set(ParamClientID, client.ID())
+
+	// https://datatracker.ietf.org/doc/html/rfc9126#section-3 request objects
+	// may be pushed as well
+	if values.Has(ParamRequest) {
+		objectValues, objErr := s.resolveRequestObject(ctx, client, values)
+		if objErr != nil {
+			return nil, objErr
+		}
+		values = objectValues
+	}
+
+	authReq, err := parseAuthorizationRequestValues(values)
+	if err != nil {
+		return nil, err
+	}
+
+	authReq, err = s.validateAuthorizationRequest(ctx, client, authReq)
+	if err != nil {
+		return nil, err
+	}
+
+	token, tokenErr := generateRandomToken()
+	if tokenErr != nil {
+		return nil, ServerError(tokenErr)
+	}
+
+	requestUri := RequestURIPrefix + token
+	expiresAt := time.Now().Add(s.pushedRequestLifetime)
+	if saveErr := s.pushedRequests.Save(ctx, requestUri, authReq, expiresAt); saveErr != nil {
+		return nil, MaybeWrapError(saveErr)
+	}
+
+	return &PushedAuthorizationResponse{
+		RequestURI: requestUri,
+		ExpiresIn:  int(s.pushedRequestLifetime.Seconds()),
+	}, nil
 }
 
 func (s *defaultAuthorizationServer) Token(ctx context.Context, req *http.Request) (*AccessTokenResponse, *OAuthError) {
@@ -165,6 +543,11 @@ func (s *defaultAuthorizationServer) Token(ctx context.Context, req *http.Reques
 		}
 	}
 
+	tokenRequest.scopeValidator = s.scopeValidator
+	tokenRequest.users = s.users
+	tokenRequest.scopeClassifier = s.scopeClassifier
+	tokenRequest.adminApprovals = s.adminApprovals
+
 	resp, grantErr := grant.Token(ctx, tokenRequest)
 
 	return resp, MaybeWrapError(grantErr)
@@ -192,3 +575,75 @@ func (s *defaultAuthorizationServer) checkAuthorizationResponseType(client Clien
 
 	return nil
 }
+
+func (s *defaultAuthorizationServer) requiresPushedAuthorizationRequests(client Client) bool {
+	if s.requirePushedRequests {
+		return true
+	}
+
+	requires, ok := client.(ClientRequiresPushedAuthorizationRequests)
+
+	return ok && requires.RequiresPushedAuthorizationRequests()
+}
+
+// swap the request_uri only request from the query string for the stored,
+// pushed authorization request.
+func (s *defaultAuthorizationServer) resolvePushedAuthorizationRequest(ctx context.Context, client Client, authReq *AuthorizationRequest) (*AuthorizationRequest, *OAuthError) {
+	if s.pushedRequests == nil {
+		return nil, RequestURINotSupported()
+	}
+
+	// https://datatracker.ietf.org/doc/html/rfc9126#section-4 request URIs are
+	// bound to the client that pushed them, other clients can't use them up
+	pushed, err := s.pushedRequests.Consume(ctx, client.ID(), authReq.RequestURI)
+	if err != nil {
+		return nil, MaybeWrapError(err)
+	}
+
+	if pushed == nil || pushed.ClientID != client.ID() {
+		return nil, InvalidRequestWithCause(ErrInvalidRequestURI, ErrInvalidRequestURI.Error())
+	}
+
+	resolved := *pushed
+	resolved.RequestURI = authReq.RequestURI
+	resolved.QueryString = authReq.QueryString
+
+	return &resolved, nil
+}
+
+func (s *defaultAuthorizationServer) checkAuthorizationResponseMode(client Client, req *AuthorizationRequest) *OAuthError {
+	responseMode := req.ResponseMode
+	if responseMode == "" {
+		responseMode = DefaultResponseMode(req.ResponseType)
+	}
+
+	handler, ok := s.responseModes[responseMode]
+	if !ok {
+		return InvalidRequestWithCause(
+			ErrUnsupportedResponseMode,
+			"%s response mode is not supported",
+			responseMode,
+		)
+	}
+
+	if !handler.SupportsResponseType(req.ResponseType) {
+		return InvalidRequestWithCause(
+			ErrResponseModeNotAllowed,
+			"%s response mode cannot be used with response type %s",
+			responseMode,
+			strings.Join(req.ResponseType, " "),
+		)
+	}
+
+	if check, ok := client.(ClientAllowsResponseMode); ok && !check.AllowsResponseMode(responseMode) {
+		return UnauthorizedClient(fmt.Sprintf(
+			"client %s does not support response mode: %s",
+			client.ID(),
+			responseMode,
+		))
+	}
+
+	req.FinalResponseMode = responseMode
+
+	return nil
+}
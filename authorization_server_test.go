@@ -251,8 +251,211 @@ func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_NoErrorIfEveryt
 		ResponseType: []string{
 			authHandler.responseType,
 		},
+		FinalResponseMode: oauth2server.ResponseModeFragment,
 	}, authReq, cmpopts.IgnoreFields(oauth2server.AuthorizationRequest{}, "QueryString"))
 	if diff != "" {
 		t.Error(diff)
 	}
 }
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_ErrorsOnUnsupportedResponseMode(t *testing.T) {
+	authHandler := &spyAuthorizationHandler{
+		responseType: "code",
+	}
+	tc := startAuthorizationServerTest(t, oauth2server.WithAuthorizationHandler(authHandler))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamResponseType: authHandler.responseType,
+		oauth2server.ParamResponseMode: "nope",
+		oauth2server.ParamClientID:     testClientId,
+	})
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+
+	authReq, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	tc.assertNotNilAuthRequest(t, authReq)
+	if !errors.Is(err, oauth2server.ErrUnsupportedResponseMode) {
+		t.Errorf("expected ErrUnsupportedResponseMode, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_ErrorsIfResponseModeCannotBeUsedWithResponseType(t *testing.T) {
+	authHandler := &spyAuthorizationHandler{
+		responseType: "id_token",
+	}
+	tc := startAuthorizationServerTest(t, oauth2server.WithAuthorizationHandler(authHandler))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamResponseType: authHandler.responseType,
+		oauth2server.ParamResponseMode: oauth2server.ResponseModeQuery,
+		oauth2server.ParamClientID:     testClientId,
+	})
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+
+	authReq, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	tc.assertNotNilAuthRequest(t, authReq)
+	if !errors.Is(err, oauth2server.ErrResponseModeNotAllowed) {
+		t.Errorf("expected ErrResponseModeNotAllowed, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_ErrorsIfClientDoesNotAllowResponseMode(t *testing.T) {
+	authHandler := &spyAuthorizationHandler{
+		responseType: "code",
+	}
+	tc := startAuthorizationServerTest(t, oauth2server.WithAuthorizationHandler(authHandler))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamResponseType: authHandler.responseType,
+		oauth2server.ParamResponseMode: oauth2server.ResponseModeFormPost,
+		oauth2server.ParamClientID:     testClientId,
+	})
+	client := &SpyClient{
+		id:                       testClientId,
+		redirectUris:             []string{testRedirectUri},
+		validRedirectURIReturn:   true,
+		allowsResponseTypeReturn: true,
+		allowsResponseModeReturn: false,
+	}
+	tc.clients.Add(client)
+
+	authReq, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	tc.assertNotNilAuthRequest(t, authReq)
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeUnauthorizedClient {
+		t.Errorf("Expected a %q error, got %v", oauth2server.ErrorTypeUnauthorizedClient, err)
+	}
+	if len(client.allowsResponseModeCalls) != 1 || client.allowsResponseModeCalls[0] != oauth2server.ResponseModeFormPost {
+		t.Errorf("expected AllowsResponseMode to be called with form_post, got %v", client.allowsResponseModeCalls)
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_UsesRequestedResponseMode(t *testing.T) {
+	authHandler := &spyAuthorizationHandler{
+		responseType: "code",
+	}
+	tc := startAuthorizationServerTest(t, oauth2server.WithAuthorizationHandler(authHandler))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamResponseType: authHandler.responseType,
+		oauth2server.ParamResponseMode: oauth2server.ResponseModeFormPost,
+		oauth2server.ParamClientID:     testClientId,
+	})
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+
+	authReq, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if authReq.FinalResponseMode != oauth2server.ResponseModeFormPost {
+		t.Errorf("bad final response mode: %q != %q", authReq.FinalResponseMode, oauth2server.ResponseModeFormPost)
+	}
+}
+
+func TestDefaultAuthorizationServer_CompleteAuthorizationRequest_ReturnsValuesFromAuthorizationHandlers(t *testing.T) {
+	authHandler := &spyAuthorizationHandler{
+		responseType:                     "code",
+		issueAuthorizationResponseReturn: "issuedcode",
+	}
+	tc := startAuthorizationServerTest(t, oauth2server.WithAuthorizationHandler(authHandler))
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	user := &testUser{id: "user"}
+
+	params, err := tc.server.CompleteAuthorizationRequest(context.Background(), &oauth2server.AuthorizationRequest{
+		ClientID:     testClientId,
+		ResponseType: []string{"code"},
+		State:        "abc123",
+	}, user)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params.Get("code") != "issuedcode" {
+		t.Errorf(`bad code: %q != "issuedcode"`, params.Get("code"))
+	}
+	if params.Get(oauth2server.ParamState) != "abc123" {
+		t.Errorf(`bad state: %q != "abc123"`, params.Get(oauth2server.ParamState))
+	}
+	if len(authHandler.issueAuthorizationResponseCalls) != 1 || authHandler.issueAuthorizationResponseCalls[0].user != user {
+		t.Errorf("expected authorization handler to be called with user: %+v", authHandler.issueAuthorizationResponseCalls)
+	}
+}
+
+func TestDefaultAuthorizationServer_CompleteAuthorizationRequest_ErrorsIfAuthorizationHandlerErrors(t *testing.T) {
+	expectedErr := errors.New("oh noz")
+	authHandler := &spyAuthorizationHandler{
+		responseType:                    "code",
+		issueAuthorizationResponseError: expectedErr,
+	}
+	tc := startAuthorizationServerTest(t, oauth2server.WithAuthorizationHandler(authHandler))
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+
+	params, err := tc.server.CompleteAuthorizationRequest(context.Background(), &oauth2server.AuthorizationRequest{
+		ClientID:     testClientId,
+		ResponseType: []string{"code"},
+	}, &testUser{id: "user"})
+
+	if params != nil {
+		t.Errorf("expected nil params, got %v", params)
+	}
+	if !errors.Is(err, expectedErr) {
+		t.Errorf("expected error from authorization handler, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_RespondWithAuthorizationError_UsesDefaultResponseModeIfNotValidated(t *testing.T) {
+	tc := startAuthorizationServerTest(t)
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	rec := httptest.NewRecorder()
+
+	err := tc.server.RespondWithAuthorizationError(context.Background(), rec, &oauth2server.AuthorizationRequest{
+		ClientID:         testClientId,
+		FinalRedirectURI: testRedirectUri,
+		ResponseType:     []string{"code"},
+		State:            "abc123",
+	}, oauth2server.UnsupportedResponseType([]string{"code"}))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	location, _ := url.Parse(rec.Header().Get("Location"))
+	if location.Query().Get(oauth2server.ParamError) != oauth2server.ErrorTypeUnsupportedResponseType {
+		t.Errorf("expected error in query string, got %q", location)
+	}
+	if location.Query().Get(oauth2server.ParamState) != "abc123" {
+		t.Errorf("expected state in query string, got %q", location)
+	}
+}
+
+func TestDefaultAuthorizationServer_RespondToAuthorizationRequest_UsesFinalResponseMode(t *testing.T) {
+	tc := startAuthorizationServerTest(t)
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	rec := httptest.NewRecorder()
+	params := url.Values{}
+	params.Set("code", "abc")
+
+	err := tc.server.RespondToAuthorizationRequest(context.Background(), rec, &oauth2server.AuthorizationRequest{
+		ClientID:          testClientId,
+		FinalRedirectURI:  testRedirectUri,
+		ResponseType:      []string{"code"},
+		FinalResponseMode: oauth2server.ResponseModeFragment,
+	}, params)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if location := rec.Header().Get("Location"); location != testRedirectUri+"#code=abc" {
+		t.Errorf("expected a fragment redirect, got %q", location)
+	}
+}
+
+func TestDefaultAuthorizationServer_RespondToAuthorizationRequest_ErrorsWithoutFinalRedirectURI(t *testing.T) {
+	tc := startAuthorizationServerTest(t)
+	rec := httptest.NewRecorder()
+
+	err := tc.server.RespondToAuthorizationRequest(context.Background(), rec, &oauth2server.AuthorizationRequest{
+		ClientID: testClientId,
+	}, url.Values{})
+
+	if !errors.Is(err, oauth2server.ErrNoAuthorizationRedirectURI) {
+		t.Errorf("expected ErrNoAuthorizationRedirectURI, got %v", err)
+	}
+}
@@ -0,0 +1,295 @@
+package oauth2server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// the `events` member that identifies a logout token
+	BackChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+	DefaultBackChannelLogoutConcurrency = 4
+	DefaultBackChannelLogoutRetries     = 2
+	DefaultBackChannelLogoutBackoff     = 500 * time.Millisecond
+
+	// how long logout tokens are valid for, they are meant to be used immediately
+	logoutTokenLifetime = 2 * time.Minute
+)
+
+// clients that implement this are sent logout tokens when a session they
+// signed into ends. See https://openid.net/specs/openid-connect-backchannel-1_0.html
+type ClientWithBackChannelLogout interface {
+	// the backchannel logout URI, empty if the client does not use it
+	BackChannelLogoutURI() string
+
+	// whether the logout token must include `sid`
+	BackChannelLogoutSessionRequired() bool
+}
+
+// a failed notification for a single client.
+type BackChannelLogoutError struct {
+	ClientID string
+	Err      error
+}
+
+func (e *BackChannelLogoutError) Error() string {
+	return fmt.Sprintf("back-channel logout for client %s: %s", e.ClientID, e.Err.Error())
+}
+
+func (e *BackChannelLogoutError) Unwrap() error {
+	return e.Err
+}
+
+// Sends logout tokens to every client in an ended session.
+type BackChannelLogoutNotifier interface {
+	// notify the clients in the session, returns the failures joined together
+	// as *BackChannelLogoutError values. Nil if every client was notified.
+	Notify(ctx context.Context, session *EndedSession) error
+}
+
+type backChannelLogoutNotifier struct {
+	issuer      string
+	clients     ClientRepository
+	keys        KeySet
+	httpClient  *http.Client
+	concurrency int
+	retries     int
+	backoff     time.Duration
+	onError     func(ctx context.Context, err *BackChannelLogoutError)
+	subjects    SubjectIdentifierStrategy
+}
+
+type BackChannelLogoutOption func(*backChannelLogoutNotifier)
+
+// Use a custom HTTP client. This replaces the default client entirely,
+// including its protection against sending to private addresses.
+func WithBackChannelLogoutHTTPClient(client *http.Client) BackChannelLogoutOption {
+	return func(n *backChannelLogoutNotifier) {
+		n.httpClient = client
+	}
+}
+
+// the most clients that are notified at once.
+func WithBackChannelLogoutConcurrency(concurrency int) BackChannelLogoutOption {
+	return func(n *backChannelLogoutNotifier) {
+		n.concurrency = concurrency
+	}
+}
+
+// retry failed deliveries up to retries times, the wait between attempts
+// starts at backoff and doubles each time.
+func WithBackChannelLogoutRetries(retries int, backoff time.Duration) BackChannelLogoutOption {
+	return func(n *backChannelLogoutNotifier) {
+		n.retries = retries
+		n.backoff = backoff
+	}
+}
+
+// called for each client that could not be notified, in addition to the
+// error being returned from Notify.
+func WithBackChannelLogoutErrorHandler(onError func(ctx context.Context, err *BackChannelLogoutError)) BackChannelLogoutOption {
+	return func(n *backChannelLogoutNotifier) {
+		n.onError = onError
+	}
+}
+
+// decide the `sub` of logout tokens, this should match the ID tokens.
+func WithBackChannelLogoutSubjectIdentifiers(subjects SubjectIdentifierStrategy) BackChannelLogoutOption {
+	return func(n *backChannelLogoutNotifier) {
+		n.subjects = subjects
+	}
+}
+
+// a notifier that signs logout tokens with keys from the key set, issuer is
+// the `iss` claim. By default requests are sent with the same protections as
+// NewHTTPFetcher and failed requests are retried DefaultBackChannelLogoutRetries
+// times.
+func NewBackChannelLogoutNotifier(issuer string, clients ClientRepository, keys KeySet, opts ...BackChannelLogoutOption) BackChannelLogoutNotifier {
+	n := &backChannelLogoutNotifier{
+		issuer:      issuer,
+		clients:     clients,
+		keys:        keys,
+		concurrency: DefaultBackChannelLogoutConcurrency,
+		retries:     DefaultBackChannelLogoutRetries,
+		backoff:     DefaultBackChannelLogoutBackoff,
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	if n.httpClient == nil {
+		n.httpClient = newPublicHTTPClient()
+	}
+	if n.concurrency < 1 {
+		n.concurrency = 1
+	}
+
+	return n
+}
+
+func (n *backChannelLogoutNotifier) Notify(ctx context.Context, session *EndedSession) error {
+	var (
+		wg     sync.WaitGroup
+		lock   sync.Mutex
+		errs   []error
+		limits = make(chan struct{}, n.concurrency)
+	)
+
+	for _, clientId := range session.ClientIDs {
+		wg.Add(1)
+		limits <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-limits }()
+
+			err := n.notifyClient(ctx, clientId, session)
+			if err == nil {
+				return
+			}
+
+			logoutErr := &BackChannelLogoutError{ClientID: clientId, Err: err}
+			if n.onError != nil {
+				n.onError(ctx, logoutErr)
+			}
+
+			lock.Lock()
+			errs = append(errs, logoutErr)
+			lock.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (n *backChannelLogoutNotifier) notifyClient(ctx context.Context, clientId string, session *EndedSession) error {
+	client, err := n.clients.Get(ctx, clientId)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return fmt.Errorf("%w: %s", ErrClientNotFound, clientId)
+	}
+
+	backChannel, ok := client.(ClientWithBackChannelLogout)
+	if !ok || backChannel.BackChannelLogoutURI() == "" {
+		return nil
+	}
+
+	if backChannel.BackChannelLogoutSessionRequired() && session.SessionID == "" {
+		return ErrBackChannelLogoutSessionRequired
+	}
+
+	token, err := n.logoutToken(ctx, client, session)
+	if err != nil {
+		return err
+	}
+
+	backoff := n.backoff
+	for attempt := 0; ; attempt++ {
+		err = n.send(ctx, backChannel.BackChannelLogoutURI(), token)
+		if err == nil || attempt >= n.retries || !retryBackChannelLogout(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// https://openid.net/specs/openid-connect-backchannel-1_0.html#LogoutToken
+func (n *backChannelLogoutNotifier) logoutToken(ctx context.Context, client Client, session *EndedSession) (string, error) {
+	alg := DefaultIDTokenSigningAlg
+	if signs, ok := client.(ClientSignsIDTokens); ok && signs.IDTokenSignedResponseAlg() != "" {
+		alg = signs.IDTokenSignedResponseAlg()
+	}
+
+	key, err := n.keys.SigningKey(ctx, alg)
+	if err != nil {
+		return "", err
+	}
+
+	jti, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := map[string]any{
+		"iss":    n.issuer,
+		"aud":    client.ID(),
+		"iat":    now.Unix(),
+		"exp":    now.Add(logoutTokenLifetime).Unix(),
+		"jti":    jti,
+		"events": map[string]any{BackChannelLogoutEvent: map[string]any{}},
+	}
+	if session.UserID != "" {
+		claims["sub"], err = subjectIdentifier(ctx, n.subjects, client, session.UserID)
+		if err != nil {
+			return "", err
+		}
+	}
+	if session.SessionID != "" {
+		claims[ParamSessionID] = session.SessionID
+	}
+
+	return signJWT(key, "logout+jwt", claims)
+}
+
+type backChannelLogoutStatusError struct {
+	statusCode int
+}
+
+func (e *backChannelLogoutStatusError) Error() string {
+	return fmt.Sprintf("%s: status %d", ErrBackChannelLogoutFailed.Error(), e.statusCode)
+}
+
+func (e *backChannelLogoutStatusError) Is(target error) bool {
+	return target == ErrBackChannelLogoutFailed
+}
+
+func (n *backChannelLogoutNotifier) send(ctx context.Context, uri string, token string) error {
+	body := url.Values{ParamLogoutToken: {token}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, DefaultFetchMaxBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &backChannelLogoutStatusError{statusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// network errors and server errors are retried, clients reject logout tokens
+// they cannot use with a 400 and sending them again will not help.
+func retryBackChannelLogout(err error) bool {
+	var statusErr *backChannelLogoutStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.statusCode >= 500 || statusErr.statusCode == http.StatusTooManyRequests
+	}
+
+	return !errors.Is(err, ErrFetchDisallowedAddress)
+}
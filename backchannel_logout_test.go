@@ -0,0 +1,271 @@
+package oauth2server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+type backChannelTestClient struct {
+	oauth2server.Client
+	backChannelLogoutUri string
+	sessionRequired      bool
+}
+
+func (c *backChannelTestClient) BackChannelLogoutURI() string {
+	return c.backChannelLogoutUri
+}
+
+func (c *backChannelTestClient) BackChannelLogoutSessionRequired() bool {
+	return c.sessionRequired
+}
+
+type backChannelTestCase struct {
+	clients *oauth2server.InMemoryClientRepository
+	server  *httptest.Server
+	lock    sync.Mutex
+	tokens  []string
+	status  atomic.Int32
+	calls   atomic.Int32
+}
+
+func startBackChannelTest(t *testing.T) *backChannelTestCase {
+	t.Helper()
+
+	tc := &backChannelTestCase{
+		clients: oauth2server.NewInMemoryClientRepository(),
+	}
+	tc.status.Store(http.StatusOK)
+	tc.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tc.calls.Add(1)
+		r.ParseForm()
+		tc.lock.Lock()
+		tc.tokens = append(tc.tokens, r.PostForm.Get(oauth2server.ParamLogoutToken))
+		tc.lock.Unlock()
+		w.WriteHeader(int(tc.status.Load()))
+	}))
+	t.Cleanup(tc.server.Close)
+
+	return tc
+}
+
+func (tc *backChannelTestCase) addClient(id string, sessionRequired bool) {
+	tc.clients.Add(&backChannelTestClient{
+		Client:               oauth2server.NewSimpleClient(id, testClientSecret, nil),
+		backChannelLogoutUri: tc.server.URL + "/logout/" + id,
+		sessionRequired:      sessionRequired,
+	})
+}
+
+func (tc *backChannelTestCase) notifier(opts ...oauth2server.BackChannelLogoutOption) oauth2server.BackChannelLogoutNotifier {
+	opts = append([]oauth2server.BackChannelLogoutOption{
+		oauth2server.WithBackChannelLogoutHTTPClient(tc.server.Client()),
+		oauth2server.WithBackChannelLogoutRetries(2, time.Millisecond),
+	}, opts...)
+
+	return oauth2server.NewBackChannelLogoutNotifier(testOIDCIssuer, tc.clients, oauth2server.NewStaticKeySet(newTestSigningKey()), opts...)
+}
+
+func TestBackChannelLogoutNotifier_SendsLogoutTokens(t *testing.T) {
+	tc := startBackChannelTest(t)
+	tc.addClient("one", true)
+	tc.addClient("two", false)
+	tc.clients.Add(oauth2server.NewSimpleClient("nobackchannel", testClientSecret, nil))
+
+	err := tc.notifier().Notify(context.Background(), &oauth2server.EndedSession{
+		UserID:    "user",
+		SessionID: "session-1",
+		ClientIDs: []string{"one", "two", "nobackchannel"},
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tc.tokens) != 2 {
+		t.Fatalf("expected two logout tokens, got %d", len(tc.tokens))
+	}
+	for _, token := range tc.tokens {
+		header, _, _ := splitTestJWT(t, token)
+		if header["typ"] != "logout+jwt" {
+			t.Errorf("expected logout+jwt type, got %v", header["typ"])
+		}
+		claims := decodeTestJWT(t, token)
+		events, _ := claims["events"].(map[string]any)
+		if _, ok := events[oauth2server.BackChannelLogoutEvent]; !ok {
+			t.Errorf("expected backchannel logout event, got %v", claims["events"])
+		}
+		if claims["iss"] != testOIDCIssuer || claims["sub"] != "user" || claims["sid"] != "session-1" || claims["jti"] == nil {
+			t.Errorf("unexpected claims: %v", claims)
+		}
+		if _, ok := claims["nonce"]; ok {
+			t.Error("logout tokens must not include a nonce")
+		}
+	}
+}
+
+func TestBackChannelLogoutNotifier_RetriesServerErrors(t *testing.T) {
+	tc := startBackChannelTest(t)
+	tc.addClient("one", false)
+	tc.status.Store(http.StatusServiceUnavailable)
+	var reported []*oauth2server.BackChannelLogoutError
+
+	err := tc.notifier(oauth2server.WithBackChannelLogoutErrorHandler(func(ctx context.Context, err *oauth2server.BackChannelLogoutError) {
+		reported = append(reported, err)
+	})).Notify(context.Background(), &oauth2server.EndedSession{
+		UserID:    "user",
+		ClientIDs: []string{"one"},
+	})
+
+	if !errors.Is(err, oauth2server.ErrBackChannelLogoutFailed) {
+		t.Errorf("expected ErrBackChannelLogoutFailed, got %v", err)
+	}
+	if calls := tc.calls.Load(); calls != 3 {
+		t.Errorf("expected first attempt and two retries, got %d calls", calls)
+	}
+	if len(reported) != 1 || reported[0].ClientID != "one" {
+		t.Errorf("expected failure to be reported for the client, got %v", reported)
+	}
+}
+
+func TestBackChannelLogoutNotifier_DoesNotRetryClientErrors(t *testing.T) {
+	tc := startBackChannelTest(t)
+	tc.addClient("one", false)
+	tc.status.Store(http.StatusBadRequest)
+
+	err := tc.notifier().Notify(context.Background(), &oauth2server.EndedSession{
+		UserID:    "user",
+		ClientIDs: []string{"one"},
+	})
+
+	var logoutErr *oauth2server.BackChannelLogoutError
+	if !errors.As(err, &logoutErr) || logoutErr.ClientID != "one" {
+		t.Errorf("expected a BackChannelLogoutError, got %v", err)
+	}
+	if calls := tc.calls.Load(); calls != 1 {
+		t.Errorf("expected a single attempt, got %d calls", calls)
+	}
+}
+
+func TestBackChannelLogoutNotifier_ErrorsIfClientRequiresSession(t *testing.T) {
+	tc := startBackChannelTest(t)
+	tc.addClient("one", true)
+
+	err := tc.notifier().Notify(context.Background(), &oauth2server.EndedSession{
+		UserID:    "user",
+		ClientIDs: []string{"one"},
+	})
+
+	if !errors.Is(err, oauth2server.ErrBackChannelLogoutSessionRequired) {
+		t.Errorf("expected ErrBackChannelLogoutSessionRequired, got %v", err)
+	}
+	if calls := tc.calls.Load(); calls != 0 {
+		t.Errorf("expected no requests, got %d", calls)
+	}
+}
+
+func TestBackChannelLogoutNotifier_RefusesPrivateAddressesByDefault(t *testing.T) {
+	tc := startBackChannelTest(t)
+	tc.addClient("one", false)
+	notifier := oauth2server.NewBackChannelLogoutNotifier(testOIDCIssuer, tc.clients, oauth2server.NewStaticKeySet(newTestSigningKey()))
+
+	err := notifier.Notify(context.Background(), &oauth2server.EndedSession{
+		UserID:    "user",
+		ClientIDs: []string{"one"},
+	})
+
+	if !errors.Is(err, oauth2server.ErrFetchDisallowedAddress) {
+		t.Errorf("expected ErrFetchDisallowedAddress, got %v", err)
+	}
+}
+
+func (tc *backChannelTestCase) endSessionHandler(onError func(context.Context, *oauth2server.EndedSession, error)) http.Handler {
+	sessions := &spySessionManager{endSessionReturn: &oauth2server.EndedSession{
+		UserID:    "user",
+		ClientIDs: []string{"one"},
+	}}
+
+	return oauth2server.NewEndSessionHandler(
+		testOIDCIssuer,
+		tc.clients,
+		oauth2server.NewStaticKeySet(newTestSigningKey()).(oauth2server.PublicKeySet),
+		sessions,
+		oauth2server.WithBackChannelLogout(tc.notifier(), onError),
+	)
+}
+
+func TestEndSessionHandler_SendsBackChannelLogout(t *testing.T) {
+	btc := startBackChannelTest(t)
+	btc.addClient("one", false)
+	handler := btc.endSessionHandler(func(ctx context.Context, session *oauth2server.EndedSession, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/logout", strings.NewReader(url.Values{}.Encode())))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected logout page, got %d", rec.Code)
+	}
+	deadline := time.Now().Add(time.Second)
+	for btc.calls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if btc.calls.Load() != 1 {
+		t.Errorf("expected a logout token to be sent, got %d", btc.calls.Load())
+	}
+}
+
+func TestEndSessionHandler_ReportsBackChannelLogoutFailures(t *testing.T) {
+	btc := startBackChannelTest(t)
+	btc.addClient("one", false)
+	btc.status.Store(http.StatusBadRequest)
+	failures := make(chan error, 1)
+	handler := btc.endSessionHandler(func(ctx context.Context, session *oauth2server.EndedSession, err error) {
+		failures <- err
+	})
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/logout", strings.NewReader(url.Values{}.Encode())))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected logout page, got %d", rec.Code)
+	}
+	select {
+	case err := <-failures:
+		var logoutErr *oauth2server.BackChannelLogoutError
+		if !errors.As(err, &logoutErr) || logoutErr.ClientID != "one" {
+			t.Errorf("expected a back-channel logout error for the client, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected the failure to be reported")
+	}
+}
+
+func TestEndSessionHandler_IgnoresBackChannelLogoutFailuresWithoutErrorHandler(t *testing.T) {
+	btc := startBackChannelTest(t)
+	btc.addClient("one", false)
+	btc.status.Store(http.StatusBadRequest)
+	handler := btc.endSessionHandler(nil)
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/logout", strings.NewReader(url.Values{}.Encode())))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected logout page, got %d", rec.Code)
+	}
+	deadline := time.Now().Add(time.Second)
+	for btc.calls.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	// give the failure a moment to reach the nil handler
+	time.Sleep(10 * time.Millisecond)
+}
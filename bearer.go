@@ -0,0 +1,83 @@
+package oauth2server
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Bearer token usage, see https://datatracker.ietf.org/doc/html/rfc6750
+const (
+	ParamAccessToken = "access_token"
+
+	bearerScheme = "Bearer"
+)
+
+// pull the bearer token from the Authorization header or, for form encoded
+// POST requests, the `access_token` body parameter.
+func BearerToken(r *http.Request) (string, *OAuthError) {
+	var tokens []string
+
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if ok && strings.EqualFold(scheme, bearerScheme) && strings.TrimSpace(token) != "" {
+			tokens = append(tokens, strings.TrimSpace(token))
+		}
+	}
+
+	if r.Method == http.MethodPost && isFormRequest(r) {
+		if err := r.ParseForm(); err != nil {
+			return "", InvalidRequestWithCause(
+				fmt.Errorf("%w: %w", ErrCouldNotParseRequestBody, err),
+				ErrCouldNotParseRequestBody.Error(),
+			)
+		}
+		if token := r.PostFormValue(ParamAccessToken); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+
+	switch len(tokens) {
+	case 0:
+		return "", &OAuthError{StatusCode: http.StatusUnauthorized, Cause: ErrMissingBearerToken}
+	case 1:
+		return tokens[0], nil
+	}
+
+	// https://datatracker.ietf.org/doc/html/rfc6750#section-2
+	return "", InvalidRequestWithCause(ErrMultipleBearerTokens, ErrMultipleBearerTokens.Error())
+}
+
+func isFormRequest(r *http.Request) bool {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return contentType == "application/x-www-form-urlencoded"
+}
+
+// send an error for a request to a bearer token protected endpoint with the
+// `WWW-Authenticate` header. Errors without an error type, like a missing
+// token, only include the scheme.
+// See https://datatracker.ietf.org/doc/html/rfc6750#section-3
+func RespondWithBearerError(w http.ResponseWriter, e *OAuthError) error {
+	challenge := bearerScheme
+	if e.ErrorType != "" {
+		challenge = fmt.Sprintf(`%s error="%s"`, bearerScheme, e.ErrorType)
+		if e.ErrorDescription != "" {
+			challenge += fmt.Sprintf(`, error_description="%s"`, strings.ReplaceAll(e.ErrorDescription, `"`, `'`))
+		}
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+
+	statusCode := e.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusBadRequest
+	}
+
+	if e.ErrorType == "" {
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(statusCode)
+		return nil
+	}
+
+	return jsonResponse(w, statusCode, e)
+}
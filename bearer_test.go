@@ -0,0 +1,70 @@
+package oauth2server_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+func TestBearerToken_ReadsAuthorizationHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/userinfo", nil)
+	req.Header.Set("Authorization", "bearer abc123")
+
+	token, err := oauth2server.BearerToken(req)
+
+	if err != nil || token != "abc123" {
+		t.Errorf("expected token from header, got %q %v", token, err)
+	}
+}
+
+func TestBearerToken_ReadsFormBody(t *testing.T) {
+	req := createRequestWithFormBody(http.MethodPost, "/userinfo", map[string]string{
+		oauth2server.ParamAccessToken: "abc123",
+	})
+
+	token, err := oauth2server.BearerToken(req)
+
+	if err != nil || token != "abc123" {
+		t.Errorf("expected token from body, got %q %v", token, err)
+	}
+}
+
+func TestBearerToken_ErrorsWithoutToken(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/userinfo", nil)
+
+	_, err := oauth2server.BearerToken(req)
+
+	if !errors.Is(err, oauth2server.ErrMissingBearerToken) {
+		t.Errorf("expected ErrMissingBearerToken, got %v", err)
+	}
+}
+
+func TestBearerToken_ErrorsIfTokenIsSentMoreThanOnce(t *testing.T) {
+	req := createRequestWithFormBody(http.MethodPost, "/userinfo", map[string]string{
+		oauth2server.ParamAccessToken: "abc123",
+	})
+	req.Header.Set("Authorization", "Bearer abc123")
+
+	_, err := oauth2server.BearerToken(req)
+
+	if !errors.Is(err, oauth2server.ErrMultipleBearerTokens) {
+		t.Errorf("expected ErrMultipleBearerTokens, got %v", err)
+	}
+}
+
+func TestRespondWithBearerError_SetsWWWAuthenticate(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	oauth2server.RespondWithBearerError(rec, oauth2server.InvalidToken(oauth2server.ErrInvalidAccessToken))
+
+	expected := `Bearer error="invalid_token", error_description="access token is invalid or expired"`
+	if got := rec.Header().Get("WWW-Authenticate"); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rec.Code)
+	}
+}
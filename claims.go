@@ -0,0 +1,176 @@
+package oauth2server
+
+import (
+	"net/url"
+	"regexp"
+)
+
+// Standard OpenID Connect claims, see
+// https://openid.net/specs/openid-connect-core-1_0.html#StandardClaims
+const (
+	ClaimName              = "name"
+	ClaimGivenName         = "given_name"
+	ClaimFamilyName        = "family_name"
+	ClaimMiddleName        = "middle_name"
+	ClaimNickname          = "nickname"
+	ClaimPreferredUsername = "preferred_username"
+	ClaimProfile           = "profile"
+	ClaimPicture           = "picture"
+	ClaimWebsite           = "website"
+	ClaimEmail             = "email"
+	ClaimEmailVerified     = "email_verified"
+	ClaimBirthdate         = "birthdate"
+	ClaimZoneinfo          = "zoneinfo"
+	ClaimLocale            = "locale"
+	ClaimUpdatedAt         = "updated_at"
+	ClaimAddress           = "address"
+	ClaimPhoneNumber       = "phone_number"
+	ClaimPhoneVerified     = "phone_number_verified"
+
+	// birthdates are full dates, a year of `0000` means the year is hidden
+	birthdateFormat = "2006-01-02"
+)
+
+// the claims each scope grants access to, see
+// https://openid.net/specs/openid-connect-core-1_0.html#ScopeClaims
+var ScopeClaimNames = map[string][]string{
+	ScopeProfile: {
+		ClaimName,
+		ClaimFamilyName,
+		ClaimGivenName,
+		ClaimMiddleName,
+		ClaimNickname,
+		ClaimPreferredUsername,
+		ClaimProfile,
+		ClaimPicture,
+		ClaimWebsite,
+		ClaimBirthdate,
+		ClaimZoneinfo,
+		ClaimLocale,
+		ClaimUpdatedAt,
+	},
+	ScopeEmail: {
+		ClaimEmail,
+		ClaimEmailVerified,
+	},
+	ScopeAddress: {
+		ClaimAddress,
+	},
+	ScopePhone: {
+		ClaimPhoneNumber,
+		ClaimPhoneVerified,
+	},
+}
+
+// a `+`, a country code, and up to 15 digits in total
+var e164PhoneNumber = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// the claims that can be released about users, use this for the
+// `claims_supported` provider metadata. Extra claims, eg from a
+// ClaimsProvider, are appended.
+func SupportedClaims(extra ...string) []string {
+	claims := []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "acr", "amr", "sid"}
+	for _, scope := range []string{ScopeProfile, ScopeEmail, ScopeAddress, ScopePhone} {
+		claims = append(claims, ScopeClaimNames[scope]...)
+	}
+
+	return append(claims, extra...)
+}
+
+// all the claims available from the user, empty values are left out.
+func UserClaims(user User) map[string]any {
+	claims := map[string]any{}
+
+	if provider, ok := user.(ClaimsProvider); ok {
+		for name, value := range provider.Claims() {
+			claims[name] = value
+		}
+	}
+
+	if withEmail, ok := user.(UserWithEmail); ok {
+		email := withEmail.UserEmail()
+		if email.Email != "" {
+			claims[ClaimEmail] = email.Email
+			claims[ClaimEmailVerified] = email.Verified
+		}
+	}
+
+	if withProfile, ok := user.(UserWithProfile); ok {
+		profileClaims(claims, withProfile.UserProfile())
+	}
+
+	if withAddress, ok := user.(UserWithAddress); ok {
+		if address := addressClaim(withAddress.UserAddress()); len(address) > 0 {
+			claims[ClaimAddress] = address
+		}
+	}
+
+	if withPhone, ok := user.(UserWithPhone); ok {
+		phone := withPhone.UserPhone()
+		if e164PhoneNumber.MatchString(phone.Number) {
+			claims[ClaimPhoneNumber] = phone.Number
+			claims[ClaimPhoneVerified] = phone.Verified
+		}
+	}
+
+	return claims
+}
+
+func profileClaims(claims map[string]any, profile UserProfile) {
+	setString := func(name string, value string) {
+		if value != "" {
+			claims[name] = value
+		}
+	}
+	setURL := func(name string, value *url.URL) {
+		if value != nil {
+			claims[name] = value.String()
+		}
+	}
+
+	setString(ClaimName, profile.Name)
+	setString(ClaimGivenName, profile.GivenName)
+	setString(ClaimFamilyName, profile.FamilyName)
+	setString(ClaimMiddleName, profile.MiddleName)
+	setString(ClaimNickname, profile.Nickname)
+	setString(ClaimPreferredUsername, profile.PreferredUsername)
+	setURL(ClaimProfile, profile.Profile)
+	setURL(ClaimWebsite, profile.Website)
+	setURL(ClaimPicture, profile.Picture)
+	setString(ClaimLocale, profile.Locale)
+
+	if !profile.Birthdate.IsZero() {
+		claims[ClaimBirthdate] = profile.Birthdate.Format(birthdateFormat)
+	}
+
+	if profile.Zoneinfo != nil {
+		setString(ClaimZoneinfo, profile.Zoneinfo.String())
+	}
+
+	if !profile.UpdatedAt.IsZero() {
+		claims[ClaimUpdatedAt] = profile.UpdatedAt.Unix()
+	}
+}
+
+func addressClaim(address UserAddress) map[string]any {
+	claim := map[string]any{}
+	set := func(name string, value string) {
+		if value != "" {
+			claim[name] = value
+		}
+	}
+
+	set("formatted", address.Formatted)
+	set("street_address", address.StreetAddress)
+	set("locality", address.Locality)
+	set("region", address.Region)
+	set("postal_code", address.PostalCode)
+	set("country", address.Country)
+
+	return claim
+}
+
+// the user's claims that the scopes grant access to.
+func ClaimsForScope(user User, scope []string) map[string]any {
+	return ClaimsFor(user, scope, nil)
+}
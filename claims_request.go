@@ -0,0 +1,157 @@
+package oauth2server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// User entities that implement this contribute claims beyond the standard
+// ones. Standard claims from UserWithEmail and UserWithProfile win if both
+// provide a claim. Custom claims are only released when requested with the
+// `claims` parameter or when a scope in ScopeClaimNames includes them.
+type ClaimsProvider interface {
+	Claims() map[string]any
+}
+
+// a request for an individual claim, a `null` request for a claim is a nil
+// *ClaimRequest. See https://openid.net/specs/openid-connect-core-1_0.html#IndividualClaimsRequests
+type ClaimRequest struct {
+	// the claim is needed for the client to work well, this is informational
+	// for the authorization server when asking for consent.
+	Essential bool `json:"essential,omitempty"`
+
+	// only release the claim if it has this value
+	Value any `json:"value,omitempty"`
+
+	// only release the claim if it has one of these values
+	Values []any `json:"values,omitempty"`
+}
+
+// the value of the `claims` request parameter, the claims requested for
+// UserInfo responses and ID tokens.
+// See https://openid.net/specs/openid-connect-core-1_0.html#ClaimsParameter
+type ClaimsRequest struct {
+	UserInfo map[string]*ClaimRequest `json:"userinfo,omitempty"`
+	IDToken  map[string]*ClaimRequest `json:"id_token,omitempty"`
+}
+
+func ParseClaimsRequest(raw string) (*ClaimsRequest, *OAuthError) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var req ClaimsRequest
+	if err := json.Unmarshal([]byte(raw), &req); err != nil {
+		return nil, InvalidRequestWithCause(
+			fmt.Errorf("%w: %w", ErrMalformedClaimsRequest, err),
+			ErrMalformedClaimsRequest.Error(),
+		)
+	}
+
+	return &req, nil
+}
+
+// the claims requested for UserInfo responses, nil if none
+func (r *ClaimsRequest) UserInfoClaims() map[string]*ClaimRequest {
+	if r == nil {
+		return nil
+	}
+
+	return r.UserInfo
+}
+
+// the claims requested for ID tokens, nil if none
+func (r *ClaimsRequest) IDTokenClaims() map[string]*ClaimRequest {
+	if r == nil {
+		return nil
+	}
+
+	return r.IDToken
+}
+
+// the names of every claim marked essential in the request
+func (r *ClaimsRequest) EssentialClaims() []string {
+	var names []string
+	for _, requested := range []map[string]*ClaimRequest{r.UserInfoClaims(), r.IDTokenClaims()} {
+		for name, claim := range requested {
+			if claim != nil && claim.Essential && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// the names of every claim in the request
+func (r *ClaimsRequest) ClaimNames() []string {
+	var names []string
+	for _, requested := range []map[string]*ClaimRequest{r.UserInfoClaims(), r.IDTokenClaims()} {
+		for name := range requested {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// whether the value satisfies the `value` and `values` constraints
+func (r *ClaimRequest) Allows(value any) bool {
+	if r == nil {
+		return true
+	}
+
+	if r.Value != nil && !claimValueEqual(r.Value, value) {
+		return false
+	}
+
+	if len(r.Values) > 0 {
+		return slices.ContainsFunc(r.Values, func(v any) bool {
+			return claimValueEqual(v, value)
+		})
+	}
+
+	return true
+}
+
+// compare as JSON, requested values are decoded from JSON while user claims
+// are go values, eg a float64 from the request and an int64 from the user.
+func claimValueEqual(a any, b any) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
+
+// the user's claims that the scopes grant access to plus any individually
+// requested claims the user has. Claims whose value does not satisfy the
+// request's constraints are left out.
+func ClaimsFor(user User, scope []string, requested map[string]*ClaimRequest) map[string]any {
+	var allowed []string
+	for _, s := range scope {
+		allowed = append(allowed, ScopeClaimNames[s]...)
+	}
+	for name := range requested {
+		allowed = append(allowed, name)
+	}
+
+	claims := UserClaims(user)
+	for name, value := range claims {
+		if !slices.Contains(allowed, name) {
+			delete(claims, name)
+			continue
+		}
+
+		if claim, ok := requested[name]; ok && !claim.Allows(value) {
+			delete(claims, name)
+		}
+	}
+
+	return claims
+}
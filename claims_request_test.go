@@ -0,0 +1,231 @@
+package oauth2server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+const testClaimsRequest = `{
+	"userinfo": {"given_name": {"essential": true}, "email": null, "https://example.com/groups": null},
+	"id_token": {"acr": {"values": ["urn:example:acr:mfa"]}, "email_verified": {"value": true, "essential": true}}
+}`
+
+func TestParseClaimsRequest_ParsesMembers(t *testing.T) {
+	req, err := oauth2server.ParseClaimsRequest(testClaimsRequest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := &oauth2server.ClaimsRequest{
+		UserInfo: map[string]*oauth2server.ClaimRequest{
+			"given_name":                 {Essential: true},
+			"email":                      nil,
+			"https://example.com/groups": nil,
+		},
+		IDToken: map[string]*oauth2server.ClaimRequest{
+			"acr":            {Values: []any{"urn:example:acr:mfa"}},
+			"email_verified": {Value: true, Essential: true},
+		},
+	}
+	if diff := cmp.Diff(expected, req); diff != "" {
+		t.Errorf("unexpected claims request (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"email_verified", "given_name"}, req.EssentialClaims()); diff != "" {
+		t.Errorf("unexpected essential claims (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseClaimsRequest_ErrorsOnInvalidJSON(t *testing.T) {
+	_, err := oauth2server.ParseClaimsRequest(`["email"]`)
+
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeInvalidRequest || !errors.Is(err, oauth2server.ErrMalformedClaimsRequest) {
+		t.Errorf("expected invalid_request caused by ErrMalformedClaimsRequest, got %v", err)
+	}
+}
+
+func TestParseAuthorizationRequest_ParsesClaims(t *testing.T) {
+	req, err := parseTestAuthenticationRequest(map[string]string{oauth2server.ParamClaims: testClaimsRequest})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := req.Claims.UserInfoClaims()["given_name"]; !ok {
+		t.Errorf("expected claims request to be parsed, got %+v", req.Claims)
+	}
+}
+
+func TestClaimRequest_Allows(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      *oauth2server.ClaimRequest
+		value    any
+		expected bool
+	}{
+		{"null request", nil, "anything", true},
+		{"no constraints", &oauth2server.ClaimRequest{Essential: true}, "anything", true},
+		{"matching value", &oauth2server.ClaimRequest{Value: "a"}, "a", true},
+		{"other value", &oauth2server.ClaimRequest{Value: "a"}, "b", false},
+		{"numbers from JSON", &oauth2server.ClaimRequest{Value: float64(1700000000)}, int64(1700000000), true},
+		{"one of values", &oauth2server.ClaimRequest{Values: []any{"a", "b"}}, "b", true},
+		{"none of values", &oauth2server.ClaimRequest{Values: []any{"a", "b"}}, "c", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.req.Allows(c.value); got != c.expected {
+				t.Errorf("expected %v, got %v", c.expected, got)
+			}
+		})
+	}
+}
+
+func TestClaimsFor_IncludesRequestedAndCustomClaims(t *testing.T) {
+	user := newTestOIDCUser()
+	user.custom = map[string]any{
+		"https://example.com/groups": []string{"admins"},
+		"https://example.com/hidden": "secret",
+		oauth2server.ClaimEmail:      "custom@example.com",
+	}
+	req, _ := oauth2server.ParseClaimsRequest(testClaimsRequest)
+
+	claims := oauth2server.ClaimsFor(user, []string{oauth2server.ScopeOpenID}, req.UserInfoClaims())
+
+	expected := map[string]any{
+		oauth2server.ClaimEmail:      "user@example.com",
+		"https://example.com/groups": []string{"admins"},
+	}
+	if diff := cmp.Diff(expected, claims); diff != "" {
+		t.Errorf("unexpected claims (-want +got):\n%s", diff)
+	}
+}
+
+func TestClaimsFor_LeavesOutClaimsThatDoNotMatchConstraints(t *testing.T) {
+	user := newTestOIDCUser()
+	user.email.Verified = false
+	req, _ := oauth2server.ParseClaimsRequest(testClaimsRequest)
+
+	claims := oauth2server.ClaimsFor(user, []string{oauth2server.ScopeOpenID}, req.IDTokenClaims())
+
+	if _, ok := claims[oauth2server.ClaimEmailVerified]; ok {
+		t.Errorf("expected email_verified to be left out, got %v", claims)
+	}
+}
+
+func TestIDTokenIssuer_IssueIDToken_IncludesRequestedClaims(t *testing.T) {
+	issuer := newTestIDTokenIssuer(newTestOIDCUser())
+	req, _ := oauth2server.ParseClaimsRequest(`{"id_token": {"name": null}}`)
+
+	token, err := issuer.IssueIDToken(context.Background(), &oauth2server.IDTokenRequest{
+		Client: oauth2server.NewSimpleClient(testClientId, testClientSecret, nil),
+		UserID: "user",
+		Scope:  []string{oauth2server.ScopeOpenID},
+		Claims: req,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	claims := decodeTestJWT(t, token)
+	if claims[oauth2server.ClaimName] != "Test User" {
+		t.Errorf("expected requested name claim, got %v", claims)
+	}
+	if _, ok := claims[oauth2server.ClaimWebsite]; ok {
+		t.Errorf("expected unrequested profile claims to be left out, got %v", claims)
+	}
+}
+
+func TestUserInfoHandler_IncludesClaimsRequestedForTheToken(t *testing.T) {
+	tc := startUserInfoTest(t)
+	req, _ := oauth2server.ParseClaimsRequest(`{"userinfo": {"name": null}, "id_token": {"email": null}}`)
+	tc.tokens.Create(context.Background(), &oauth2server.AccessToken{
+		Token:     "token",
+		ClientID:  testClientId,
+		UserID:    "user",
+		Scope:     []string{oauth2server.ScopeOpenID},
+		Claims:    req,
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+
+	rec := tc.serve(http.MethodGet, "token")
+
+	var claims map[string]any
+	json.Unmarshal(rec.Body.Bytes(), &claims)
+	if claims[oauth2server.ClaimName] != "Test User" {
+		t.Errorf("expected requested name claim, got %v", claims)
+	}
+	if _, ok := claims[oauth2server.ClaimEmail]; ok {
+		t.Errorf("expected claims requested for the ID token to be left out, got %v", claims)
+	}
+}
+
+func TestRefreshTokenGrant_Token_KeepsRequestedClaims(t *testing.T) {
+	tc := startRefreshTokenTest(t)
+	req, _ := oauth2server.ParseClaimsRequest(`{"userinfo": {"name": null}}`)
+	resp, err := tc.issuer.IssueAccessToken(context.Background(), &oauth2server.IssueTokenRequest{
+		Client: oauth2server.NewSimpleClient(testClientId, testClientSecret, nil),
+		UserID: "user",
+		Scope:  []string{oauth2server.ScopeOpenID},
+		Claims: req,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	refreshed, err := tc.grant.Token(context.Background(), newRefreshTokenRequest(t, map[string]string{
+		oauth2server.ParamRefreshToken: resp.RefreshToken,
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	token, _ := tc.tokens.Get(context.Background(), refreshed.AccessToken)
+	if diff := cmp.Diff(req, token.Claims); diff != "" {
+		t.Errorf("expected claims request on the refreshed token (-want +got):\n%s", diff)
+	}
+}
+
+func TestRefreshTokenGrant_Token_IssuesIDTokensWithRequestedClaims(t *testing.T) {
+	clients := oauth2server.NewInMemoryClientRepository()
+	clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	refreshTokens := oauth2server.NewInMemoryRefreshTokenRepository()
+	issuer := oauth2server.NewTokenIssuer(oauth2server.NewInMemoryAccessTokenRepository(), oauth2server.WithRefreshTokens(refreshTokens, 0))
+	grant := oauth2server.NewRefreshTokenGrant(
+		clients,
+		refreshTokens,
+		issuer,
+		oauth2server.WithRefreshTokenIDTokens(newTestIDTokenIssuer(newTestOIDCUser())),
+	)
+	req, _ := oauth2server.ParseClaimsRequest(`{"id_token": {"email_verified": {"essential": true}}}`)
+	resp, err := issuer.IssueAccessToken(context.Background(), &oauth2server.IssueTokenRequest{
+		Client: oauth2server.NewSimpleClient(testClientId, testClientSecret, nil),
+		UserID: "user",
+		Scope:  []string{oauth2server.ScopeOpenID},
+		Claims: req,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	refreshed, err := grant.Token(context.Background(), newRefreshTokenRequest(t, map[string]string{
+		oauth2server.ParamRefreshToken: resp.RefreshToken,
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	claims := decodeTestJWT(t, refreshed.IDToken)
+	if claims[oauth2server.ClaimEmailVerified] != true {
+		t.Errorf("expected the essential claim on the refreshed ID token, got %v", claims)
+	}
+	if claims["at_hash"] != testHalfHash(refreshed.AccessToken) {
+		t.Errorf("expected at_hash of the new access token, got %v", claims["at_hash"])
+	}
+}
@@ -0,0 +1,158 @@
+package oauth2server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+func TestUserClaims_LeavesOutEmptyValues(t *testing.T) {
+	claims := oauth2server.UserClaims(&oidcTestUser{id: "user"})
+
+	if len(claims) != 0 {
+		t.Errorf("expected no claims, got %v", claims)
+	}
+}
+
+func TestUserClaims_FormatsProfileValues(t *testing.T) {
+	zone, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+	user := &oidcTestUser{
+		id: "user",
+		profile: oauth2server.UserProfile{
+			GivenName: "Test",
+			Birthdate: time.Date(1990, time.March, 4, 0, 0, 0, 0, time.UTC),
+			Zoneinfo:  zone,
+			UpdatedAt: time.Unix(1700000000, 0),
+		},
+	}
+
+	claims := oauth2server.UserClaims(user)
+
+	expected := map[string]any{
+		oauth2server.ClaimGivenName: "Test",
+		oauth2server.ClaimBirthdate: "1990-03-04",
+		oauth2server.ClaimZoneinfo:  "America/Chicago",
+		oauth2server.ClaimUpdatedAt: int64(1700000000),
+	}
+	if diff := cmp.Diff(expected, claims); diff != "" {
+		t.Errorf("unexpected claims (-want +got):\n%s", diff)
+	}
+}
+
+func TestUserClaims_HiddenBirthYearIsZeros(t *testing.T) {
+	user := &oidcTestUser{
+		id: "user",
+		profile: oauth2server.UserProfile{
+			Birthdate: time.Date(0, time.December, 25, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	claims := oauth2server.UserClaims(user)
+
+	if claims[oauth2server.ClaimBirthdate] != "0000-12-25" {
+		t.Errorf("expected birthdate without year, got %v", claims[oauth2server.ClaimBirthdate])
+	}
+}
+
+func TestClaimsForScope_OnlyIncludesClaimsForScopes(t *testing.T) {
+	user := newTestOIDCUser()
+
+	claims := oauth2server.ClaimsForScope(user, []string{oauth2server.ScopeOpenID, oauth2server.ScopeEmail})
+
+	expected := map[string]any{
+		oauth2server.ClaimEmail:         "user@example.com",
+		oauth2server.ClaimEmailVerified: true,
+	}
+	if diff := cmp.Diff(expected, claims); diff != "" {
+		t.Errorf("unexpected claims (-want +got):\n%s", diff)
+	}
+}
+
+func TestClaimsForScope_IncludesAddressAndPhone(t *testing.T) {
+	user := newTestOIDCUser()
+	user.address = oauth2server.UserAddress{
+		StreetAddress: "123 Main St",
+		Locality:      "Springfield",
+		Country:       "US",
+	}
+	user.phone = oauth2server.UserPhone{Number: "+15555550100", Verified: true}
+
+	claims := oauth2server.ClaimsForScope(user, []string{oauth2server.ScopeOpenID, oauth2server.ScopeAddress, oauth2server.ScopePhone})
+
+	expected := map[string]any{
+		oauth2server.ClaimAddress: map[string]any{
+			"street_address": "123 Main St",
+			"locality":       "Springfield",
+			"country":        "US",
+		},
+		oauth2server.ClaimPhoneNumber:   "+15555550100",
+		oauth2server.ClaimPhoneVerified: true,
+	}
+	if diff := cmp.Diff(expected, claims); diff != "" {
+		t.Errorf("unexpected claims (-want +got):\n%s", diff)
+	}
+}
+
+func TestUserClaims_LeavesOutPhoneNumbersNotInE164(t *testing.T) {
+	for _, number := range []string{"555-555-0100", "15555550100", "+0155555501", "+1234567890123456"} {
+		user := &oidcTestUser{id: "user", phone: oauth2server.UserPhone{Number: number, Verified: true}}
+
+		claims := oauth2server.UserClaims(user)
+
+		if len(claims) != 0 {
+			t.Errorf("expected no phone claims for %q, got %v", number, claims)
+		}
+	}
+}
+
+func TestSupportedClaims_IncludesScopeClaimsAndExtras(t *testing.T) {
+	claims := oauth2server.SupportedClaims("https://example.com/groups")
+
+	for _, name := range []string{"sub", oauth2server.ClaimEmail, oauth2server.ClaimAddress, oauth2server.ClaimPhoneVerified, "https://example.com/groups"} {
+		if !slices.Contains(claims, name) {
+			t.Errorf("expected %q in supported claims, got %v", name, claims)
+		}
+	}
+}
+
+func TestDefaultAuthorizationServer_Introspect_IncludesUserClaims(t *testing.T) {
+	tokens := oauth2server.NewInMemoryAccessTokenRepository()
+	users := oauth2server.NewInMemoryUserRepository()
+	users.Add(newTestOIDCUser())
+	tc := startAuthorizationServerTest(t, oauth2server.WithIntrospection(tokens), oauth2server.WithUsers(users))
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	tokens.Create(context.Background(), &oauth2server.AccessToken{
+		Token:     "token",
+		ClientID:  testClientId,
+		UserID:    "user",
+		Scope:     []string{oauth2server.ScopeOpenID, oauth2server.ScopeProfile},
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	req := newIntrospectionRequest("token")
+
+	resp, err := tc.server.Introspect(req.Context(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	oauth2server.RespondWithIntrospection(rec, resp)
+	var body map[string]any
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if body["active"] != true || body["sub"] != "user" || body[oauth2server.ClaimName] != "Test User" {
+		t.Errorf("expected user claims at the top level, got %v", body)
+	}
+	if _, ok := body[oauth2server.ClaimEmail]; ok {
+		t.Errorf("expected no email claims without the email scope, got %v", body)
+	}
+}
@@ -2,6 +2,7 @@ package oauth2server
 
 import (
 	"context"
+	"net/http"
 	"sync"
 )
 
@@ -66,6 +67,54 @@ func GetClient(ctx context.Context, clients ClientRepository, clientId string) (
 	return client, nil
 }
 
+// fetch a client from the ClientRepository and authenticate it with its
+// credentials. Confidential clients must include a valid secret, public clients
+// only need to identify themselves.
+// See https://datatracker.ietf.org/doc/html/rfc6749#section-2.3
+func AuthenticateClient(ctx context.Context, clients ClientRepository, clientId string, clientSecret string) (Client, *OAuthError) {
+	if clientId == "" {
+		return nil, unauthenticatedClient(InvalidClientWithCause(ErrMissingClientID, ErrMissingClientID.Error()))
+	}
+
+	client, err := clients.Get(ctx, clientId)
+	if err != nil {
+		return nil, MaybeWrapError(err)
+	}
+
+	if client == nil {
+		return nil, unauthenticatedClient(InvalidClientWithCause(ErrClientNotFound, "client authentication failed"))
+	}
+
+	if !client.IsConfidential() {
+		return client, nil
+	}
+
+	if clientSecret == "" {
+		return nil, unauthenticatedClient(InvalidClientWithCause(ErrMissingClientSecret, ErrMissingClientSecret.Error()))
+	}
+
+	if !validClientSecret(client, clientSecret) {
+		return nil, unauthenticatedClient(InvalidClientWithCause(ErrInvalidClientSecret, "client authentication failed"))
+	}
+
+	return client, nil
+}
+
+func validClientSecret(client Client, secret string) bool {
+	if validates, ok := client.(ClientValidatesSecrets); ok {
+		return validates.ValidSecret(secret)
+	}
+
+	return constantTimeCompare(client.Secret(), secret)
+}
+
+// https://datatracker.ietf.org/doc/html/rfc6749#section-5.2 allows a 401
+// for failed client authentication
+func unauthenticatedClient(e *OAuthError) *OAuthError {
+	e.StatusCode = http.StatusUnauthorized
+	return e
+}
+
 type SimpleClient struct {
 	id             string
 	secret         string
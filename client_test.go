@@ -3,6 +3,7 @@ package oauth2server_test
 import (
 	"context"
 	"errors"
+	"net/http"
 	"testing"
 
 	"github.com/chrisguitarguy/oauth2server"
@@ -148,3 +149,93 @@ func TestGetClient_ReturnsClientWhenFound(t *testing.T) {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
+
+func TestAuthenticateClient_ErrorsIfClientIDIsMissing(t *testing.T) {
+	clients := oauth2server.NewInMemoryClientRepository()
+
+	client, err := oauth2server.AuthenticateClient(context.Background(), clients, "", "")
+
+	if client != nil {
+		t.Errorf("expected a nil client, got %+v", client)
+	}
+	if !errors.Is(err, oauth2server.ErrMissingClientID) {
+		t.Errorf("expected ErrMissingClientID, got %v", err)
+	}
+}
+
+func TestAuthenticateClient_ErrorsIfClientIsNotFound(t *testing.T) {
+	clients := oauth2server.NewInMemoryClientRepository()
+
+	client, err := oauth2server.AuthenticateClient(context.Background(), clients, "nope", "shh")
+
+	if client != nil {
+		t.Errorf("expected a nil client, got %+v", client)
+	}
+	if !errors.Is(err, oauth2server.ErrClientNotFound) {
+		t.Errorf("expected ErrClientNotFound, got %v", err)
+	}
+	if err.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected a %d status code, got %d", http.StatusUnauthorized, err.StatusCode)
+	}
+}
+
+func TestAuthenticateClient_ErrorsIfConfidentialClientHasNoSecret(t *testing.T) {
+	clients := oauth2server.NewInMemoryClientRepository()
+	clients.Add(oauth2server.NewSimpleClient("id", "secret", []string{"https://example.com"}))
+
+	client, err := oauth2server.AuthenticateClient(context.Background(), clients, "id", "")
+
+	if client != nil {
+		t.Errorf("expected a nil client, got %+v", client)
+	}
+	if !errors.Is(err, oauth2server.ErrMissingClientSecret) {
+		t.Errorf("expected ErrMissingClientSecret, got %v", err)
+	}
+}
+
+func TestAuthenticateClient_ErrorsIfSecretIsInvalid(t *testing.T) {
+	clients := oauth2server.NewInMemoryClientRepository()
+	clients.Add(oauth2server.NewSimpleClient("id", "secret", []string{"https://example.com"}))
+
+	client, err := oauth2server.AuthenticateClient(context.Background(), clients, "id", "wrong")
+
+	if client != nil {
+		t.Errorf("expected a nil client, got %+v", client)
+	}
+	if !errors.Is(err, oauth2server.ErrInvalidClientSecret) {
+		t.Errorf("expected ErrInvalidClientSecret, got %v", err)
+	}
+	if err.ErrorType != oauth2server.ErrorTypeInvalidClient {
+		t.Errorf("%q != %q", err.ErrorType, oauth2server.ErrorTypeInvalidClient)
+	}
+}
+
+func TestAuthenticateClient_ReturnsClientWithValidSecret(t *testing.T) {
+	expectedClient := oauth2server.NewSimpleClient("id", "secret", []string{"https://example.com"})
+	clients := oauth2server.NewInMemoryClientRepository()
+	clients.Add(expectedClient)
+
+	client, err := oauth2server.AuthenticateClient(context.Background(), clients, "id", "secret")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client != expectedClient {
+		t.Errorf("invalid client returned: %v != %v", client, expectedClient)
+	}
+}
+
+func TestAuthenticateClient_PublicClientsDoNotNeedASecret(t *testing.T) {
+	expectedClient := oauth2server.NewPublicSimpleClient("id", []string{"https://example.com"})
+	clients := oauth2server.NewInMemoryClientRepository()
+	clients.Add(expectedClient)
+
+	client, err := oauth2server.AuthenticateClient(context.Background(), clients, "id", "")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client != expectedClient {
+		t.Errorf("invalid client returned: %v != %v", client, expectedClient)
+	}
+}
@@ -0,0 +1,200 @@
+package oauth2server
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+)
+
+// a client the user has authorized, for showing in the user's account settings.
+type ConnectedApp struct {
+	ClientID string
+
+	// the client itself, nil if it no longer exists
+	Client Client
+
+	// the ID of the AuthorizationRecord for the user and client, empty if
+	// authorization records are not kept.
+	AuthorizationID string
+
+	// everything the user granted the client, this combines the authorization
+	// record, remembered consent, and active tokens.
+	Scope    []string
+	Resource []string
+
+	// when the user first authorized the client, zero if unknown
+	AuthorizedAt time.Time
+
+	// when the client was last issued a token for the user, zero if it holds
+	// no active tokens. Token use is not tracked, a client may have used its
+	// tokens more recently than this.
+	LastIssuedAt time.Time
+
+	ActiveAccessTokens  int
+	ActiveRefreshTokens int
+}
+
+// Lists and revokes the clients a user has authorized.
+type ConnectedApps interface {
+	// every client the user has authorized, ordered by client ID
+	List(ctx context.Context, userId string) ([]*ConnectedApp, error)
+
+	// remove everything issued to the client on behalf of the user: codes,
+	// refresh tokens, access tokens, the authorization record, and remembered
+	// consent.
+	Revoke(ctx context.Context, userId string, clientId string) error
+
+	// Revoke every client the user has authorized, eg after a password change
+	RevokeAll(ctx context.Context, userId string) error
+}
+
+type connectedApps struct {
+	revocationCascade
+	clients ClientRepository
+}
+
+type ConnectedAppsOption func(*connectedApps)
+
+// include authorization records when listing and revoking
+func WithConnectedAppsAuthorizationRecords(records AuthorizationRecordRepository) ConnectedAppsOption {
+	return func(c *connectedApps) {
+		c.records = records
+	}
+}
+
+// include remembered consent when listing and revoking
+func WithConnectedAppsConsent(consents ConsentRepository) ConnectedAppsOption {
+	return func(c *connectedApps) {
+		c.consents = consents
+	}
+}
+
+// a nil refresh token repository is skipped for servers that do not issue
+// refresh tokens.
+func NewConnectedApps(
+	clients ClientRepository,
+	codes AuthorizationCodeRepository,
+	refreshTokens RefreshTokenRepository,
+	accessTokens AccessTokenRepository,
+	opts ...ConnectedAppsOption,
+) ConnectedApps {
+	c := &connectedApps{
+		revocationCascade: revocationCascade{
+			codes:         codes,
+			refreshTokens: refreshTokens,
+			accessTokens:  accessTokens,
+		},
+		clients: clients,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *connectedApps) List(ctx context.Context, userId string) ([]*ConnectedApp, error) {
+	apps := map[string]*ConnectedApp{}
+	app := func(clientId string) *ConnectedApp {
+		if _, ok := apps[clientId]; !ok {
+			apps[clientId] = &ConnectedApp{ClientID: clientId}
+		}
+		return apps[clientId]
+	}
+
+	if c.records != nil {
+		records, err := c.records.ListForUser(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			a := app(record.ClientID)
+			a.AuthorizationID = record.ID
+			a.Scope = union(a.Scope, record.Scope)
+			a.Resource = union(a.Resource, record.Resource)
+			a.AuthorizedAt = earliest(a.AuthorizedAt, record.CreatedAt)
+		}
+	}
+
+	if c.consents != nil {
+		consents, err := c.consents.ListForUser(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+		for _, consent := range consents {
+			a := app(consent.ClientID)
+			a.Scope = union(a.Scope, consent.Scope)
+			a.Resource = union(a.Resource, consent.Resource)
+			a.AuthorizedAt = earliest(a.AuthorizedAt, consent.GrantedAt)
+		}
+	}
+
+	if c.refreshTokens != nil {
+		refreshTokens, err := c.refreshTokens.ListForUser(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+		for _, token := range refreshTokens {
+			a := app(token.ClientID)
+			a.ActiveRefreshTokens++
+			a.Scope = union(a.Scope, token.Scope)
+			a.Resource = union(a.Resource, token.Resource)
+			a.LastIssuedAt = latest(a.LastIssuedAt, token.IssuedAt)
+		}
+	}
+
+	accessTokens, err := c.accessTokens.ListForUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range accessTokens {
+		a := app(token.ClientID)
+		a.ActiveAccessTokens++
+		a.Scope = union(a.Scope, token.Scope)
+		a.LastIssuedAt = latest(a.LastIssuedAt, token.IssuedAt)
+	}
+
+	list := make([]*ConnectedApp, 0, len(apps))
+	for _, a := range apps {
+		client, err := c.clients.Get(ctx, a.ClientID)
+		if err != nil {
+			return nil, err
+		}
+		a.Client = client
+		list = append(list, a)
+	}
+	slices.SortFunc(list, func(a, b *ConnectedApp) int {
+		return strings.Compare(a.ClientID, b.ClientID)
+	})
+
+	return list, nil
+}
+
+func (c *connectedApps) Revoke(ctx context.Context, userId string, clientId string) error {
+	if clientId == "" {
+		return nil
+	}
+
+	return c.revoke(ctx, revocation{userId: userId, clientId: clientId})
+}
+
+func (c *connectedApps) RevokeAll(ctx context.Context, userId string) error {
+	return c.revoke(ctx, revocation{userId: userId})
+}
+
+func earliest(a time.Time, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+
+	return a
+}
+
+func latest(a time.Time, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+
+	return a
+}
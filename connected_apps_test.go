@@ -0,0 +1,127 @@
+package oauth2server_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+const testOtherClientId = "thirdpartyclient"
+
+func startConnectedAppsTest(t *testing.T) (*authorizationRecordTestCase, oauth2server.ConnectedApps) {
+	t.Helper()
+
+	tc := startAuthorizationRecordTest(t)
+	tc.clients.Add(oauth2server.NewSimpleClient(testOtherClientId, testClientSecret, []string{testRedirectUri}))
+	apps := oauth2server.NewConnectedApps(
+		tc.clients,
+		tc.codes,
+		tc.refreshTokens,
+		tc.accessTokens,
+		oauth2server.WithConnectedAppsAuthorizationRecords(tc.records),
+		oauth2server.WithConnectedAppsConsent(tc.consents),
+	)
+
+	return tc, apps
+}
+
+// the other client is approved, but never exchanges its code
+func authorizeOtherClient(t *testing.T, tc *authorizationRecordTestCase) string {
+	t.Helper()
+
+	params, err := tc.server.CompleteAuthorizationRequest(context.Background(), &oauth2server.AuthorizationRequest{
+		ClientID:     testOtherClientId,
+		ResponseType: []string{oauth2server.ResponseTypeCode},
+		Scope:        []string{"profile"},
+	}, &testUser{id: "user"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return params.Get(oauth2server.ResponseTypeCode)
+}
+
+func TestConnectedApps_List_ListsAuthorizedClients(t *testing.T) {
+	tc, apps := startConnectedAppsTest(t)
+	tc.exchange(t, tc.authorize(t, false, "read", "write"))
+	authorizeOtherClient(t, tc)
+
+	list, err := apps.List(context.Background(), "user")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list) != 2 || list[0].ClientID != testClientId || list[1].ClientID != testOtherClientId {
+		t.Fatalf("expected both clients ordered by ID, got %+v", list)
+	}
+
+	used := list[0]
+	if used.Client == nil || used.AuthorizationID == "" || used.AuthorizedAt.IsZero() || used.LastIssuedAt.IsZero() {
+		t.Errorf("unexpected connected app: %+v", used)
+	}
+	if used.ActiveAccessTokens != 1 || used.ActiveRefreshTokens != 1 || len(used.Scope) != 2 {
+		t.Errorf("expected token counts and scopes, got %+v", used)
+	}
+
+	unused := list[1]
+	if unused.ActiveAccessTokens != 0 || !unused.LastIssuedAt.IsZero() || len(unused.Scope) != 1 {
+		t.Errorf("expected an app with no tokens, got %+v", unused)
+	}
+}
+
+func TestConnectedApps_List_EmptyForUsersWithoutApps(t *testing.T) {
+	_, apps := startConnectedAppsTest(t)
+
+	list, err := apps.List(context.Background(), "nobody")
+
+	if err != nil || len(list) != 0 {
+		t.Errorf("expected no apps, got %+v %v", list, err)
+	}
+}
+
+func TestConnectedApps_Revoke_RevokesOnlyTheClient(t *testing.T) {
+	tc, apps := startConnectedAppsTest(t)
+	resp := tc.exchange(t, tc.authorize(t, false, "read"))
+	code := tc.authorize(t, false, "write")
+	authorizeOtherClient(t, tc)
+
+	if err := apps.Revoke(context.Background(), "user", testClientId); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if token, _ := tc.accessTokens.Get(context.Background(), resp.AccessToken); token != nil {
+		t.Errorf("expected access token to be revoked, got %+v", token)
+	}
+	if token, _ := tc.refreshTokens.Get(context.Background(), resp.RefreshToken); token != nil {
+		t.Errorf("expected refresh token to be revoked, got %+v", token)
+	}
+	if c, _ := tc.codes.Consume(context.Background(), code); c != nil {
+		t.Errorf("expected code to be revoked, got %+v", c)
+	}
+	if consent, _ := tc.consents.Get(context.Background(), "user", testClientId); consent != nil {
+		t.Errorf("expected consent to be forgotten, got %+v", consent)
+	}
+
+	list, _ := apps.List(context.Background(), "user")
+	if len(list) != 1 || list[0].ClientID != testOtherClientId {
+		t.Errorf("expected only the other client to remain, got %+v", list)
+	}
+}
+
+func TestConnectedApps_RevokeAll_RevokesEveryClient(t *testing.T) {
+	tc, apps := startConnectedAppsTest(t)
+	tc.exchange(t, tc.authorize(t, false, "read"))
+	code := authorizeOtherClient(t, tc)
+
+	if err := apps.RevokeAll(context.Background(), "user"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if list, _ := apps.List(context.Background(), "user"); len(list) != 0 {
+		t.Errorf("expected no apps to remain, got %+v", list)
+	}
+	if c, _ := tc.codes.Consume(context.Background(), code); c != nil {
+		t.Errorf("expected pending codes to be revoked, got %+v", c)
+	}
+}
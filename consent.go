@@ -0,0 +1,278 @@
+package oauth2server
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// clients that implement this and return true are trusted, internal apps.
+// The end-user is never asked to approve their requests and no consent is
+// recorded for them.
+type ClientIsFirstParty interface {
+	IsFirstParty() bool
+}
+
+// what an end-user approved for a client.
+type Consent struct {
+	UserID string
+
+	ClientID string
+
+	// the approved scopes
+	Scope []string
+
+	// the approved resources, see https://datatracker.ietf.org/doc/html/rfc8707
+	Resource []string
+
+	// the approved authorization details, see https://datatracker.ietf.org/doc/html/rfc9396
+	AuthorizationDetails []AuthorizationDetail
+
+	// the names of the individually requested claims that were approved
+	Claims []string
+
+	// when the end-user last approved the client
+	GrantedAt time.Time
+
+	// when the consent should no longer be used, zero if it never expires
+	ExpiresAt time.Time
+}
+
+func (c *Consent) IsExpired(now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && !now.Before(c.ExpiresAt)
+}
+
+// whether everything the authorization request asks for was already approved
+func (c *Consent) Covers(req *AuthorizationRequest) bool {
+	return isSubset(req.Scope, c.Scope) &&
+		isSubset(req.Resource, c.Resource) &&
+		authorizationDetailsSubset(req.AuthorizationDetails, c.AuthorizationDetails) &&
+		isSubset(req.Claims.ClaimNames(), c.Claims)
+}
+
+func union(a []string, b []string) []string {
+	merged := slices.Clone(a)
+	for _, v := range b {
+		if !slices.Contains(merged, v) {
+			merged = append(merged, v)
+		}
+	}
+
+	return merged
+}
+
+// what the end-user approved in an authorization request, this must be a
+// subset of what was requested. The approved request is what codes and tokens
+// are issued for.
+type ConsentDecision struct {
+	Scope []string
+
+	Resource []string
+
+	AuthorizationDetails []AuthorizationDetail
+}
+
+// a decision that approves everything in the authorization request
+func ApproveAll(req *AuthorizationRequest) *ConsentDecision {
+	return &ConsentDecision{
+		Scope:                req.Scope,
+		Resource:             req.Resource,
+		AuthorizationDetails: req.AuthorizationDetails,
+	}
+}
+
+// the authorization request narrowed to what the decision approves.
+func (d *ConsentDecision) apply(req *AuthorizationRequest) (*AuthorizationRequest, *OAuthError) {
+	if !isSubset(d.Scope, req.Scope) ||
+		!isSubset(d.Resource, req.Resource) ||
+		!authorizationDetailsSubset(d.AuthorizationDetails, req.AuthorizationDetails) {
+		return nil, ServerError(ErrConsentNotRequested)
+	}
+
+	// an empty `scope` in the token response would look like everything
+	// requested was granted, see https://datatracker.ietf.org/doc/html/rfc6749#section-5.1
+	if len(req.Scope) > 0 && len(d.Scope) == 0 {
+		return nil, &OAuthError{
+			ErrorType:        ErrorTypeAccessDenied,
+			ErrorDescription: ErrNoScopesGranted.Error(),
+			Cause:            ErrNoScopesGranted,
+		}
+	}
+
+	approved := *req
+	approved.Scope = d.Scope
+	approved.Resource = d.Resource
+	approved.AuthorizationDetails = d.AuthorizationDetails
+
+	return &approved, nil
+}
+
+// storage for remembered consent, one per user and client.
+type ConsentRepository interface {
+	// Get the consent the user gave the client, return a `nil` consent if not
+	// found or expired. Any errors returned here will be propagated as server
+	// errors.
+	Get(ctx context.Context, userId string, clientId string) (*Consent, error)
+
+	// store the consent, replacing any existing consent for the user and client
+	Save(ctx context.Context, consent *Consent) error
+
+	// forget the consent the user gave the client, if any
+	Revoke(ctx context.Context, userId string, clientId string) error
+
+	// every unexpired consent the user has given
+	ListForUser(ctx context.Context, userId string) ([]*Consent, error)
+}
+
+type inMemoryConsentKey struct {
+	userId   string
+	clientId string
+}
+
+type InMemoryConsentRepository struct {
+	lock     sync.RWMutex
+	consents map[inMemoryConsentKey]*Consent
+}
+
+func NewInMemoryConsentRepository() *InMemoryConsentRepository {
+	return &InMemoryConsentRepository{
+		consents: make(map[inMemoryConsentKey]*Consent),
+	}
+}
+
+func (r *InMemoryConsentRepository) Get(ctx context.Context, userId string, clientId string) (*Consent, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	consent, ok := r.consents[inMemoryConsentKey{userId, clientId}]
+	if !ok || consent.IsExpired(time.Now()) {
+		return nil, nil
+	}
+
+	return consent, nil
+}
+
+func (r *InMemoryConsentRepository) Save(ctx context.Context, consent *Consent) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.consents[inMemoryConsentKey{consent.UserID, consent.ClientID}] = consent
+
+	return nil
+}
+
+func (r *InMemoryConsentRepository) Revoke(ctx context.Context, userId string, clientId string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.consents, inMemoryConsentKey{userId, clientId})
+
+	return nil
+}
+
+func (r *InMemoryConsentRepository) ListForUser(ctx context.Context, userId string) ([]*Consent, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	now := time.Now()
+	var consents []*Consent
+	for key, consent := range r.consents {
+		if key.userId == userId && !consent.IsExpired(now) {
+			consents = append(consents, consent)
+		}
+	}
+
+	return consents, nil
+}
+
+// remember what end-users approve so they are not asked again. Completed
+// authorization requests are recorded in the repository and expire after
+// lifetime, a zero lifetime never expires.
+func WithConsent(consents ConsentRepository, lifetime time.Duration) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.consents = consents
+		opts.consentLifetime = lifetime
+	}
+}
+
+func isFirstParty(client Client) bool {
+	firstParty, ok := client.(ClientIsFirstParty)
+
+	return ok && firstParty.IsFirstParty()
+}
+
+func (s *defaultAuthorizationServer) RequiresConsent(ctx context.Context, req *AuthorizationRequest, user User) (bool, *OAuthError) {
+	client, clientErr := GetClient(ctx, s.clients, req.ClientID)
+	if clientErr != nil {
+		return false, clientErr
+	}
+
+	required, err := s.requiresConsent(ctx, client, req, user)
+	if err != nil {
+		return false, MaybeWrapError(err)
+	}
+
+	if required && req.IsSilent() {
+		return true, ConsentRequired()
+	}
+
+	return required, nil
+}
+
+func (s *defaultAuthorizationServer) requiresConsent(ctx context.Context, client Client, req *AuthorizationRequest, user User) (bool, error) {
+	// the client asked for the end-user to be prompted, even if they are trusted
+	if req.HasPrompt(PromptConsent) {
+		return true, nil
+	}
+
+	if isFirstParty(client) {
+		return false, nil
+	}
+
+	if s.consents == nil {
+		return true, nil
+	}
+
+	consent, err := s.consents.Get(ctx, user.ID(), client.ID())
+	if err != nil {
+		return false, err
+	}
+
+	return consent == nil || consent.IsExpired(time.Now()) || !consent.Covers(req), nil
+}
+
+// merge the approved request into the user's existing consent for the client.
+func (s *defaultAuthorizationServer) recordConsent(ctx context.Context, client Client, req *AuthorizationRequest, user User) error {
+	if s.consents == nil || isFirstParty(client) {
+		return nil
+	}
+
+	existing, err := s.consents.Get(ctx, user.ID(), client.ID())
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	consent := &Consent{
+		UserID:    user.ID(),
+		ClientID:  client.ID(),
+		Scope:     req.Scope,
+		Resource:  req.Resource,
+		Claims:    req.Claims.ClaimNames(),
+		GrantedAt: now,
+
+		AuthorizationDetails: req.AuthorizationDetails,
+	}
+	if existing != nil && !existing.IsExpired(now) {
+		consent.Scope = union(existing.Scope, consent.Scope)
+		consent.Resource = union(existing.Resource, consent.Resource)
+		consent.Claims = union(existing.Claims, consent.Claims)
+		consent.AuthorizationDetails = unionAuthorizationDetails(existing.AuthorizationDetails, consent.AuthorizationDetails)
+	}
+	if s.consentLifetime > 0 {
+		consent.ExpiresAt = now.Add(s.consentLifetime)
+	}
+
+	return s.consents.Save(ctx, consent)
+}
@@ -0,0 +1,284 @@
+package oauth2server_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+type firstPartyClient struct {
+	oauth2server.Client
+}
+
+func (c *firstPartyClient) IsFirstParty() bool {
+	return true
+}
+
+type consentTestCase struct {
+	*authorizationServerTestCase
+	consents *oauth2server.InMemoryConsentRepository
+	user     *testUser
+}
+
+func startConsentTest(t *testing.T, lifetime time.Duration) *consentTestCase {
+	t.Helper()
+
+	consents := oauth2server.NewInMemoryConsentRepository()
+	tc := startAuthorizationServerTest(
+		t,
+		oauth2server.WithAuthorizationHandler(&spyAuthorizationHandler{responseType: "code", issueAuthorizationResponseReturn: "code"}),
+		oauth2server.WithConsent(consents, lifetime),
+	)
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+
+	return &consentTestCase{
+		authorizationServerTestCase: tc,
+		consents:                    consents,
+		user:                        &testUser{id: "user"},
+	}
+}
+
+func (tc *consentTestCase) complete(t *testing.T, req *oauth2server.AuthorizationRequest) {
+	t.Helper()
+
+	if _, err := tc.server.CompleteAuthorizationRequest(context.Background(), req, tc.user); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func newConsentTestRequest(scope ...string) *oauth2server.AuthorizationRequest {
+	return &oauth2server.AuthorizationRequest{
+		ClientID:     testClientId,
+		ResponseType: []string{"code"},
+		Scope:        scope,
+	}
+}
+
+func TestDefaultAuthorizationServer_RequiresConsent_RequiredWithoutRememberedConsent(t *testing.T) {
+	tc := startConsentTest(t, 0)
+
+	required, err := tc.server.RequiresConsent(context.Background(), newConsentTestRequest("read"), tc.user)
+
+	if err != nil || !required {
+		t.Errorf("expected consent to be required, got %v %v", required, err)
+	}
+}
+
+func TestDefaultAuthorizationServer_RequiresConsent_NotRequiredWhenCoveredByRememberedConsent(t *testing.T) {
+	tc := startConsentTest(t, 0)
+	tc.complete(t, newConsentTestRequest("read", "write"))
+
+	required, err := tc.server.RequiresConsent(context.Background(), newConsentTestRequest("read"), tc.user)
+
+	if err != nil || required {
+		t.Errorf("expected consent to not be required, got %v %v", required, err)
+	}
+}
+
+func TestDefaultAuthorizationServer_RequiresConsent_RequiredForNewScopesResourcesAndClaims(t *testing.T) {
+	tc := startConsentTest(t, 0)
+	tc.complete(t, newConsentTestRequest("read"))
+	claims, _ := oauth2server.ParseClaimsRequest(`{"userinfo": {"email": null}}`)
+	requests := map[string]*oauth2server.AuthorizationRequest{
+		"scope":    newConsentTestRequest("read", "write"),
+		"resource": {ClientID: testClientId, Scope: []string{"read"}, Resource: []string{"https://api.example.com"}},
+		"claims":   {ClientID: testClientId, Scope: []string{"read"}, Claims: claims},
+	}
+
+	for name, req := range requests {
+		required, err := tc.server.RequiresConsent(context.Background(), req, tc.user)
+
+		if err != nil || !required {
+			t.Errorf("%s: expected consent to be required, got %v %v", name, required, err)
+		}
+	}
+}
+
+func TestDefaultAuthorizationServer_RequiresConsent_RequiredForNewAuthorizationDetails(t *testing.T) {
+	tc := startConsentTest(t, 0)
+	approved, _ := oauth2server.ParseAuthorizationDetails(`[{"type": "payment", "amount": "10.00"}]`)
+	different, _ := oauth2server.ParseAuthorizationDetails(`[{"type": "payment", "amount": "500.00"}]`)
+	req := newConsentTestRequest("read")
+	req.AuthorizationDetails = approved
+	tc.complete(t, req)
+
+	same := newConsentTestRequest("read")
+	same.AuthorizationDetails = approved
+	required, err := tc.server.RequiresConsent(context.Background(), same, tc.user)
+	if err != nil || required {
+		t.Errorf("expected consent to not be required for approved details, got %v %v", required, err)
+	}
+
+	other := newConsentTestRequest("read")
+	other.AuthorizationDetails = different
+	required, err = tc.server.RequiresConsent(context.Background(), other, tc.user)
+	if err != nil || !required {
+		t.Errorf("expected consent to be required for new details, got %v %v", required, err)
+	}
+}
+
+func TestDefaultAuthorizationServer_RequiresConsent_RequiredWhenConsentExpires(t *testing.T) {
+	tc := startConsentTest(t, 0)
+	tc.consents.Save(context.Background(), &oauth2server.Consent{
+		UserID:    "user",
+		ClientID:  testClientId,
+		Scope:     []string{"read"},
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+
+	required, err := tc.server.RequiresConsent(context.Background(), newConsentTestRequest("read"), tc.user)
+
+	if err != nil || !required {
+		t.Errorf("expected consent to be required, got %v %v", required, err)
+	}
+}
+
+func TestDefaultAuthorizationServer_RequiresConsent_RequiredWithPromptConsent(t *testing.T) {
+	tc := startConsentTest(t, 0)
+	tc.clients.Add(&firstPartyClient{Client: oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri})})
+	req := newConsentTestRequest("read")
+	req.Prompt = []string{oauth2server.PromptConsent}
+
+	required, err := tc.server.RequiresConsent(context.Background(), req, tc.user)
+
+	if err != nil || !required {
+		t.Errorf("expected consent to be required, got %v %v", required, err)
+	}
+}
+
+func TestDefaultAuthorizationServer_RequiresConsent_ErrorsForSilentRequests(t *testing.T) {
+	tc := startConsentTest(t, 0)
+	req := newConsentTestRequest("read")
+	req.Prompt = []string{oauth2server.PromptNone}
+
+	required, err := tc.server.RequiresConsent(context.Background(), req, tc.user)
+
+	if !required || err == nil || err.ErrorType != oauth2server.ErrorTypeConsentRequired {
+		t.Errorf("expected consent_required error, got %v %v", required, err)
+	}
+}
+
+func TestDefaultAuthorizationServer_RequiresConsent_SkipsFirstPartyClients(t *testing.T) {
+	tc := startConsentTest(t, 0)
+	tc.clients.Add(&firstPartyClient{Client: oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri})})
+
+	required, err := tc.server.RequiresConsent(context.Background(), newConsentTestRequest("read"), tc.user)
+	if err != nil || required {
+		t.Errorf("expected consent to not be required, got %v %v", required, err)
+	}
+
+	tc.complete(t, newConsentTestRequest("read"))
+	consent, _ := tc.consents.Get(context.Background(), "user", testClientId)
+	if consent != nil {
+		t.Errorf("expected no consent to be recorded for first party clients, got %+v", consent)
+	}
+}
+
+func TestDefaultAuthorizationServer_CompleteAuthorizationRequest_MergesRememberedConsent(t *testing.T) {
+	tc := startConsentTest(t, time.Hour)
+	claims, _ := oauth2server.ParseClaimsRequest(`{"id_token": {"email": null}}`)
+	tc.complete(t, newConsentTestRequest("read"))
+	tc.complete(t, &oauth2server.AuthorizationRequest{
+		ClientID:     testClientId,
+		ResponseType: []string{"code"},
+		Scope:        []string{"write"},
+		Resource:     []string{"https://api.example.com"},
+		Claims:       claims,
+	})
+
+	consent, _ := tc.consents.Get(context.Background(), "user", testClientId)
+
+	expected := &oauth2server.Consent{
+		UserID:   "user",
+		ClientID: testClientId,
+		Scope:    []string{"read", "write"},
+		Resource: []string{"https://api.example.com"},
+		Claims:   []string{"email"},
+	}
+	if diff := cmp.Diff(expected, consent, cmpopts.IgnoreFields(oauth2server.Consent{}, "GrantedAt", "ExpiresAt")); diff != "" {
+		t.Errorf("unexpected consent (-want +got):\n%s", diff)
+	}
+	if consent.ExpiresAt.Sub(consent.GrantedAt) != time.Hour {
+		t.Errorf("expected consent to expire after its lifetime, got %v to %v", consent.GrantedAt, consent.ExpiresAt)
+	}
+}
+
+func TestInMemoryConsentRepository_Revoke(t *testing.T) {
+	consents := oauth2server.NewInMemoryConsentRepository()
+	consents.Save(context.Background(), &oauth2server.Consent{UserID: "user", ClientID: testClientId})
+
+	consents.Revoke(context.Background(), "user", testClientId)
+
+	if consent, _ := consents.Get(context.Background(), "user", testClientId); consent != nil {
+		t.Errorf("expected consent to be revoked, got %+v", consent)
+	}
+}
+
+func TestDefaultAuthorizationServer_ApproveAuthorizationRequest_IssuesForApprovedScopes(t *testing.T) {
+	tc := startAuthorizationCodeTest(t)
+	consents := oauth2server.NewInMemoryConsentRepository()
+	server := oauth2server.NewAuthorizationServer(tc.clients, oauth2server.WithGrant(tc.grant), oauth2server.WithConsent(consents, 0))
+	req := &oauth2server.AuthorizationRequest{
+		ClientID:            testClientId,
+		RedirectURI:         testRedirectUri,
+		ResponseType:        []string{oauth2server.ResponseTypeCode},
+		Scope:               []string{"read", "write", "delete"},
+		CodeChallenge:       testCodeChallenge,
+		CodeChallengeMethod: oauth2server.CodeChallengeMethodS256,
+	}
+
+	params, err := server.ApproveAuthorizationRequest(context.Background(), req, &testUser{id: "user"}, &oauth2server.ConsentDecision{
+		Scope: []string{"read", "delete"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, tokenErr := tc.grant.Token(context.Background(), newCodeTokenRequest(t, map[string]string{
+		oauth2server.ParamCode:         params.Get(oauth2server.ResponseTypeCode),
+		oauth2server.ParamRedirectURI:  testRedirectUri,
+		oauth2server.ParamCodeVerifier: testCodeVerifier,
+	}))
+	if tokenErr != nil {
+		t.Fatalf("unexpected error: %v", tokenErr)
+	}
+	if resp.Scope != "read delete" {
+		t.Errorf("expected granted scope in token response, got %q", resp.Scope)
+	}
+
+	consent, _ := consents.Get(context.Background(), "user", testClientId)
+	if consent == nil || !cmp.Equal([]string{"read", "delete"}, consent.Scope) {
+		t.Errorf("expected only approved scopes to be remembered, got %+v", consent)
+	}
+	if len(req.Scope) != 3 {
+		t.Errorf("expected the authorization request to be left as is, got %v", req.Scope)
+	}
+}
+
+func TestDefaultAuthorizationServer_ApproveAuthorizationRequest_ErrorsIfDecisionWasNotRequested(t *testing.T) {
+	tc := startConsentTest(t, 0)
+
+	_, err := tc.server.ApproveAuthorizationRequest(context.Background(), newConsentTestRequest("read"), tc.user, &oauth2server.ConsentDecision{
+		Scope: []string{"read", "admin"},
+	})
+
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeServerError || !errors.Is(err, oauth2server.ErrConsentNotRequested) {
+		t.Errorf("expected server error caused by ErrConsentNotRequested, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_ApproveAuthorizationRequest_DeniesIfNoScopesWereApproved(t *testing.T) {
+	tc := startConsentTest(t, 0)
+
+	_, err := tc.server.ApproveAuthorizationRequest(context.Background(), newConsentTestRequest("read"), tc.user, &oauth2server.ConsentDecision{})
+
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeAccessDenied || !errors.Is(err, oauth2server.ErrNoScopesGranted) {
+		t.Errorf("expected access_denied caused by ErrNoScopesGranted, got %v", err)
+	}
+}
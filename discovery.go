@@ -0,0 +1,109 @@
+package oauth2server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	// where the discovery document is served relative to the issuer, see
+	// https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderConfig
+	OpenIDConfigurationPath = "/.well-known/openid-configuration"
+
+	// how long clients may cache the discovery document and JWK set.
+	discoveryCacheControl = "public, max-age=3600"
+)
+
+// OpenID provider metadata, see
+// https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata
+// and https://datatracker.ietf.org/doc/html/rfc8414#section-2
+type ProviderMetadata struct {
+	Issuer                                 string   `json:"issuer"`
+	AuthorizationEndpoint                  string   `json:"authorization_endpoint"`
+	TokenEndpoint                          string   `json:"token_endpoint,omitempty"`
+	UserInfoEndpoint                       string   `json:"userinfo_endpoint,omitempty"`
+	JWKSURI                                string   `json:"jwks_uri"`
+	RegistrationEndpoint                   string   `json:"registration_endpoint,omitempty"`
+	IntrospectionEndpoint                  string   `json:"introspection_endpoint,omitempty"`
+	PushedAuthorizationRequestEndpoint     string   `json:"pushed_authorization_request_endpoint,omitempty"`
+	EndSessionEndpoint                     string   `json:"end_session_endpoint,omitempty"`
+	ScopesSupported                        []string `json:"scopes_supported,omitempty"`
+	ResponseTypesSupported                 []string `json:"response_types_supported"`
+	ResponseModesSupported                 []string `json:"response_modes_supported,omitempty"`
+	GrantTypesSupported                    []string `json:"grant_types_supported,omitempty"`
+	SubjectTypesSupported                  []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported       []string `json:"id_token_signing_alg_values_supported"`
+	UserInfoSigningAlgValuesSupported      []string `json:"userinfo_signing_alg_values_supported,omitempty"`
+	AuthorizationSigningAlgValuesSupported []string `json:"authorization_signing_alg_values_supported,omitempty"`
+	RequestObjectSigningAlgValuesSupported []string `json:"request_object_signing_alg_values_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported      []string `json:"token_endpoint_auth_methods_supported,omitempty"`
+	ClaimsSupported                        []string `json:"claims_supported,omitempty"`
+	ACRValuesSupported                     []string `json:"acr_values_supported,omitempty"`
+	DisplayValuesSupported                 []string `json:"display_values_supported,omitempty"`
+	PromptValuesSupported                  []string `json:"prompt_values_supported,omitempty"`
+	UILocalesSupported                     []string `json:"ui_locales_supported,omitempty"`
+	CodeChallengeMethodsSupported          []string `json:"code_challenge_methods_supported,omitempty"`
+	AuthorizationDetailsTypesSupported     []string `json:"authorization_details_types_supported,omitempty"`
+	ClaimsParameterSupported               bool     `json:"claims_parameter_supported,omitempty"`
+	RequestParameterSupported              bool     `json:"request_parameter_supported"`
+	RequestURIParameterSupported           bool     `json:"request_uri_parameter_supported"`
+	RequirePushedAuthorizationRequests     bool     `json:"require_pushed_authorization_requests,omitempty"`
+	FrontChannelLogoutSupported            bool     `json:"frontchannel_logout_supported,omitempty"`
+	FrontChannelLogoutSessionSupported     bool     `json:"frontchannel_logout_session_supported,omitempty"`
+	BackChannelLogoutSupported             bool     `json:"backchannel_logout_supported,omitempty"`
+	BackChannelLogoutSessionSupported      bool     `json:"backchannel_logout_session_supported,omitempty"`
+}
+
+// serves the provider metadata, this should be mounted at
+// OpenIDConfigurationPath.
+func NewDiscoveryHandler(metadata *ProviderMetadata) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allowGet(w, r) {
+			return
+		}
+
+		cacheableJSONResponse(w, metadata)
+	})
+}
+
+// serves the public keys of the key set as a JWK set, the `jwks_uri`.
+func NewJWKSHandler(keys PublicKeySet) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allowGet(w, r) {
+			return
+		}
+
+		publicKeys, err := keys.PublicKeys(r.Context())
+		if err != nil {
+			RespondWithError(w, *ServerError(err))
+			return
+		}
+
+		cacheableJSONResponse(w, JSONWebKeySet{Keys: publicKeys})
+	})
+}
+
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+
+	w.Header().Set("Allow", "GET, HEAD")
+	w.WriteHeader(http.StatusMethodNotAllowed)
+
+	return false
+}
+
+func cacheableJSONResponse(w http.ResponseWriter, body any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return RespondWithError(w, *ServerError(err))
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("Cache-Control", discoveryCacheControl)
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(b)
+
+	return err
+}
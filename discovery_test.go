@@ -0,0 +1,78 @@
+package oauth2server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+func TestDiscoveryHandler_ServesMetadata(t *testing.T) {
+	handler := oauth2server.NewDiscoveryHandler(&oauth2server.ProviderMetadata{
+		Issuer:                           testOIDCIssuer,
+		AuthorizationEndpoint:            testOIDCIssuer + "/authorize",
+		JWKSURI:                          testOIDCIssuer + "/jwks",
+		ResponseTypesSupported:           []string{oauth2server.ResponseTypeCode},
+		SubjectTypesSupported:            []string{oauth2server.SubjectTypePublic},
+		IDTokenSigningAlgValuesSupported: []string{oauth2server.SigningAlgRS256},
+	})
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, oauth2server.OpenIDConfigurationPath, nil))
+
+	var body map[string]any
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	expected := map[string]any{
+		"issuer":                                testOIDCIssuer,
+		"authorization_endpoint":                testOIDCIssuer + "/authorize",
+		"jwks_uri":                              testOIDCIssuer + "/jwks",
+		"response_types_supported":              []any{"code"},
+		"subject_types_supported":               []any{"public"},
+		"id_token_signing_alg_values_supported": []any{"RS256"},
+		"request_parameter_supported":           false,
+		"request_uri_parameter_supported":       false,
+	}
+	if diff := cmp.Diff(expected, body); diff != "" {
+		t.Errorf("unexpected metadata (-want +got):\n%s", diff)
+	}
+	if rec.Header().Get("Cache-Control") == "no-store" {
+		t.Error("expected discovery document to be cacheable")
+	}
+}
+
+func TestDiscoveryHandler_RejectsOtherMethods(t *testing.T) {
+	handler := oauth2server.NewDiscoveryHandler(&oauth2server.ProviderMetadata{})
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, oauth2server.OpenIDConfigurationPath, nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestJWKSHandler_PublishesPublicKeys(t *testing.T) {
+	key := newTestSigningKey()
+	handler := oauth2server.NewJWKSHandler(oauth2server.NewStaticKeySet(key).(oauth2server.PublicKeySet))
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jwks", nil))
+
+	var set oauth2server.JSONWebKeySet
+	if err := json.Unmarshal(rec.Body.Bytes(), &set); err != nil {
+		t.Fatalf("could not decode JWK set: %v", err)
+	}
+	if len(set.Keys) != 1 || set.Keys[0].KeyID != key.ID || set.Keys[0].Use != "sig" {
+		t.Errorf("unexpected keys: %+v", set.Keys)
+	}
+
+	jwt, _ := oauth2server.SignJWT(key, map[string]any{"sub": "user"})
+	var claims map[string]any
+	if err := oauth2server.VerifyJWT(jwt, set.Keys, &claims); err != nil {
+		t.Errorf("could not verify JWT with published keys: %v", err)
+	}
+}
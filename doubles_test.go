@@ -2,6 +2,7 @@ package oauth2server_test
 
 import (
 	"context"
+	"time"
 
 	"github.com/chrisguitarguy/oauth2server"
 )
@@ -18,6 +19,9 @@ type SpyClient struct {
 
 	allowsResponseTypeCalls  [][]string
 	allowsResponseTypeReturn bool
+
+	allowsResponseModeCalls  []string
+	allowsResponseModeReturn bool
 }
 
 func (c *SpyClient) ID() string {
@@ -46,6 +50,11 @@ func (c *SpyClient) AllowsResponseType(responseTypes []string) bool {
 	return c.allowsResponseTypeReturn
 }
 
+func (c *SpyClient) AllowsResponseMode(responseMode string) bool {
+	c.allowsResponseModeCalls = append(c.allowsResponseModeCalls, responseMode)
+	return c.allowsResponseModeReturn
+}
+
 type spyPKCEVerifyCall struct {
 	method    string
 	challenge string
@@ -83,6 +92,16 @@ type spyAuthorizationHandler struct {
 
 	validateAuthorizationRequestCalls []validateAuthorizationRequestCall
 	validateAuthorizationRequestError error
+
+	issueAuthorizationResponseCalls  []issueAuthorizationResponseCall
+	issueAuthorizationResponseReturn string
+	issueAuthorizationResponseError  error
+}
+
+type issueAuthorizationResponseCall struct {
+	client oauth2server.Client
+	req    *oauth2server.AuthorizationRequest
+	user   oauth2server.User
 }
 
 func (s *spyAuthorizationHandler) ResponseType() string {
@@ -111,5 +130,119 @@ func (s *spyAuthorizationHandler) IssueAuthorizationResponse(
 	req *oauth2server.AuthorizationRequest,
 	user oauth2server.User,
 ) (string, error) {
-	return "", nil // TODO
+	s.issueAuthorizationResponseCalls = append(
+		s.issueAuthorizationResponseCalls,
+		issueAuthorizationResponseCall{
+			client: client,
+			req:    req,
+			user:   user,
+		},
+	)
+
+	return s.issueAuthorizationResponseReturn, s.issueAuthorizationResponseError
+}
+
+type testUser struct {
+	id string
+}
+
+func (u *testUser) ID() string {
+	return u.id
+}
+
+type spyFetcher struct {
+	body  []byte
+	err   error
+	calls []string
+}
+
+func (f *spyFetcher) Fetch(ctx context.Context, uri string) ([]byte, error) {
+	f.calls = append(f.calls, uri)
+	return f.body, f.err
+}
+
+type keyedClient struct {
+	oauth2server.Client
+	keys []oauth2server.JSONWebKey
+}
+
+func (c *keyedClient) JSONWebKeys(ctx context.Context) ([]oauth2server.JSONWebKey, error) {
+	return c.keys, nil
+}
+
+type spyAuthorizationDetailsValidator struct {
+	detailsType string
+	err         error
+	calls       []oauth2server.AuthorizationDetail
+}
+
+func (v *spyAuthorizationDetailsValidator) Type() string {
+	return v.detailsType
+}
+
+func (v *spyAuthorizationDetailsValidator) ValidateAuthorizationDetail(ctx context.Context, client oauth2server.Client, detail oauth2server.AuthorizationDetail) error {
+	v.calls = append(v.calls, detail)
+	return v.err
+}
+
+type detailsTypesClient struct {
+	oauth2server.Client
+	types []string
+}
+
+func (c *detailsTypesClient) AuthorizationDetailsTypes() []string {
+	return c.types
+}
+
+type oidcTestUser struct {
+	id       string
+	email    oauth2server.UserEmail
+	profile  oauth2server.UserProfile
+	address  oauth2server.UserAddress
+	phone    oauth2server.UserPhone
+	authTime time.Time
+	acr      string
+	amr      []string
+	session  string
+	custom   map[string]any
+}
+
+func (u *oidcTestUser) ID() string {
+	return u.id
+}
+
+func (u *oidcTestUser) UserEmail() oauth2server.UserEmail {
+	return u.email
+}
+
+func (u *oidcTestUser) UserProfile() oauth2server.UserProfile {
+	return u.profile
+}
+
+func (u *oidcTestUser) UserAddress() oauth2server.UserAddress {
+	return u.address
+}
+
+func (u *oidcTestUser) UserPhone() oauth2server.UserPhone {
+	return u.phone
+}
+
+func (u *oidcTestUser) AuthTime() time.Time {
+	return u.authTime
+}
+
+func (u *oidcTestUser) ACR() string {
+	return u.acr
+}
+
+func (u *oidcTestUser) AMR() []string {
+	return u.amr
+}
+
+func (u *oidcTestUser) SessionID() string {
+	return u.session
+}
+
+func (u *oidcTestUser) Claims() map[string]any {
+	return u.custom
 }
@@ -3,36 +3,117 @@ package oauth2server
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strings"
 )
 
 var (
-	ErrInvalidRequestMethod           = errors.New("invalid request method")
-	ErrCouldNotParseRequestBody       = errors.New("could not parse request body")
-	ErrCouldNotParseQueryString       = errors.New("could not parse query string")
-	ErrMissingGrantType               = fmt.Errorf("missing %s in request body", ParamGrantType)
-	ErrMissingClientID                = fmt.Errorf("%s was not included in the request", ParamClientID)
-	ErrClientNotFound                 = errors.New("client not found")
-	ErrClientHasNoRedirectURIs        = errors.New("client does not have any redirect URIs")
-	ErrClientRequiresRedirectURI      = errors.New("the client has >1 redirect uri and redirect_uri must be included in the request")
-	ErrClientInvalidRedirectURI       = errors.New("redirect_uri in request was not valid for the client")
-	ErrMissingClientSecret            = fmt.Errorf("%s was not included in the request", ParamClientSecret)
-	ErrMissingResponseType            = fmt.Errorf("%s was not included in the requset", ParamResponseType)
-	ErrUnsupportedCodeChallengeMethod = errors.New("code challenge method not supported")
-	ErrInvalidCodeChallenge           = errors.New("code challenges must be 43-128 alphanumeric characters, dashes, or underscores")
+	ErrInvalidRequestMethod                = errors.New("invalid request method")
+	ErrCouldNotParseRequestBody            = errors.New("could not parse request body")
+	ErrCouldNotParseQueryString            = errors.New("could not parse query string")
+	ErrMissingGrantType                    = fmt.Errorf("missing %s in request body", ParamGrantType)
+	ErrMissingClientID                     = fmt.Errorf("%s was not included in the request", ParamClientID)
+	ErrClientNotFound                      = errors.New("client not found")
+	ErrClientHasNoRedirectURIs             = errors.New("client does not have any redirect URIs")
+	ErrClientRequiresRedirectURI           = errors.New("the client has >1 redirect uri and redirect_uri must be included in the request")
+	ErrClientInvalidRedirectURI            = errors.New("redirect_uri in request was not valid for the client")
+	ErrMissingClientSecret                 = fmt.Errorf("%s was not included in the request", ParamClientSecret)
+	ErrInvalidClientSecret                 = errors.New("client secret is not valid")
+	ErrMissingResponseType                 = fmt.Errorf("%s was not included in the requset", ParamResponseType)
+	ErrUnsupportedCodeChallengeMethod      = errors.New("code challenge method not supported")
+	ErrInvalidCodeChallenge                = errors.New("code challenges must be 43-128 alphanumeric characters, dashes, or underscores")
+	ErrUnsupportedResponseMode             = errors.New("response mode not supported")
+	ErrResponseModeNotAllowed              = errors.New("response mode cannot be used with the requested response type")
+	ErrNoAuthorizationRedirectURI          = errors.New("authorization request has no final redirect URI")
+	ErrInvalidRequestURI                   = fmt.Errorf("%s is invalid or expired", ParamRequestURI)
+	ErrRequestURINotAllowed                = fmt.Errorf("%s cannot be included in a pushed authorization request", ParamRequestURI)
+	ErrRequestURINotSupported              = fmt.Errorf("%s is not supported", ParamRequestURI)
+	ErrPushedAuthorizationRequired         = errors.New("authorization requests must be pushed")
+	ErrPushedAuthorizationRequestsNotSet   = errors.New("this server does not have pushed authorization requests configured")
+	ErrRequestNotSupported                 = fmt.Errorf("%s is not supported", ParamRequest)
+	ErrInvalidRequestObject                = errors.New("request object is invalid")
+	ErrMalformedAuthorizationDetails       = fmt.Errorf("%s could not be parsed", ParamAuthorizationDetails)
+	ErrUnsupportedAuthorizationDetailsType = errors.New("authorization details type not supported")
+	ErrAuthorizationDetailsTypeNotAllowed  = errors.New("authorization details type not allowed for client")
+	ErrAuthorizationDetailsNotGranted      = errors.New("requested authorization details were not granted")
+	ErrMissingCode                         = fmt.Errorf("%s was not included in the request", ParamCode)
+	ErrInvalidAuthorizationCode            = errors.New("authorization code is invalid or expired")
+	ErrAuthorizationCodeClientMismatch     = errors.New("authorization code was issued to another client")
+	ErrRedirectURIMismatch                 = fmt.Errorf("%s does not match the authorization request", ParamRedirectURI)
+	ErrMissingCodeVerifier                 = fmt.Errorf("%s was not included in the request", ParamCodeVerifier)
+	ErrInvalidCodeVerifier                 = fmt.Errorf("%s does not match the code challenge", ParamCodeVerifier)
+	ErrCodeChallengeRequired               = errors.New("public clients must use PKCE")
+	ErrGrantTypeNotAllowed                 = errors.New("grant type not allowed for client")
+	ErrMissingToken                        = fmt.Errorf("%s was not included in the request", ParamToken)
+	ErrIntrospectionNotSet                 = errors.New("this server does not have token introspection configured")
+	ErrPublicClientIntrospection           = errors.New("public clients may not introspect tokens")
+	ErrInvalidResource                     = fmt.Errorf("%s must be an absolute URI without a fragment", ParamResource)
+	ErrUnknownResource                     = errors.New("resource is not known")
+	ErrResourceIndicatorsNotSupported      = errors.New("this server does not have resource indicators configured")
+	ErrScopeNotAllowedForResource          = errors.New("scope is not allowed for the requested resources")
+	ErrResourceNotGranted                  = errors.New("requested resource was not granted")
+	ErrMissingRefreshToken                 = fmt.Errorf("%s was not included in the request", ParamRefreshToken)
+	ErrInvalidRefreshToken                 = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenClientMismatch          = errors.New("refresh token was issued to another client")
+	ErrScopeNotGranted                     = errors.New("requested scope was not granted")
+	ErrResourceServerNotFound              = errors.New("resource server not found")
+	ErrUserNotFound                        = errors.New("user not found")
+	ErrOpenIDScopeRequired                 = fmt.Errorf("the %s scope is required", ScopeOpenID)
+	ErrMissingNonce                        = fmt.Errorf("%s was not included in the request", ParamNonce)
+	ErrMissingBearerToken                  = errors.New("no bearer token was included in the request")
+	ErrMultipleBearerTokens                = errors.New("bearer tokens must only be sent with one method")
+	ErrInvalidAccessToken                  = errors.New("access token is invalid or expired")
+	ErrAccessTokenHasNoUser                = errors.New("access token was not issued to a user")
+	ErrInsufficientScope                   = errors.New("access token does not have the required scope")
+	ErrInvalidPrompt                       = fmt.Errorf("%s %s cannot be combined with other values", ParamPrompt, PromptNone)
+	ErrInvalidMaxAge                       = fmt.Errorf("%s must be a non-negative integer", ParamMaxAge)
+	ErrUnsupportedDisplay                  = fmt.Errorf("%s value not supported", ParamDisplay)
+	ErrLoginRequired                       = errors.New("end-user authentication is required")
+	ErrConsentRequired                     = errors.New("end-user consent is required")
+	ErrInteractionRequired                 = errors.New("end-user interaction is required")
+	ErrAccountSelectionRequired            = errors.New("end-user must select an account")
+	ErrInvalidIDTokenHint                  = fmt.Errorf("%s is invalid", ParamIDTokenHint)
+	ErrIDTokenHintClientMismatch           = fmt.Errorf("%s was not issued to the client", ParamIDTokenHint)
+	ErrPostLogoutRedirectURIRequiresClient = fmt.Errorf("%s requires a %s or %s", ParamPostLogoutRedirectURI, ParamClientID, ParamIDTokenHint)
+	ErrPostLogoutRedirectURINotAllowed     = fmt.Errorf("%s is not registered for the client", ParamPostLogoutRedirectURI)
+	ErrBackChannelLogoutFailed             = errors.New("back-channel logout notification failed")
+	ErrBackChannelLogoutSessionRequired    = errors.New("client requires a session ID for back-channel logout")
+	ErrInvalidSectorIdentifier             = fmt.Errorf("%s document is invalid", ParamSectorIdentifierURI)
+	ErrSectorIdentifierMismatch            = fmt.Errorf("redirect URI is not listed in the %s document", ParamSectorIdentifierURI)
+	ErrSectorIdentifierRequired            = fmt.Errorf("redirect URIs with multiple hosts require a %s", ParamSectorIdentifierURI)
+	ErrSectorIdentifierNotHTTPS            = fmt.Errorf("%s must use https", ParamSectorIdentifierURI)
+	ErrMalformedClaimsRequest              = fmt.Errorf("%s is not a valid JSON object", ParamClaims)
+	ErrConsentNotRequested                 = errors.New("consent decision grants more than was requested")
+	ErrNoScopesGranted                     = errors.New("the end-user did not grant any of the requested scopes")
+	ErrAuthorizationRevoked                = errors.New("the authorization record was revoked")
+	ErrAdminApprovalRequired               = errors.New("an administrator must approve the requested scopes")
+	ErrScopeNotAllowed                     = errors.New("scope is not allowed for client")
 )
 
 const (
-	ErrorTypeInvalidRequest          = "invalid_request"
-	ErrorTypeUnauthorizedClient      = "unauthorized_client"
-	ErrorTypeInvalidClient           = "invalid_client"
-	ErrorTypeAccessDenied            = "access_denied"
-	ErrorTypeUnsupportedResponseType = "unsupported_response_type"
-	ErrorTypeInvalidScope            = "invalid_scope"
-	ErrorTypeServerError             = "server_error"
-	ErrorTypeTemporarilyUnavailable  = "temporarily_unavailable"
-	ErrorTypeInvalidGrant            = "invalid_grant"
-	ErrorTypeUnsupportedGrantType    = "unsupported_grant_type"
+	ErrorTypeInvalidRequest              = "invalid_request"
+	ErrorTypeUnauthorizedClient          = "unauthorized_client"
+	ErrorTypeInvalidClient               = "invalid_client"
+	ErrorTypeAccessDenied                = "access_denied"
+	ErrorTypeUnsupportedResponseType     = "unsupported_response_type"
+	ErrorTypeInvalidScope                = "invalid_scope"
+	ErrorTypeServerError                 = "server_error"
+	ErrorTypeTemporarilyUnavailable      = "temporarily_unavailable"
+	ErrorTypeInvalidGrant                = "invalid_grant"
+	ErrorTypeUnsupportedGrantType        = "unsupported_grant_type"
+	ErrorTypeInvalidRequestURI           = "invalid_request_uri"
+	ErrorTypeInvalidRequestObject        = "invalid_request_object"
+	ErrorTypeRequestNotSupported         = "request_not_supported"
+	ErrorTypeRequestURINotSupported      = "request_uri_not_supported"
+	ErrorTypeInvalidAuthorizationDetails = "invalid_authorization_details"
+	ErrorTypeInvalidTarget               = "invalid_target"
+	ErrorTypeInvalidToken                = "invalid_token"
+	ErrorTypeInsufficientScope           = "insufficient_scope"
+	ErrorTypeLoginRequired               = "login_required"
+	ErrorTypeConsentRequired             = "consent_required"
+	ErrorTypeInteractionRequired         = "interaction_required"
+	ErrorTypeAccountSelectionRequired    = "account_selection_required"
 )
 
 // An error generated from the oauth2 server during an access token request.
@@ -70,6 +151,21 @@ func (e *OAuthError) Unwrap() error {
 	return e.Cause
 }
 
+// the error as a set of parameters that can be sent back to the client in
+// an authorization response.
+func (e *OAuthError) Values() url.Values {
+	v := url.Values{}
+	v.Set(ParamError, e.ErrorType)
+	if e.ErrorDescription != "" {
+		v.Set(ParamErrorDescription, e.ErrorDescription)
+	}
+	if e.ErrorURI != "" {
+		v.Set(ParamErrorURI, e.ErrorURI)
+	}
+
+	return v
+}
+
 func InvalidRequest(format string, a ...any) *OAuthError {
 	return &OAuthError{
 		ErrorType:        ErrorTypeInvalidRequest,
@@ -137,6 +233,134 @@ func UnauthorizedClient(reason string) *OAuthError {
 	}
 }
 
+func InvalidGrant(format string, a ...any) *OAuthError {
+	return &OAuthError{
+		ErrorType:        ErrorTypeInvalidGrant,
+		ErrorDescription: fmt.Sprintf(format, a...),
+	}
+}
+
+func InvalidGrantWithCause(cause error, format string, a ...any) *OAuthError {
+	e := InvalidGrant(format, a...)
+	e.Cause = cause
+
+	return e
+}
+
+func InvalidAuthorizationDetails(format string, a ...any) *OAuthError {
+	return &OAuthError{
+		ErrorType:        ErrorTypeInvalidAuthorizationDetails,
+		ErrorDescription: fmt.Sprintf(format, a...),
+	}
+}
+
+func InvalidAuthorizationDetailsWithCause(cause error, format string, a ...any) *OAuthError {
+	e := InvalidAuthorizationDetails(format, a...)
+	e.Cause = cause
+
+	return e
+}
+
+func InvalidTarget(format string, a ...any) *OAuthError {
+	return &OAuthError{
+		ErrorType:        ErrorTypeInvalidTarget,
+		ErrorDescription: fmt.Sprintf(format, a...),
+	}
+}
+
+func InvalidTargetWithCause(cause error, format string, a ...any) *OAuthError {
+	e := InvalidTarget(format, a...)
+	e.Cause = cause
+
+	return e
+}
+
+func InvalidToken(cause error) *OAuthError {
+	return &OAuthError{
+		ErrorType:        ErrorTypeInvalidToken,
+		ErrorDescription: cause.Error(),
+		StatusCode:       http.StatusUnauthorized,
+		Cause:            cause,
+	}
+}
+
+func InsufficientScope(requiredScopes ...string) *OAuthError {
+	return &OAuthError{
+		ErrorType:        ErrorTypeInsufficientScope,
+		ErrorDescription: fmt.Sprintf("required scopes: %s", strings.Join(requiredScopes, " ")),
+		StatusCode:       http.StatusForbidden,
+		Cause:            ErrInsufficientScope,
+	}
+}
+
+// errors for `prompt=none` requests that cannot be completed without showing
+// the end-user a page, see https://openid.net/specs/openid-connect-core-1_0.html#AuthError
+func LoginRequired() *OAuthError {
+	return &OAuthError{
+		ErrorType:        ErrorTypeLoginRequired,
+		ErrorDescription: ErrLoginRequired.Error(),
+		Cause:            ErrLoginRequired,
+	}
+}
+
+func ConsentRequired() *OAuthError {
+	return &OAuthError{
+		ErrorType:        ErrorTypeConsentRequired,
+		ErrorDescription: ErrConsentRequired.Error(),
+		Cause:            ErrConsentRequired,
+	}
+}
+
+func InteractionRequired() *OAuthError {
+	return &OAuthError{
+		ErrorType:        ErrorTypeInteractionRequired,
+		ErrorDescription: ErrInteractionRequired.Error(),
+		Cause:            ErrInteractionRequired,
+	}
+}
+
+func AccountSelectionRequired() *OAuthError {
+	return &OAuthError{
+		ErrorType:        ErrorTypeAccountSelectionRequired,
+		ErrorDescription: ErrAccountSelectionRequired.Error(),
+		Cause:            ErrAccountSelectionRequired,
+	}
+}
+
+// the cause may include key IDs, URLs, or parse errors so it is kept out of
+// the description sent to the client.
+func InvalidRequestObject(cause error) *OAuthError {
+	return &OAuthError{
+		ErrorType:        ErrorTypeInvalidRequestObject,
+		ErrorDescription: ErrInvalidRequestObject.Error(),
+		Cause:            fmt.Errorf("%w: %w", ErrInvalidRequestObject, cause),
+	}
+}
+
+func InvalidRequestURI(cause error) *OAuthError {
+	return &OAuthError{
+		ErrorType:        ErrorTypeInvalidRequestURI,
+		ErrorDescription: fmt.Sprintf("could not fetch %s", ParamRequestURI),
+		Cause:            cause,
+	}
+}
+
+func RequestNotSupported() *OAuthError {
+	return &OAuthError{
+		ErrorType:        ErrorTypeRequestNotSupported,
+		ErrorDescription: ErrRequestNotSupported.Error(),
+		Cause:            ErrRequestNotSupported,
+	}
+}
+
+func RequestURINotSupported() *OAuthError {
+	return &OAuthError{
+		ErrorType:        ErrorTypeRequestURINotSupported,
+		ErrorDescription: ErrRequestURINotSupported.Error(),
+		Cause:            ErrRequestURINotSupported,
+	}
+}
+
 func AsOAuthError(err error) (*OAuthError, bool) {
 	var oauthErr *OAuthError
 	if errors.As(err, &oauthErr) {
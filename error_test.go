@@ -100,3 +100,22 @@ func TestMaybeWrapError_WrapsGenericErrorWithServerErrors(t *testing.T) {
 		t.Errorf("OAuthErrors should wrap the cause: %#v", err)
 	}
 }
+
+func TestOAuthError_Values_ReturnsAuthorizationResponseParameters(t *testing.T) {
+	err := &oauth2server.OAuthError{
+		ErrorType:        oauth2server.ErrorTypeAccessDenied,
+		ErrorDescription: "nope",
+	}
+
+	vals := err.Values()
+
+	if vals.Get(oauth2server.ParamError) != oauth2server.ErrorTypeAccessDenied {
+		t.Errorf("bad error: %q", vals.Get(oauth2server.ParamError))
+	}
+	if vals.Get(oauth2server.ParamErrorDescription) != "nope" {
+		t.Errorf("bad error description: %q", vals.Get(oauth2server.ParamErrorDescription))
+	}
+	if vals.Has(oauth2server.ParamErrorURI) {
+		t.Errorf("empty error URIs should not be included: %v", vals)
+	}
+}
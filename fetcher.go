@@ -0,0 +1,141 @@
+package oauth2server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultFetchMaxBytes = 64 * 1024
+	DefaultFetchTimeout  = 5 * time.Second
+)
+
+var (
+	ErrFetchNotHTTPS          = errors.New("only https URIs can be fetched")
+	ErrFetchBadStatus         = errors.New("unexpected response status")
+	ErrFetchTooLarge          = errors.New("response body is too large")
+	ErrFetchDisallowedAddress = errors.New("fetching from a non-public address is not allowed")
+)
+
+// fetches documents by reference from client controlled URIs, eg a
+// `request_uri`. Implementations should be careful: the URIs come from
+// untrusted requests.
+type Fetcher interface {
+	Fetch(ctx context.Context, uri string) ([]byte, error)
+}
+
+type httpFetcher struct {
+	client   *http.Client
+	maxBytes int64
+}
+
+type HTTPFetcherOption func(*httpFetcher)
+
+// Use a custom HTTP client. This replaces the default client entirely,
+// including its protection against fetching from private addresses.
+func WithHTTPFetcherClient(client *http.Client) HTTPFetcherOption {
+	return func(f *httpFetcher) {
+		f.client = client
+	}
+}
+
+func WithHTTPFetcherMaxBytes(maxBytes int64) HTTPFetcherOption {
+	return func(f *httpFetcher) {
+		f.maxBytes = maxBytes
+	}
+}
+
+// A fetcher that makes GET requests to https URIs. By default responses are
+// limited to DefaultFetchMaxBytes, requests time out after DefaultFetchTimeout,
+// redirects are not followed, and connections to loopback, private, or
+// link local addresses are refused.
+func NewHTTPFetcher(opts ...HTTPFetcherOption) Fetcher {
+	f := &httpFetcher{
+		maxBytes: DefaultFetchMaxBytes,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	if f.client == nil {
+		f.client = newPublicHTTPClient()
+	}
+
+	return f
+}
+
+func newPublicHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: DefaultFetchTimeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%w: %s", ErrFetchDisallowedAddress, host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+
+	return &http.Client{
+		Timeout:   DefaultFetchTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func isPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast()
+}
+
+func (f *httpFetcher) Fetch(ctx context.Context, uri string) ([]byte, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "https" {
+		return nil, ErrFetchNotHTTPS
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %d", ErrFetchBadStatus, resp.StatusCode)
+	}
+
+	// read one more byte than allowed to detect bodies that are too large
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > f.maxBytes {
+		return nil, ErrFetchTooLarge
+	}
+
+	return body, nil
+}
@@ -0,0 +1,81 @@
+package oauth2server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+func startFetcherTest(t *testing.T, status int, body string) (*httptest.Server, oauth2server.Fetcher) {
+	t.Helper()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, oauth2server.NewHTTPFetcher(
+		oauth2server.WithHTTPFetcherClient(server.Client()),
+		oauth2server.WithHTTPFetcherMaxBytes(10),
+	)
+}
+
+func TestHTTPFetcher_ReturnsResponseBody(t *testing.T) {
+	server, fetcher := startFetcherTest(t, http.StatusOK, "hello")
+
+	body, err := fetcher.Fetch(context.Background(), server.URL)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(body) != "hello" {
+		t.Errorf(`bad body: %q != "hello"`, body)
+	}
+}
+
+func TestHTTPFetcher_ErrorsIfResponseIsTooLarge(t *testing.T) {
+	server, fetcher := startFetcherTest(t, http.StatusOK, strings.Repeat("a", 11))
+
+	_, err := fetcher.Fetch(context.Background(), server.URL)
+
+	if !errors.Is(err, oauth2server.ErrFetchTooLarge) {
+		t.Errorf("expected ErrFetchTooLarge, got %v", err)
+	}
+}
+
+func TestHTTPFetcher_ErrorsOnNonOKResponse(t *testing.T) {
+	server, fetcher := startFetcherTest(t, http.StatusNotFound, "")
+
+	_, err := fetcher.Fetch(context.Background(), server.URL)
+
+	if !errors.Is(err, oauth2server.ErrFetchBadStatus) {
+		t.Errorf("expected ErrFetchBadStatus, got %v", err)
+	}
+}
+
+func TestHTTPFetcher_OnlyFetchesHTTPSURIs(t *testing.T) {
+	fetcher := oauth2server.NewHTTPFetcher()
+
+	_, err := fetcher.Fetch(context.Background(), "http://example.com")
+
+	if !errors.Is(err, oauth2server.ErrFetchNotHTTPS) {
+		t.Errorf("expected ErrFetchNotHTTPS, got %v", err)
+	}
+}
+
+func TestHTTPFetcher_DefaultClientRefusesLoopbackAddresses(t *testing.T) {
+	server, _ := startFetcherTest(t, http.StatusOK, "hello")
+	fetcher := oauth2server.NewHTTPFetcher()
+
+	_, err := fetcher.Fetch(context.Background(), server.URL)
+
+	if !errors.Is(err, oauth2server.ErrFetchDisallowedAddress) {
+		t.Errorf("expected ErrFetchDisallowedAddress, got %v", err)
+	}
+}
@@ -2,4 +2,4 @@ module github.com/chrisguitarguy/oauth2server
 
 go 1.23.4
 
-require github.com/google/go-cmp v0.7.0 // indirect
+require github.com/google/go-cmp v0.7.0
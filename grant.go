@@ -7,21 +7,31 @@ import (
 )
 
 type AccessTokenResponse struct {
-	AccessToken  string `json:"access_token"`
-	TokenType    string `json:"token_type"`
-	ExpiresIn    int    `json:"expires_in,omitempty"`
-	RefreshToken string `json:"refresh_token,omitempty"`
-	IDToken      string `json:"id_token,omitempty"`
+	AccessToken          string                `json:"access_token"`
+	TokenType            string                `json:"token_type"`
+	ExpiresIn            int                   `json:"expires_in,omitempty"`
+	RefreshToken         string                `json:"refresh_token,omitempty"`
+	IDToken              string                `json:"id_token,omitempty"`
+	Scope                string                `json:"scope,omitempty"`
+	AuthorizationDetails []AuthorizationDetail `json:"authorization_details,omitempty"`
 }
 
 // Parse an incomding net/http request and pull out oauth client info and
 // post form values.
 type AccessTokenRequest struct {
-	ClientID      string
-	ClientSecret  string
-	UsedBasicAuth bool
-	GrantType     string
-	HTTPRequest   *http.Request
+	ClientID             string
+	ClientSecret         string
+	UsedBasicAuth        bool
+	GrantType            string
+	Resource             []string
+	AuthorizationDetails []AuthorizationDetail
+	HTTPRequest          *http.Request
+
+	// set by the authorization server for ValidateScopes
+	scopeValidator  ScopeValidator
+	users           UserRepository
+	scopeClassifier ScopeClassifier
+	adminApprovals  AdminApprovalRepository
 }
 
 // parse an incoming access token request
@@ -49,6 +59,35 @@ func ParseAccessTokenRequest(r *http.Request) (*AccessTokenRequest, *OAuthError)
 		return nil, oauthErr
 	}
 
+	clientId, clientSecret, basicAuth := clientCredentials(r)
+
+	resources, resourceErr := ParseResourceParameter(r.PostForm[ParamResource])
+	if resourceErr != nil {
+		return nil, resourceErr
+	}
+
+	details, detailsErr := ParseAuthorizationDetails(r.PostFormValue(ParamAuthorizationDetails))
+	if detailsErr != nil {
+		return nil, detailsErr
+	}
+
+	return &AccessTokenRequest{
+		ClientID:             clientId,
+		ClientSecret:         clientSecret,
+		UsedBasicAuth:        basicAuth,
+		GrantType:            grantType,
+		Resource:             resources,
+		AuthorizationDetails: details,
+		HTTPRequest:          r,
+	}, nil
+}
+
+// parameters used to authenticate clients in request bodies
+var clientAuthenticationParams = []string{ParamClientSecret}
+
+// pull client credentials from basic auth or the request body. The request
+// form must already be parsed.
+func clientCredentials(r *http.Request) (string, string, bool) {
 	clientId, clientSecret, basicAuth := r.BasicAuth()
 	if !basicAuth {
 		// fall back to request body parameters if basic auth is not present
@@ -56,13 +95,7 @@ func ParseAccessTokenRequest(r *http.Request) (*AccessTokenRequest, *OAuthError)
 		clientSecret = r.PostFormValue(ParamClientSecret)
 	}
 
-	return &AccessTokenRequest{
-		ClientID:      clientId,
-		ClientSecret:  clientSecret,
-		UsedBasicAuth: basicAuth,
-		GrantType:     grantType,
-		HTTPRequest:   r,
-	}, nil
+	return clientId, clientSecret, basicAuth
 }
 
 func (r *AccessTokenRequest) ClientIDOrError() (string, *OAuthError) {
@@ -81,6 +114,39 @@ func (r *AccessTokenRequest) ClientSecretOrError() (string, *OAuthError) {
 	return r.ClientSecret, nil
 }
 
+// validate the scopes a grant is about to issue with the client's allowlist
+// and the authorization server's ScopeValidator, see ValidateScopesFor, and
+// check that sensitive scopes are still approved, see WithAdminApproval. The
+// user is looked up with the server's UserRepository, if any.
+func (r *AccessTokenRequest) ValidateScopes(ctx context.Context, client Client, userId string, scopes []string) *OAuthError {
+	var user User
+	if userId != "" {
+		user = userIdentity(userId)
+		if r.users != nil {
+			u, err := r.users.Get(ctx, userId)
+			if err != nil {
+				return ServerError(err)
+			}
+			if u != nil {
+				user = u
+			}
+		}
+	}
+
+	if err := ValidateScopesFor(ctx, r.scopeValidator, client, user, scopes); err != nil {
+		return err
+	}
+
+	return r.checkAdminApproval(ctx, client, scopes)
+}
+
+// a user known only by its ID
+type userIdentity string
+
+func (u userIdentity) ID() string {
+	return string(u)
+}
+
 func (r *AccessTokenRequest) Param(paramName string) string {
 	return r.HTTPRequest.PostFormValue(paramName)
 }
@@ -0,0 +1,262 @@
+package oauth2server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// OpenID Connect ID tokens, see https://openid.net/specs/openid-connect-core-1_0.html
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+	ScopeAddress = "address"
+	ScopePhone   = "phone"
+
+	DefaultIDTokenSigningAlg = SigningAlgRS256
+	DefaultIDTokenLifetime   = time.Hour
+)
+
+// extension point to let clients choose the algorithm used to sign their ID
+// tokens, the `id_token_signed_response_alg` client metadata. Uses
+// DefaultIDTokenSigningAlg if not implemented.
+type ClientSignsIDTokens interface {
+	IDTokenSignedResponseAlg() string
+}
+
+// what an ID token is being issued for.
+type IDTokenRequest struct {
+	Client Client
+
+	UserID string
+
+	Scope []string
+
+	Nonce string
+
+	// when and how the user authenticated, see UserWithAuthentication
+	AuthTime time.Time
+	ACR      string
+	AMR      []string
+
+	// the user's session at the authorization server, used for `sid`
+	SessionID string
+
+	// the claims requested with the `claims` parameter, only the `id_token`
+	// member is used. Nil if none.
+	Claims *ClaimsRequest
+
+	// the access token issued alongside the ID token, used for `at_hash`
+	AccessToken string
+
+	// the authorization code issued alongside the ID token, used for `c_hash`
+	Code string
+}
+
+// build an ID token request for the user with their authentication details
+func newIDTokenRequest(client Client, user User, authReq *AuthorizationRequest) *IDTokenRequest {
+	req := &IDTokenRequest{
+		Client: client,
+		UserID: user.ID(),
+		Scope:  authReq.Scope,
+		Nonce:  authReq.Nonce,
+		Claims: authReq.Claims,
+	}
+
+	if authn, ok := user.(UserWithAuthentication); ok {
+		req.AuthTime = authn.AuthTime()
+		req.ACR = authn.ACR()
+		req.AMR = authn.AMR()
+	}
+
+	if session, ok := user.(UserWithSession); ok {
+		req.SessionID = session.SessionID()
+	}
+
+	return req
+}
+
+// Issues signed ID tokens, this is shared by the token endpoint and the
+// `id_token` authorization handler.
+type IDTokenIssuer interface {
+	IssueIDToken(ctx context.Context, req *IDTokenRequest) (string, error)
+}
+
+type defaultIDTokenIssuer struct {
+	issuer   string
+	keys     KeySet
+	users    UserRepository
+	lifetime time.Duration
+	subjects SubjectIdentifierStrategy
+}
+
+type IDTokenIssuerOption func(*defaultIDTokenIssuer)
+
+func WithIDTokenLifetime(lifetime time.Duration) IDTokenIssuerOption {
+	return func(i *defaultIDTokenIssuer) {
+		i.lifetime = lifetime
+	}
+}
+
+// decide the `sub` of issued ID tokens, defaults to the user's ID.
+func WithIDTokenSubjectIdentifiers(subjects SubjectIdentifierStrategy) IDTokenIssuerOption {
+	return func(i *defaultIDTokenIssuer) {
+		i.subjects = subjects
+	}
+}
+
+// an ID token issuer that signs tokens with keys from the key set, issuer is
+// the `iss` claim. Users are looked up to include scope driven claims.
+func NewIDTokenIssuer(issuer string, keys KeySet, users UserRepository, opts ...IDTokenIssuerOption) IDTokenIssuer {
+	i := &defaultIDTokenIssuer{
+		issuer:   issuer,
+		keys:     keys,
+		users:    users,
+		lifetime: DefaultIDTokenLifetime,
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	return i
+}
+
+func (i *defaultIDTokenIssuer) IssueIDToken(ctx context.Context, req *IDTokenRequest) (string, error) {
+	user, err := i.users.Get(ctx, req.UserID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "", fmt.Errorf("%w: %s", ErrUserNotFound, req.UserID)
+	}
+
+	alg := DefaultIDTokenSigningAlg
+	if signs, ok := req.Client.(ClientSignsIDTokens); ok && signs.IDTokenSignedResponseAlg() != "" {
+		alg = signs.IDTokenSignedResponseAlg()
+	}
+
+	key, err := i.keys.SigningKey(ctx, alg)
+	if err != nil {
+		return "", err
+	}
+
+	sub, err := subjectIdentifier(ctx, i.subjects, req.Client, user.ID())
+	if err != nil {
+		return "", err
+	}
+
+	claims := ClaimsFor(user, req.Scope, req.Claims.IDTokenClaims())
+
+	now := time.Now()
+	claims["iss"] = i.issuer
+	claims["sub"] = sub
+	claims["aud"] = req.Client.ID()
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(i.lifetime).Unix()
+
+	if req.Nonce != "" {
+		claims["nonce"] = req.Nonce
+	}
+	if !req.AuthTime.IsZero() {
+		claims["auth_time"] = req.AuthTime.Unix()
+	}
+	if req.ACR != "" {
+		claims["acr"] = req.ACR
+	}
+	if len(req.AMR) > 0 {
+		claims["amr"] = req.AMR
+	}
+	if req.SessionID != "" {
+		claims["sid"] = req.SessionID
+	}
+
+	if req.AccessToken != "" {
+		atHash, err := leftHalfHash(alg, req.AccessToken)
+		if err != nil {
+			return "", err
+		}
+		claims["at_hash"] = atHash
+	}
+	if req.Code != "" {
+		cHash, err := leftHalfHash(alg, req.Code)
+		if err != nil {
+			return "", err
+		}
+		claims["c_hash"] = cHash
+	}
+
+	return SignJWT(key, claims)
+}
+
+// the base64url encoded left-most half of the hash of value with the hash
+// function of the signing algorithm, used for `at_hash` and `c_hash`.
+// See https://openid.net/specs/openid-connect-core-1_0.html#CodeIDToken
+func leftHalfHash(alg string, value string) (string, error) {
+	var sum []byte
+	switch alg {
+	case SigningAlgRS256, SigningAlgPS256, SigningAlgES256:
+		h := sha256.Sum256([]byte(value))
+		sum = h[:]
+	case SigningAlgEdDSA:
+		// Ed25519 uses SHA-512
+		h := sha512.Sum512([]byte(value))
+		sum = h[:]
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedSigningAlg, alg)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2]), nil
+}
+
+type idTokenAuthorizationHandler struct {
+	idTokens IDTokenIssuer
+}
+
+// the `id_token` response type for the implicit and hybrid flows, requires the
+// `openid` scope and a nonce.
+func NewIDTokenAuthorizationHandler(idTokens IDTokenIssuer) AuthorizationHandler {
+	return &idTokenAuthorizationHandler{
+		idTokens: idTokens,
+	}
+}
+
+func (h *idTokenAuthorizationHandler) ResponseType() string {
+	return ResponseTypeIDToken
+}
+
+func (h *idTokenAuthorizationHandler) ValidateAuthorizationRequest(ctx context.Context, client Client, req *AuthorizationRequest) error {
+	if !slices.Contains(req.Scope, ScopeOpenID) {
+		return InvalidRequestWithCause(ErrOpenIDScopeRequired, ErrOpenIDScopeRequired.Error())
+	}
+
+	// https://openid.net/specs/openid-connect-core-1_0.html#ImplicitAuthRequest
+	if req.Nonce == "" {
+		return MissingRequestParameterWithCause(ErrMissingNonce, ParamNonce)
+	}
+
+	return nil
+}
+
+func (h *idTokenAuthorizationHandler) IssueAuthorizationResponse(ctx context.Context, client Client, req *AuthorizationRequest, user User) (string, error) {
+	return h.IssueDependentAuthorizationResponse(ctx, client, req, user, url.Values{})
+}
+
+func (h *idTokenAuthorizationHandler) IssueDependentAuthorizationResponse(
+	ctx context.Context,
+	client Client,
+	req *AuthorizationRequest,
+	user User,
+	issued url.Values,
+) (string, error) {
+	idReq := newIDTokenRequest(client, user, req)
+	idReq.Code = issued.Get(ResponseTypeCode)
+	idReq.AccessToken = issued.Get(ResponseTypeToken)
+
+	return h.idTokens.IssueIDToken(ctx, idReq)
+}
@@ -0,0 +1,185 @@
+package oauth2server_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+const testOIDCIssuer = "https://id.example.com"
+
+func newTestOIDCUser() *oidcTestUser {
+	website, _ := url.Parse("https://example.com/~user")
+
+	return &oidcTestUser{
+		id:    "user",
+		email: oauth2server.UserEmail{Email: "user@example.com", Verified: true},
+		profile: oauth2server.UserProfile{
+			Name:    "Test User",
+			Website: website,
+		},
+		authTime: time.Unix(1700000000, 0),
+		acr:      "urn:example:acr:mfa",
+		amr:      []string{"pwd", "otp"},
+		session:  "session-1",
+	}
+}
+
+func newTestIDTokenIssuer(users ...oauth2server.User) oauth2server.IDTokenIssuer {
+	repo := oauth2server.NewInMemoryUserRepository()
+	for _, u := range users {
+		repo.Add(u)
+	}
+
+	return oauth2server.NewIDTokenIssuer(testOIDCIssuer, oauth2server.NewStaticKeySet(newTestSigningKey()), repo)
+}
+
+func testHalfHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
+
+func TestIDTokenIssuer_IssueIDToken_ErrorsIfUserIsNotFound(t *testing.T) {
+	issuer := newTestIDTokenIssuer()
+
+	_, err := issuer.IssueIDToken(context.Background(), &oauth2server.IDTokenRequest{
+		Client: oauth2server.NewSimpleClient(testClientId, testClientSecret, nil),
+		UserID: "missing",
+	})
+
+	if !errors.Is(err, oauth2server.ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestIDTokenIssuer_IssueIDToken_IncludesStandardAndScopeClaims(t *testing.T) {
+	issuer := newTestIDTokenIssuer(newTestOIDCUser())
+
+	token, err := issuer.IssueIDToken(context.Background(), &oauth2server.IDTokenRequest{
+		Client:      oauth2server.NewSimpleClient(testClientId, testClientSecret, nil),
+		UserID:      "user",
+		Scope:       []string{oauth2server.ScopeOpenID, oauth2server.ScopeEmail},
+		Nonce:       "n-0S6_WzA2Mj",
+		AuthTime:    time.Unix(1700000000, 0),
+		ACR:         "urn:example:acr:mfa",
+		AMR:         []string{"pwd"},
+		AccessToken: "access",
+		Code:        "code",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	claims := decodeTestJWT(t, token)
+	expected := map[string]any{
+		"iss":            testOIDCIssuer,
+		"sub":            "user",
+		"aud":            testClientId,
+		"nonce":          "n-0S6_WzA2Mj",
+		"auth_time":      float64(1700000000),
+		"acr":            "urn:example:acr:mfa",
+		"at_hash":        testHalfHash("access"),
+		"c_hash":         testHalfHash("code"),
+		"email":          "user@example.com",
+		"email_verified": true,
+	}
+	for k, v := range expected {
+		if claims[k] != v {
+			t.Errorf("expected claim %s to be %v, got %v", k, v, claims[k])
+		}
+	}
+	if _, ok := claims["name"]; ok {
+		t.Error("expected profile claims to be excluded without the profile scope")
+	}
+}
+
+func TestIDTokenAuthorizationHandler_ValidateAuthorizationRequest_RequiresOpenIDAndNonce(t *testing.T) {
+	handler := oauth2server.NewIDTokenAuthorizationHandler(newTestIDTokenIssuer())
+	client := oauth2server.NewSimpleClient(testClientId, testClientSecret, nil)
+
+	err := handler.ValidateAuthorizationRequest(context.Background(), client, &oauth2server.AuthorizationRequest{
+		Nonce: "nonce",
+	})
+	if !errors.Is(err, oauth2server.ErrOpenIDScopeRequired) {
+		t.Errorf("expected ErrOpenIDScopeRequired, got %v", err)
+	}
+
+	err = handler.ValidateAuthorizationRequest(context.Background(), client, &oauth2server.AuthorizationRequest{
+		Scope: []string{oauth2server.ScopeOpenID},
+	})
+	if !errors.Is(err, oauth2server.ErrMissingNonce) {
+		t.Errorf("expected ErrMissingNonce, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_CompleteAuthorizationRequest_IssuesIDTokenWithCodeHash(t *testing.T) {
+	user := newTestOIDCUser()
+	tc := startAuthorizationServerTest(
+		t,
+		oauth2server.WithAuthorizationHandler(&spyAuthorizationHandler{responseType: "code", issueAuthorizationResponseReturn: "thecode"}),
+		oauth2server.WithAuthorizationHandler(oauth2server.NewIDTokenAuthorizationHandler(newTestIDTokenIssuer(user))),
+	)
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+
+	params, err := tc.server.CompleteAuthorizationRequest(context.Background(), &oauth2server.AuthorizationRequest{
+		ClientID:     testClientId,
+		ResponseType: []string{"id_token", "code"},
+		Scope:        []string{oauth2server.ScopeOpenID},
+		Nonce:        "nonce",
+	}, user)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	claims := decodeTestJWT(t, params.Get("id_token"))
+	if claims["c_hash"] != testHalfHash("thecode") {
+		t.Errorf("expected c_hash of the issued code, got %v", claims["c_hash"])
+	}
+}
+
+func TestAuthorizationCodeGrant_Token_IssuesIDTokenForOpenIDScope(t *testing.T) {
+	user := newTestOIDCUser()
+	clients := oauth2server.NewInMemoryClientRepository()
+	client := oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri})
+	clients.Add(client)
+	grant := oauth2server.NewAuthorizationCodeGrant(
+		clients,
+		oauth2server.NewInMemoryAuthorizationCodeRepository(),
+		oauth2server.NewTokenIssuer(oauth2server.NewInMemoryAccessTokenRepository()),
+		oauth2server.WithAuthorizationCodeIDTokens(newTestIDTokenIssuer(user)),
+	)
+	code, _ := grant.(oauth2server.AuthorizationHandler).IssueAuthorizationResponse(
+		context.Background(),
+		client,
+		&oauth2server.AuthorizationRequest{
+			ClientID: testClientId,
+			Scope:    []string{oauth2server.ScopeOpenID, oauth2server.ScopeProfile},
+			Nonce:    "nonce",
+		},
+		user,
+	)
+
+	resp, err := grant.Token(context.Background(), newCodeTokenRequest(t, map[string]string{
+		oauth2server.ParamCode: code,
+	}))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	claims := decodeTestJWT(t, resp.IDToken)
+	if claims["nonce"] != "nonce" || claims["at_hash"] != testHalfHash(resp.AccessToken) {
+		t.Errorf("expected nonce and at_hash claims, got %v", claims)
+	}
+	if claims["auth_time"] != float64(1700000000) || claims["name"] != "Test User" {
+		t.Errorf("expected auth_time and profile claims, got %v", claims)
+	}
+	if claims["sid"] != "session-1" {
+		t.Errorf("expected sid of the user's session, got %v", claims["sid"])
+	}
+}
@@ -0,0 +1,195 @@
+package oauth2server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Token introspection, see https://datatracker.ietf.org/doc/html/rfc7662
+type IntrospectionResponse struct {
+	Active               bool                  `json:"active"`
+	Scope                string                `json:"scope,omitempty"`
+	ClientID             string                `json:"client_id,omitempty"`
+	TokenType            string                `json:"token_type,omitempty"`
+	Exp                  int64                 `json:"exp,omitempty"`
+	Iat                  int64                 `json:"iat,omitempty"`
+	Sub                  string                `json:"sub,omitempty"`
+	Aud                  []string              `json:"aud,omitempty"`
+	AuthorizationDetails []AuthorizationDetail `json:"authorization_details,omitempty"`
+
+	// the user's claims allowed by the token's scopes, included at the top
+	// level of the response.
+	Claims map[string]any `json:"-"`
+}
+
+// the alias drops the JSON methods below to avoid recursion
+type introspectionResponseFields IntrospectionResponse
+
+func (r IntrospectionResponse) MarshalJSON() ([]byte, error) {
+	fields, err := json.Marshal(introspectionResponseFields(r))
+	if err != nil || len(r.Claims) == 0 {
+		return fields, err
+	}
+
+	merged := map[string]any{}
+	for k, v := range r.Claims {
+		merged[k] = v
+	}
+	if err := json.Unmarshal(fields, &merged); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(merged)
+}
+
+// look up users to include their claims in introspection responses
+func WithUsers(users UserRepository) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.users = users
+	}
+}
+
+// decide the `sub` of introspection responses, defaults to the user's ID.
+// The subject is the one the token's client sees.
+func WithSubjectIdentifiers(subjects SubjectIdentifierStrategy) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.subjects = subjects
+	}
+}
+
+// enable token introspection of access tokens from the repository
+func WithIntrospection(tokens AccessTokenRepository) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.introspectionTokens = tokens
+	}
+}
+
+func (s *defaultAuthorizationServer) Introspect(ctx context.Context, req *http.Request) (*IntrospectionResponse, *OAuthError) {
+	if s.introspectionTokens == nil {
+		return nil, ServerError(ErrIntrospectionNotSet)
+	}
+
+	if req.Method != http.MethodPost {
+		return nil, InvalidRequestWithCause(
+			ErrInvalidRequestMethod,
+			"introspection requests must be %s requests",
+			http.MethodPost,
+		)
+	}
+
+	if err := req.ParseForm(); err != nil {
+		return nil, InvalidRequestWithCause(
+			fmt.Errorf("%w: %w", ErrCouldNotParseRequestBody, err),
+			ErrCouldNotParseRequestBody.Error(),
+		)
+	}
+
+	callerId, callerSecret, _ := clientCredentials(req)
+	resourceServer, callerClient, authErr := s.authenticateIntrospectionCaller(ctx, callerId, callerSecret)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	tokenValue := req.PostFormValue(ParamToken)
+	if tokenValue == "" {
+		return nil, MissingRequestParameterWithCause(ErrMissingToken, ParamToken)
+	}
+
+	token, err := s.introspectionTokens.Get(ctx, tokenValue)
+	if err != nil {
+		return nil, MaybeWrapError(err)
+	}
+
+	// https://datatracker.ietf.org/doc/html/rfc7662#section-2.2 unknown and
+	// expired tokens are only ever inactive
+	if token == nil || !token.IsActive(time.Now()) {
+		return &IntrospectionResponse{Active: false}, nil
+	}
+
+	// https://datatracker.ietf.org/doc/html/rfc7662#section-4 resource servers
+	// only learn about tokens meant for them
+	if resourceServer != nil && len(token.Audience) > 0 && !slices.Contains(token.Audience, resourceServer.ID()) {
+		return &IntrospectionResponse{Active: false}, nil
+	}
+
+	// clients only learn about their own tokens, anything else would leak
+	// another client's scopes, claims, and pairwise subject identifiers.
+	if callerClient != nil && token.ClientID != callerClient.ID() {
+		return &IntrospectionResponse{Active: false}, nil
+	}
+
+	resp := &IntrospectionResponse{
+		Active:               true,
+		Scope:                strings.Join(token.Scope, spaceSeparator),
+		ClientID:             token.ClientID,
+		TokenType:            TokenTypeBearer,
+		Exp:                  token.ExpiresAt.Unix(),
+		Iat:                  token.IssuedAt.Unix(),
+		Sub:                  token.UserID,
+		Aud:                  token.Audience,
+		AuthorizationDetails: token.AuthorizationDetails,
+	}
+
+	if s.subjects != nil && token.UserID != "" {
+		client, clientErr := GetClient(ctx, s.clients, token.ClientID)
+		if clientErr != nil {
+			return nil, clientErr
+		}
+		resp.Sub, err = s.subjects.SubjectIdentifier(ctx, client, token.UserID)
+		if err != nil {
+			return nil, MaybeWrapError(err)
+		}
+	}
+
+	if s.users != nil && token.UserID != "" {
+		user, err := s.users.Get(ctx, token.UserID)
+		if err != nil {
+			return nil, MaybeWrapError(err)
+		}
+		if user != nil {
+			resp.Claims = ClaimsForScope(user, token.Scope)
+		}
+	}
+
+	return resp, nil
+}
+
+// introspection callers are either resource servers or confidential clients,
+// exactly one of the two is returned.
+func (s *defaultAuthorizationServer) authenticateIntrospectionCaller(ctx context.Context, id string, secret string) (ResourceServer, Client, *OAuthError) {
+	if s.resourceServers != nil {
+		rs, err := s.resourceServers.Get(ctx, id)
+		if err != nil {
+			return nil, nil, MaybeWrapError(err)
+		}
+		if rs != nil {
+			rs, authErr := AuthenticateResourceServer(ctx, s.resourceServers, id, secret)
+			return rs, nil, authErr
+		}
+	}
+
+	client, authErr := AuthenticateClient(ctx, s.clients, id, secret)
+	if authErr != nil {
+		return nil, nil, authErr
+	}
+
+	// public clients authenticate with only their ID, that's not enough to
+	// learn about arbitrary tokens.
+	if !client.IsConfidential() {
+		return nil, nil, unauthenticatedClient(InvalidClientWithCause(
+			ErrPublicClientIntrospection,
+			ErrPublicClientIntrospection.Error(),
+		))
+	}
+
+	return nil, client, nil
+}
+
+func RespondWithIntrospection(w http.ResponseWriter, resp *IntrospectionResponse) error {
+	return jsonResponse(w, http.StatusOK, resp)
+}
@@ -0,0 +1,155 @@
+package oauth2server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+func startIntrospectionTest(t *testing.T) (*authorizationServerTestCase, *oauth2server.InMemoryAccessTokenRepository) {
+	t.Helper()
+
+	tokens := oauth2server.NewInMemoryAccessTokenRepository()
+	tc := startAuthorizationServerTest(t, oauth2server.WithIntrospection(tokens))
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+
+	return tc, tokens
+}
+
+func newIntrospectionRequest(token string) *http.Request {
+	req := createRequestWithFormBody(http.MethodPost, "/introspect", map[string]string{
+		oauth2server.ParamToken: token,
+	})
+	req.SetBasicAuth(testClientId, testClientSecret)
+
+	return req
+}
+
+func TestDefaultAuthorizationServer_Introspect_ErrorsIfNotConfigured(t *testing.T) {
+	tc := startAuthorizationServerTest(t)
+	req := newIntrospectionRequest("token")
+
+	_, err := tc.server.Introspect(req.Context(), req)
+
+	if !errors.Is(err, oauth2server.ErrIntrospectionNotSet) {
+		t.Errorf("expected ErrIntrospectionNotSet, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_Introspect_PublicClientsCannotIntrospect(t *testing.T) {
+	tc, _ := startIntrospectionTest(t)
+	tc.clients.Add(oauth2server.NewPublicSimpleClient("public", []string{testRedirectUri}))
+	req := createRequestWithFormBody(http.MethodPost, "/introspect", map[string]string{
+		oauth2server.ParamClientID: "public",
+		oauth2server.ParamToken:    "token",
+	})
+
+	_, err := tc.server.Introspect(req.Context(), req)
+
+	if !errors.Is(err, oauth2server.ErrPublicClientIntrospection) {
+		t.Errorf("expected ErrPublicClientIntrospection, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_Introspect_ErrorsIfTokenIsMissing(t *testing.T) {
+	tc, _ := startIntrospectionTest(t)
+	req := newIntrospectionRequest("")
+
+	_, err := tc.server.Introspect(req.Context(), req)
+
+	if !errors.Is(err, oauth2server.ErrMissingToken) {
+		t.Errorf("expected ErrMissingToken, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_Introspect_UnknownAndExpiredTokensAreInactive(t *testing.T) {
+	tc, tokens := startIntrospectionTest(t)
+	tokens.Create(context.Background(), &oauth2server.AccessToken{
+		Token:     "expired",
+		ClientID:  testClientId,
+		ExpiresAt: time.Now().Add(-time.Second),
+	})
+
+	for _, token := range []string{"unknown", "expired"} {
+		req := newIntrospectionRequest(token)
+
+		resp, err := tc.server.Introspect(req.Context(), req)
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Active || resp.ClientID != "" {
+			t.Errorf("expected %s token to be inactive with no other claims, got %+v", token, resp)
+		}
+	}
+}
+
+func TestDefaultAuthorizationServer_Introspect_IncludesAuthorizationDetails(t *testing.T) {
+	tc, tokens := startIntrospectionTest(t)
+	details, _ := oauth2server.ParseAuthorizationDetails(testPaymentDetails)
+	tokens.Create(context.Background(), &oauth2server.AccessToken{
+		Token:                "active",
+		ClientID:             testClientId,
+		UserID:               "user",
+		Scope:                []string{"payments"},
+		AuthorizationDetails: details,
+		IssuedAt:             time.Now(),
+		ExpiresAt:            time.Now().Add(time.Minute),
+	})
+	req := newIntrospectionRequest("active")
+
+	resp, err := tc.server.Introspect(req.Context(), req)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Active || resp.Sub != "user" || resp.Scope != "payments" || resp.ClientID != testClientId {
+		t.Errorf("unexpected introspection response: %+v", resp)
+	}
+	if len(resp.AuthorizationDetails) != 1 || !resp.AuthorizationDetails[0].Equal(details[0]) {
+		t.Errorf("expected authorization details, got %+v", resp.AuthorizationDetails)
+	}
+}
+
+func TestDefaultAuthorizationServer_Introspect_ClientsOnlySeeTheirOwnTokens(t *testing.T) {
+	tc, tokens := startIntrospectionTest(t)
+	tokens.Create(context.Background(), &oauth2server.AccessToken{
+		Token:     "other",
+		ClientID:  "otherclient",
+		UserID:    "user",
+		Scope:     []string{"read"},
+		IssuedAt:  time.Now(),
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	req := newIntrospectionRequest("other")
+
+	resp, err := tc.server.Introspect(req.Context(), req)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Active || resp.Sub != "" || resp.Scope != "" {
+		t.Errorf("expected another client's token to be inactive, got %+v", resp)
+	}
+}
+
+func TestRespondWithIntrospection_SendsJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	err := oauth2server.RespondWithIntrospection(rec, &oauth2server.IntrospectionResponse{Active: false})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var body map[string]any
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if rec.Code != http.StatusOK || body["active"] != false {
+		t.Errorf("unexpected response %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,164 @@
+package oauth2server
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// JWT Secured Authorization Response Modes, see
+// https://openid.net/specs/oauth-v2-jarm.html
+const (
+	ResponseModeJWT         = "jwt"
+	ResponseModeQueryJWT    = "query.jwt"
+	ResponseModeFragmentJWT = "fragment.jwt"
+	ResponseModeFormPostJWT = "form_post.jwt"
+
+	ParamResponse = "response"
+
+	// the spec recommends short lived authorization responses
+	DefaultAuthorizationResponseLifetime = 10 * time.Minute
+)
+
+// extension point to let clients choose the algorithm used to sign their
+// authorization responses. Uses RS256 if not implemented.
+type ClientSignsAuthorizationResponse interface {
+	AuthorizationSignedResponseAlg() string
+}
+
+// extension point to let clients have their signed authorization responses
+// encrypted.
+type ClientEncryptsAuthorizationResponse interface {
+	// return a nil encrypter to send the signed response as-is
+	AuthorizationResponseEncrypter(ctx context.Context) (JWTEncrypter, error)
+}
+
+type jarmResponseMode struct {
+	responseMode string
+	issuer       string
+	keys         KeySet
+	lifetime     time.Duration
+
+	// the response mode that will deliver the `response` parameter, nil for
+	// the generic `jwt` mode which choses one based on the response type.
+	delivery ResponseModeHandler
+}
+
+// Create the JWT secured authorization response modes: `query.jwt`,
+// `fragment.jwt`, `form_post.jwt`, and `jwt`. Responses are signed with a
+// key from keys and issuer is used as the `iss` claim.
+func JARMResponseModes(issuer string, keys KeySet) []ResponseModeHandler {
+	newMode := func(mode string, delivery ResponseModeHandler) ResponseModeHandler {
+		return &jarmResponseMode{
+			responseMode: mode,
+			issuer:       issuer,
+			keys:         keys,
+			lifetime:     DefaultAuthorizationResponseLifetime,
+			delivery:     delivery,
+		}
+	}
+
+	return []ResponseModeHandler{
+		newMode(ResponseModeJWT, nil),
+		newMode(ResponseModeQueryJWT, QueryResponseMode()),
+		newMode(ResponseModeFragmentJWT, FragmentResponseMode()),
+		newMode(ResponseModeFormPostJWT, FormPostResponseMode()),
+	}
+}
+
+// enable the JWT secured authorization response modes on the server.
+func WithJARM(issuer string, keys KeySet) ServerOption {
+	return func(opts *ServerOptions) {
+		for _, m := range JARMResponseModes(issuer, keys) {
+			opts.responseModes[m.ResponseMode()] = m
+		}
+	}
+}
+
+func (m *jarmResponseMode) ResponseMode() string {
+	return m.responseMode
+}
+
+// https://openid.net/specs/oauth-v2-jarm.html#section-2.3.1 says `query.jwt`
+// must not be used with response types that issue tokens unless the response
+// is encrypted. Encryption is optional per client, so this is always disallowed.
+func (m *jarmResponseMode) SupportsResponseType(responseType []string) bool {
+	if m.delivery == nil {
+		return true
+	}
+
+	return m.delivery.SupportsResponseType(responseType)
+}
+
+func (m *jarmResponseMode) Respond(
+	ctx context.Context,
+	w http.ResponseWriter,
+	client Client,
+	req *AuthorizationRequest,
+	params url.Values,
+) error {
+	response, err := m.authorizationResponseJWT(ctx, client, params)
+	if err != nil {
+		return err
+	}
+
+	delivery := m.delivery
+	if delivery == nil {
+		// https://openid.net/specs/oauth-v2-jarm.html#section-2.3.4
+		delivery = FragmentResponseMode()
+		if !issuesTokenInAuthorizationResponse(req.ResponseType) {
+			delivery = QueryResponseMode()
+		}
+	}
+
+	return delivery.Respond(ctx, w, client, req, url.Values{
+		ParamResponse: []string{response},
+	})
+}
+
+// https://openid.net/specs/oauth-v2-jarm.html#section-2.1
+func (m *jarmResponseMode) authorizationResponseJWT(ctx context.Context, client Client, params url.Values) (string, error) {
+	alg := SigningAlgRS256
+	if signs, ok := client.(ClientSignsAuthorizationResponse); ok && signs.AuthorizationSignedResponseAlg() != "" {
+		alg = signs.AuthorizationSignedResponseAlg()
+	}
+
+	key, err := m.keys.SigningKey(ctx, alg)
+	if err != nil {
+		return "", err
+	}
+
+	claims := make(map[string]any, len(params)+3)
+	for k, vals := range params {
+		// repeated parameters are kept as an array rather than dropped
+		if len(vals) == 1 {
+			claims[k] = vals[0]
+		} else {
+			claims[k] = vals
+		}
+	}
+	claims["iss"] = m.issuer
+	claims["aud"] = client.ID()
+	claims["exp"] = time.Now().Add(m.lifetime).Unix()
+
+	jwt, err := SignJWT(key, claims)
+	if err != nil {
+		return "", err
+	}
+
+	encrypts, ok := client.(ClientEncryptsAuthorizationResponse)
+	if !ok {
+		return jwt, nil
+	}
+
+	encrypter, err := encrypts.AuthorizationResponseEncrypter(ctx)
+	if err != nil {
+		return "", err
+	}
+	if encrypter == nil {
+		return jwt, nil
+	}
+
+	return encrypter.EncryptJWT(ctx, jwt)
+}
@@ -0,0 +1,183 @@
+package oauth2server_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+type encryptingClient struct {
+	oauth2server.Client
+	encrypter oauth2server.JWTEncrypter
+}
+
+func (c *encryptingClient) AuthorizationResponseEncrypter(ctx context.Context) (oauth2server.JWTEncrypter, error) {
+	return c.encrypter, nil
+}
+
+func startJARMTest(t *testing.T, client oauth2server.Client) *authorizationServerTestCase {
+	t.Helper()
+
+	tc := startAuthorizationServerTest(
+		t,
+		oauth2server.WithJARM("https://issuer.example.com", oauth2server.NewStaticKeySet(newTestSigningKey())),
+	)
+	tc.clients.Add(client)
+
+	return tc
+}
+
+func TestJARMResponseModes_ContainsAllJWTModes(t *testing.T) {
+	modes := oauth2server.JARMResponseModes("https://issuer.example.com", oauth2server.NewStaticKeySet())
+
+	var names []string
+	for _, m := range modes {
+		names = append(names, m.ResponseMode())
+	}
+
+	expected := "jwt query.jwt fragment.jwt form_post.jwt"
+	if strings.Join(names, " ") != expected {
+		t.Errorf("bad response modes: %v != %v", names, expected)
+	}
+}
+
+func TestJARM_QueryJWTSendsSignedResponseInQueryString(t *testing.T) {
+	tc := startJARMTest(t, oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	rec := httptest.NewRecorder()
+	params := url.Values{}
+	params.Set("code", "abc")
+	params.Set("state", "123")
+
+	err := tc.server.RespondToAuthorizationRequest(context.Background(), rec, &oauth2server.AuthorizationRequest{
+		ClientID:          testClientId,
+		FinalRedirectURI:  testRedirectUri,
+		ResponseType:      []string{"code"},
+		FinalResponseMode: oauth2server.ResponseModeQueryJWT,
+	}, params)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	location, _ := url.Parse(rec.Header().Get("Location"))
+	if location.Query().Has("code") {
+		t.Errorf("response parameters should only be in the JWT: %q", location)
+	}
+	claims := decodeTestJWT(t, location.Query().Get(oauth2server.ParamResponse))
+	if claims["code"] != "abc" || claims["state"] != "123" {
+		t.Errorf("expected response params in claims, got %v", claims)
+	}
+	if claims["iss"] != "https://issuer.example.com" {
+		t.Errorf("bad issuer: %v", claims["iss"])
+	}
+	if claims["aud"] != testClientId {
+		t.Errorf("bad audience: %v", claims["aud"])
+	}
+	if _, ok := claims["exp"]; !ok {
+		t.Errorf("expected an exp claim: %v", claims)
+	}
+}
+
+func TestJARM_KeepsRepeatedResponseParameters(t *testing.T) {
+	tc := startJARMTest(t, oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	rec := httptest.NewRecorder()
+	params := url.Values{"code": {"abc"}, "extra": {"one", "two"}}
+
+	err := tc.server.RespondToAuthorizationRequest(context.Background(), rec, &oauth2server.AuthorizationRequest{
+		ClientID:          testClientId,
+		FinalRedirectURI:  testRedirectUri,
+		ResponseType:      []string{"code"},
+		FinalResponseMode: oauth2server.ResponseModeQueryJWT,
+	}, params)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	location, _ := url.Parse(rec.Header().Get("Location"))
+	claims := decodeTestJWT(t, location.Query().Get(oauth2server.ParamResponse))
+	extra, _ := claims["extra"].([]any)
+	if claims["code"] != "abc" || len(extra) != 2 || extra[0] != "one" || extra[1] != "two" {
+		t.Errorf("expected repeated params as an array, got %v", claims)
+	}
+}
+
+func TestJARM_JWTResponseModeUsesFragmentForTokenResponseTypes(t *testing.T) {
+	tc := startJARMTest(t, oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	rec := httptest.NewRecorder()
+
+	err := tc.server.RespondWithAuthorizationError(context.Background(), rec, &oauth2server.AuthorizationRequest{
+		ClientID:          testClientId,
+		FinalRedirectURI:  testRedirectUri,
+		ResponseType:      []string{"code", "id_token"},
+		FinalResponseMode: oauth2server.ResponseModeJWT,
+	}, oauth2server.UnauthorizedClient("nope"))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	location, _ := url.Parse(rec.Header().Get("Location"))
+	fragment, _ := url.ParseQuery(location.Fragment)
+	claims := decodeTestJWT(t, fragment.Get(oauth2server.ParamResponse))
+	if claims["error"] != oauth2server.ErrorTypeUnauthorizedClient {
+		t.Errorf("expected error in claims, got %v", claims)
+	}
+}
+
+func TestJARM_EncryptsResponsesForClientsWithEncrypter(t *testing.T) {
+	client := &encryptingClient{
+		Client:    oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}),
+		encrypter: oauth2server.NewRSAOAEPEncrypter("", &testRSAKey.PublicKey),
+	}
+	tc := startJARMTest(t, client)
+	rec := httptest.NewRecorder()
+	params := url.Values{}
+	params.Set("code", "abc")
+
+	err := tc.server.RespondToAuthorizationRequest(context.Background(), rec, &oauth2server.AuthorizationRequest{
+		ClientID:          testClientId,
+		FinalRedirectURI:  testRedirectUri,
+		ResponseType:      []string{"code"},
+		FinalResponseMode: oauth2server.ResponseModeFormPostJWT,
+	}, params)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body := rec.Body.String()
+	start := strings.Index(body, `name="response" value="`)
+	if start < 0 {
+		t.Fatalf("expected a response input in form: %s", body)
+	}
+	start += len(`name="response" value="`)
+	encrypted := body[start : start+strings.Index(body[start:], `"`)]
+	claims := decodeTestJWT(t, decryptTestJWE(t, encrypted))
+	if claims["code"] != "abc" {
+		t.Errorf("expected response params in claims, got %v", claims)
+	}
+}
+
+func TestJARM_QueryJWTCannotBeUsedWithTokenResponseTypes(t *testing.T) {
+	authHandler := &spyAuthorizationHandler{
+		responseType: "id_token",
+	}
+	tc := startAuthorizationServerTest(
+		t,
+		oauth2server.WithAuthorizationHandler(authHandler),
+		oauth2server.WithJARM("https://issuer.example.com", oauth2server.NewStaticKeySet(newTestSigningKey())),
+	)
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamResponseType: authHandler.responseType,
+		oauth2server.ParamResponseMode: oauth2server.ResponseModeQueryJWT,
+		oauth2server.ParamClientID:     testClientId,
+	})
+
+	_, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeInvalidRequest {
+		t.Errorf("expected an invalid_request error, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package oauth2server
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+const (
+	EncryptionAlgRSAOAEP256 = "RSA-OAEP-256"
+	EncryptionEncA256GCM    = "A256GCM"
+)
+
+// Encrypts signed JWTs for a recipient, producing a nested JWT. The standard
+// library only has enough to support RSA-OAEP-256 out of the box, so
+// this is an interface to allow other algorithms to be plugged in.
+type JWTEncrypter interface {
+	EncryptJWT(ctx context.Context, jwt string) (string, error)
+}
+
+type jweHeader struct {
+	Algorithm   string `json:"alg"`
+	Encryption  string `json:"enc"`
+	ContentType string `json:"cty,omitempty"`
+	KeyID       string `json:"kid,omitempty"`
+}
+
+type rsaOAEPEncrypter struct {
+	keyID string
+	key   *rsa.PublicKey
+}
+
+// encrypt JWTs with `RSA-OAEP-256` key encryption and `A256GCM` content
+// encryption. keyID is the recipient's key identifier and may be empty.
+func NewRSAOAEPEncrypter(keyID string, key *rsa.PublicKey) JWTEncrypter {
+	return &rsaOAEPEncrypter{
+		keyID: keyID,
+		key:   key,
+	}
+}
+
+// https://datatracker.ietf.org/doc/html/rfc7516#section-5.1
+func (e *rsaOAEPEncrypter) EncryptJWT(ctx context.Context, jwt string) (string, error) {
+	header, err := json.Marshal(jweHeader{
+		Algorithm:   EncryptionAlgRSAOAEP256,
+		Encryption:  EncryptionEncA256GCM,
+		ContentType: "JWT",
+		KeyID:       e.keyID,
+	})
+	if err != nil {
+		return "", err
+	}
+	encodedHeader := base64.RawURLEncoding.EncodeToString(header)
+
+	cek := make([]byte, 32)
+	if _, err := rand.Read(cek); err != nil {
+		return "", err
+	}
+
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, e.key, cek, nil)
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nil, iv, []byte(jwt), []byte(encodedHeader))
+	ciphertext, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	return strings.Join([]string{
+		encodedHeader,
+		base64.RawURLEncoding.EncodeToString(encryptedKey),
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(ciphertext),
+		base64.RawURLEncoding.EncodeToString(tag),
+	}, "."), nil
+}
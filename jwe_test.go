@@ -0,0 +1,66 @@
+package oauth2server_test
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+func decryptTestJWE(t *testing.T, token string) string {
+	t.Helper()
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		t.Fatalf("expected a JWE with five parts, got %q", token)
+	}
+	decoded := make([][]byte, 5)
+	for i, p := range parts {
+		b, err := base64.RawURLEncoding.DecodeString(p)
+		if err != nil {
+			t.Fatalf("could not decode JWE part %d: %v", i, err)
+		}
+		decoded[i] = b
+	}
+
+	var header map[string]any
+	if err := json.Unmarshal(decoded[0], &header); err != nil {
+		t.Fatalf("could not decode JWE header: %v", err)
+	}
+	if header["alg"] != oauth2server.EncryptionAlgRSAOAEP256 || header["enc"] != oauth2server.EncryptionEncA256GCM {
+		t.Fatalf("bad JWE header: %v", header)
+	}
+
+	cek, err := rsa.DecryptOAEP(sha256.New(), nil, testRSAKey, decoded[1], nil)
+	if err != nil {
+		t.Fatalf("could not decrypt content encryption key: %v", err)
+	}
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plain, err := gcm.Open(nil, decoded[2], append(decoded[3], decoded[4]...), []byte(parts[0]))
+	if err != nil {
+		t.Fatalf("could not decrypt JWE: %v", err)
+	}
+
+	return string(plain)
+}
+
+func TestRSAOAEPEncrypter_EncryptsJWTs(t *testing.T) {
+	encrypter := oauth2server.NewRSAOAEPEncrypter("enckey", &testRSAKey.PublicKey)
+
+	encrypted, err := encrypter.EncryptJWT(context.Background(), "a.b.c")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decrypted := decryptTestJWE(t, encrypted); decrypted != "a.b.c" {
+		t.Errorf(`bad decrypted value: %q != "a.b.c"`, decrypted)
+	}
+}
@@ -0,0 +1,141 @@
+package oauth2server
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	ErrUnsupportedJSONWebKey = errors.New("unsupported JSON web key")
+)
+
+// A public JSON web key, see https://datatracker.ietf.org/doc/html/rfc7517
+// only RSA, EC (P-256), and Ed25519 keys are supported.
+type JSONWebKey struct {
+	KeyID     string
+	Algorithm string
+	Use       string
+
+	// one of *rsa.PublicKey, *ecdsa.PublicKey, or ed25519.PublicKey
+	Key crypto.PublicKey
+}
+
+// a JWK set, see https://datatracker.ietf.org/doc/html/rfc7517#section-5
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+type rawJSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Use       string `json:"use,omitempty"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC and OKP
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+func (k JSONWebKey) MarshalJSON() ([]byte, error) {
+	raw := rawJSONWebKey{
+		KeyID:     k.KeyID,
+		Algorithm: k.Algorithm,
+		Use:       k.Use,
+	}
+
+	switch key := k.Key.(type) {
+	case *rsa.PublicKey:
+		raw.KeyType = "RSA"
+		raw.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		raw.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("%w: curve %s", ErrUnsupportedJSONWebKey, key.Curve.Params().Name)
+		}
+		raw.KeyType = "EC"
+		raw.Curve = "P-256"
+		raw.X = base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32)))
+		raw.Y = base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32)))
+	case ed25519.PublicKey:
+		raw.KeyType = "OKP"
+		raw.Curve = "Ed25519"
+		raw.X = base64.RawURLEncoding.EncodeToString(key)
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedJSONWebKey, k.Key)
+	}
+
+	return json.Marshal(raw)
+}
+
+func (k *JSONWebKey) UnmarshalJSON(b []byte) error {
+	var raw rawJSONWebKey
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	k.KeyID = raw.KeyID
+	k.Algorithm = raw.Algorithm
+	k.Use = raw.Use
+
+	switch raw.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(raw.N)
+		if err != nil {
+			return err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(raw.E)
+		if err != nil {
+			return err
+		}
+		k.Key = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	case "EC":
+		if raw.Curve != "P-256" {
+			return fmt.Errorf("%w: curve %s", ErrUnsupportedJSONWebKey, raw.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(raw.X)
+		if err != nil {
+			return err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(raw.Y)
+		if err != nil {
+			return err
+		}
+		k.Key = &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+	case "OKP":
+		if raw.Curve != "Ed25519" {
+			return fmt.Errorf("%w: curve %s", ErrUnsupportedJSONWebKey, raw.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(raw.X)
+		if err != nil {
+			return err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return fmt.Errorf("%w: bad Ed25519 key size", ErrUnsupportedJSONWebKey)
+		}
+		k.Key = ed25519.PublicKey(x)
+	default:
+		return fmt.Errorf("%w: key type %s", ErrUnsupportedJSONWebKey, raw.KeyType)
+	}
+
+	return nil
+}
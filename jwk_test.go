@@ -0,0 +1,75 @@
+package oauth2server_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+func roundTripJSONWebKey(t *testing.T, key oauth2server.JSONWebKey) oauth2server.JSONWebKey {
+	t.Helper()
+
+	encoded, err := json.Marshal(key)
+	if err != nil {
+		t.Fatalf("unexpected error encoding key: %v", err)
+	}
+
+	var decoded oauth2server.JSONWebKey
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("unexpected error decoding key: %v", err)
+	}
+
+	return decoded
+}
+
+func TestJSONWebKey_RSAKeysCanBeEncodedAndDecoded(t *testing.T) {
+	decoded := roundTripJSONWebKey(t, oauth2server.JSONWebKey{
+		KeyID:     "rsa",
+		Algorithm: oauth2server.SigningAlgRS256,
+		Use:       "sig",
+		Key:       &testRSAKey.PublicKey,
+	})
+
+	if decoded.KeyID != "rsa" || decoded.Algorithm != oauth2server.SigningAlgRS256 || decoded.Use != "sig" {
+		t.Errorf("bad key metadata: %+v", decoded)
+	}
+	if !testRSAKey.PublicKey.Equal(decoded.Key) {
+		t.Errorf("decoded key does not match: %+v", decoded.Key)
+	}
+}
+
+func TestJSONWebKey_ECKeysCanBeEncodedAndDecoded(t *testing.T) {
+	priv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	decoded := roundTripJSONWebKey(t, oauth2server.JSONWebKey{Key: &priv.PublicKey})
+
+	if !priv.PublicKey.Equal(decoded.Key) {
+		t.Errorf("decoded key does not match: %+v", decoded.Key)
+	}
+}
+
+func TestJSONWebKey_Ed25519KeysCanBeEncodedAndDecoded(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	decoded := roundTripJSONWebKey(t, oauth2server.JSONWebKey{Key: pub})
+
+	if !pub.Equal(decoded.Key) {
+		t.Errorf("decoded key does not match: %+v", decoded.Key)
+	}
+}
+
+func TestJSONWebKey_ErrorsOnUnsupportedKeyTypes(t *testing.T) {
+	var key oauth2server.JSONWebKey
+
+	err := json.Unmarshal([]byte(`{"kty":"oct","k":"abc"}`), &key)
+
+	if !errors.Is(err, oauth2server.ErrUnsupportedJSONWebKey) {
+		t.Errorf("expected ErrUnsupportedJSONWebKey, got %v", err)
+	}
+}
@@ -0,0 +1,242 @@
+package oauth2server
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	SigningAlgRS256 = "RS256"
+	SigningAlgPS256 = "PS256"
+	SigningAlgES256 = "ES256"
+	SigningAlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrUnsupportedSigningAlg = errors.New("unsupported signing algorithm")
+	ErrSigningKeyNotFound    = errors.New("no signing key found")
+	ErrSigningKeyMismatch    = errors.New("signing key does not match its algorithm")
+	ErrMalformedJWT          = errors.New("malformed JWT")
+	ErrInvalidJWTSignature   = errors.New("JWT signature could not be verified")
+)
+
+// a private key used to sign JWTs issued by the server. The signer is any
+// `crypto.Signer`, so keys held in an HSM or KMS can be used as well as keys
+// from the standard library.
+type SigningKey struct {
+	// the key identifier, this is included as the `kid` header of signed JWTs
+	ID string
+
+	// the JWS algorithm the key signs with, eg `RS256`
+	Algorithm string
+
+	Signer crypto.Signer
+}
+
+// the public half of the key as a JWK for signature verification
+func (k *SigningKey) PublicKey() JSONWebKey {
+	return JSONWebKey{
+		KeyID:     k.ID,
+		Algorithm: k.Algorithm,
+		Use:       "sig",
+		Key:       k.Signer.Public(),
+	}
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+	KeyID     string `json:"kid,omitempty"`
+}
+
+// sign the claims with the given key and return a compact serialized JWS
+func SignJWT(key *SigningKey, claims any) (string, error) {
+	return signJWT(key, "JWT", claims)
+}
+
+func signJWT(key *SigningKey, typ string, claims any) (string, error) {
+	header, err := json.Marshal(jwtHeader{
+		Algorithm: key.Algorithm,
+		Type:      typ,
+		KeyID:     key.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	sig, err := signJWS(key, []byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func signJWS(key *SigningKey, signingInput []byte) ([]byte, error) {
+	switch key.Algorithm {
+	case SigningAlgRS256:
+		if _, ok := key.Signer.Public().(*rsa.PublicKey); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrSigningKeyMismatch, key.Algorithm)
+		}
+		digest := sha256.Sum256(signingInput)
+		return key.Signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	case SigningAlgPS256:
+		if _, ok := key.Signer.Public().(*rsa.PublicKey); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrSigningKeyMismatch, key.Algorithm)
+		}
+		digest := sha256.Sum256(signingInput)
+		return key.Signer.Sign(rand.Reader, digest[:], &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+			Hash:       crypto.SHA256,
+		})
+	case SigningAlgES256:
+		pub, ok := key.Signer.Public().(*ecdsa.PublicKey)
+		if !ok || pub.Curve.Params().BitSize != 256 {
+			return nil, fmt.Errorf("%w: %s", ErrSigningKeyMismatch, key.Algorithm)
+		}
+		digest := sha256.Sum256(signingInput)
+		der, err := key.Signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		return ecdsaDERToJWS(der, 32)
+	case SigningAlgEdDSA:
+		if _, ok := key.Signer.Public().(ed25519.PublicKey); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrSigningKeyMismatch, key.Algorithm)
+		}
+		return key.Signer.Sign(rand.Reader, signingInput, crypto.Hash(0))
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedSigningAlg, key.Algorithm)
+}
+
+// `crypto.Signer` returns ASN.1 encoded ECDSA signatures, but JWS wants the
+// R and S values concatenated, see https://datatracker.ietf.org/doc/html/rfc7518#section-3.4
+func ecdsaDERToJWS(der []byte, size int) ([]byte, error) {
+	var sig struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, size*2)
+	sig.R.FillBytes(out[:size])
+	sig.S.FillBytes(out[size:])
+
+	return out, nil
+}
+
+// verify a compact serialized JWS against a set of public keys and decode its
+// claims into `claims`. Only the key with a matching `kid` is tried if the
+// JWT has one, otherwise every key is tried. Unsigned JWTs are always rejected.
+func VerifyJWT(token string, keys []JSONWebKey, claims any) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("%w: expected three parts", ErrMalformedJWT)
+	}
+
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedJWT, err)
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedJWT, err)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedJWT, err)
+	}
+
+	signingInput := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range keys {
+		if header.KeyID != "" && key.KeyID != header.KeyID {
+			continue
+		}
+		if key.Algorithm != "" && key.Algorithm != header.Algorithm {
+			continue
+		}
+		if verifyJWS(header.Algorithm, key.Key, signingInput, sig) {
+			verified = true
+			break
+		}
+	}
+
+	if !verified {
+		return ErrInvalidJWTSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedJWT, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(claims); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedJWT, err)
+	}
+
+	return nil
+}
+
+func verifyJWS(alg string, key crypto.PublicKey, signingInput []byte, sig []byte) bool {
+	switch alg {
+	case SigningAlgRS256:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		digest := sha256.Sum256(signingInput)
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+	case SigningAlgPS256:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		digest := sha256.Sum256(signingInput)
+		return rsa.VerifyPSS(pub, crypto.SHA256, digest[:], sig, &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+		}) == nil
+	case SigningAlgES256:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return false
+		}
+		digest := sha256.Sum256(signingInput)
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(pub, digest[:], r, s)
+	case SigningAlgEdDSA:
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return false
+		}
+		return ed25519.Verify(pub, signingInput, sig)
+	}
+
+	// includes `none`
+	return false
+}
@@ -0,0 +1,222 @@
+package oauth2server_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+var testRSAKey = mustGenerateRSAKey()
+
+func mustGenerateRSAKey() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	return key
+}
+
+func newTestSigningKey() *oauth2server.SigningKey {
+	return &oauth2server.SigningKey{
+		ID:        "testkey",
+		Algorithm: oauth2server.SigningAlgRS256,
+		Signer:    testRSAKey,
+	}
+}
+
+// decode the claims of a JWT signed with the test RSA key, failing the test
+// if the signature is invalid.
+func decodeTestJWT(t *testing.T, token string) map[string]any {
+	t.Helper()
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("expected a JWT with three parts, got %q", token)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatalf("could not decode signature: %v", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&testRSAKey.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+		t.Fatalf("invalid JWT signature: %v", err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatalf("could not decode payload: %v", err)
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("could not decode claims: %v", err)
+	}
+
+	return claims
+}
+
+func splitTestJWT(t *testing.T, token string) (map[string]any, []byte, []byte) {
+	t.Helper()
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("expected a JWT with three parts, got %q", token)
+	}
+	header, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+
+	var h map[string]any
+	if err := json.Unmarshal(header, &h); err != nil {
+		t.Fatalf("could not decode header: %v", err)
+	}
+
+	return h, []byte(parts[0] + "." + parts[1]), sig
+}
+
+func TestSignJWT_SignsWithRS256(t *testing.T) {
+	token, err := oauth2server.SignJWT(newTestSigningKey(), map[string]any{"sub": "abc"})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	header, _, _ := splitTestJWT(t, token)
+	if header["alg"] != oauth2server.SigningAlgRS256 || header["kid"] != "testkey" {
+		t.Errorf("bad header: %v", header)
+	}
+	claims := decodeTestJWT(t, token)
+	if claims["sub"] != "abc" {
+		t.Errorf("bad claims: %v", claims)
+	}
+}
+
+func TestSignJWT_SignsWithES256(t *testing.T) {
+	priv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	token, err := oauth2server.SignJWT(&oauth2server.SigningKey{
+		Algorithm: oauth2server.SigningAlgES256,
+		Signer:    priv,
+	}, map[string]any{"sub": "abc"})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, input, sig := splitTestJWT(t, token)
+	if len(sig) != 64 {
+		t.Fatalf("expected a 64 byte signature, got %d", len(sig))
+	}
+	digest := sha256.Sum256(input)
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(&priv.PublicKey, digest[:], r, s) {
+		t.Error("invalid ES256 signature")
+	}
+}
+
+func TestSignJWT_SignsWithEdDSA(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+
+	token, err := oauth2server.SignJWT(&oauth2server.SigningKey{
+		Algorithm: oauth2server.SigningAlgEdDSA,
+		Signer:    priv,
+	}, map[string]any{"sub": "abc"})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, input, sig := splitTestJWT(t, token)
+	if !ed25519.Verify(pub, input, sig) {
+		t.Error("invalid EdDSA signature")
+	}
+}
+
+func TestSignJWT_ErrorsIfKeyDoesNotMatchAlgorithm(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+
+	_, err := oauth2server.SignJWT(&oauth2server.SigningKey{
+		Algorithm: oauth2server.SigningAlgRS256,
+		Signer:    priv,
+	}, map[string]any{})
+
+	if !errors.Is(err, oauth2server.ErrSigningKeyMismatch) {
+		t.Errorf("expected ErrSigningKeyMismatch, got %v", err)
+	}
+}
+
+func TestSignJWT_ErrorsOnUnsupportedAlgorithm(t *testing.T) {
+	_, err := oauth2server.SignJWT(&oauth2server.SigningKey{
+		Algorithm: "HS256",
+		Signer:    testRSAKey,
+	}, map[string]any{})
+
+	if !errors.Is(err, oauth2server.ErrUnsupportedSigningAlg) {
+		t.Errorf("expected ErrUnsupportedSigningAlg, got %v", err)
+	}
+}
+
+func testJSONWebKeys() []oauth2server.JSONWebKey {
+	return []oauth2server.JSONWebKey{
+		{KeyID: "testkey", Key: &testRSAKey.PublicKey},
+	}
+}
+
+func TestVerifyJWT_DecodesClaimsWithValidSignature(t *testing.T) {
+	token, _ := oauth2server.SignJWT(newTestSigningKey(), map[string]any{"sub": "abc"})
+
+	var claims map[string]any
+	err := oauth2server.VerifyJWT(token, testJSONWebKeys(), &claims)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claims["sub"] != "abc" {
+		t.Errorf("bad claims: %v", claims)
+	}
+}
+
+func TestVerifyJWT_ErrorsIfNoKeyMatches(t *testing.T) {
+	token, _ := oauth2server.SignJWT(newTestSigningKey(), map[string]any{"sub": "abc"})
+	other := mustGenerateRSAKey()
+
+	var claims map[string]any
+	err := oauth2server.VerifyJWT(token, []oauth2server.JSONWebKey{
+		{KeyID: "testkey", Key: &other.PublicKey},
+	}, &claims)
+
+	if !errors.Is(err, oauth2server.ErrInvalidJWTSignature) {
+		t.Errorf("expected ErrInvalidJWTSignature, got %v", err)
+	}
+}
+
+func TestVerifyJWT_RejectsUnsignedJWTs(t *testing.T) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"abc"}`))
+
+	var claims map[string]any
+	err := oauth2server.VerifyJWT(header+"."+payload+".", testJSONWebKeys(), &claims)
+
+	if !errors.Is(err, oauth2server.ErrInvalidJWTSignature) {
+		t.Errorf("expected ErrInvalidJWTSignature, got %v", err)
+	}
+}
+
+func TestVerifyJWT_ErrorsOnMalformedJWTs(t *testing.T) {
+	var claims map[string]any
+	err := oauth2server.VerifyJWT("nope", testJSONWebKeys(), &claims)
+
+	if !errors.Is(err, oauth2server.ErrMalformedJWT) {
+		t.Errorf("expected ErrMalformedJWT, got %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package oauth2server
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultKeyRotationInterval = 30 * 24 * time.Hour
+
+	// how long retired keys are published by default, the longest default
+	// lifetime of the JWTs the server signs.
+	DefaultRetiredKeyLifetime = max(DefaultIDTokenLifetime, DefaultAuthorizationResponseLifetime, logoutTokenLifetime)
+)
+
+var ErrRetiredKeyLifetimeTooShort = errors.New("retired keys must be published for as long as the tokens they signed are valid")
+
+// creates signing keys for a RotatingKeySet. Implementations can create keys
+// in an HSM or KMS, they only need to return a `crypto.Signer` for the key.
+type KeyGenerator interface {
+	GenerateKey(ctx context.Context, alg string) (crypto.Signer, error)
+}
+
+type inMemoryKeyGenerator struct{}
+
+// generate keys in memory with the standard library, RSA keys are 2048 bits.
+func NewInMemoryKeyGenerator() KeyGenerator {
+	return &inMemoryKeyGenerator{}
+}
+
+func (g *inMemoryKeyGenerator) GenerateKey(ctx context.Context, alg string) (crypto.Signer, error) {
+	switch alg {
+	case SigningAlgRS256, SigningAlgPS256:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case SigningAlgES256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case SigningAlgEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedSigningAlg, alg)
+}
+
+type retiredSigningKey struct {
+	key       *SigningKey
+	expiresAt time.Time
+}
+
+// A key set that rotates its keys. Each algorithm has an active key that
+// signs and a next key that will become active on the next rotation. Both are
+// published so verifiers can cache the next key before it is used. Retired
+// keys stay published until the retired key lifetime passes.
+type RotatingKeySet struct {
+	generator       KeyGenerator
+	algorithms      []string
+	interval        time.Duration
+	retiredLifetime time.Duration
+	tokenLifetimes  []time.Duration
+
+	mu      sync.RWMutex
+	active  map[string]*SigningKey
+	next    map[string]*SigningKey
+	retired []retiredSigningKey
+}
+
+type RotatingKeySetOption func(*RotatingKeySet)
+
+// the algorithms the key set has keys for, defaults to RS256 only.
+func WithKeyRotationAlgorithms(algs ...string) RotatingKeySetOption {
+	return func(k *RotatingKeySet) {
+		k.algorithms = algs
+	}
+}
+
+// how often Run rotates the keys.
+func WithKeyRotationInterval(interval time.Duration) RotatingKeySetOption {
+	return func(k *RotatingKeySet) {
+		k.interval = interval
+	}
+}
+
+// how long retired public keys are still published after rotation.
+func WithRetiredKeyLifetime(lifetime time.Duration) RotatingKeySetOption {
+	return func(k *RotatingKeySet) {
+		k.retiredLifetime = lifetime
+	}
+}
+
+// the lifetimes of the JWTs signed with the key set, eg the ID token lifetime
+// given to WithIDTokenLifetime. NewRotatingKeySet errors if the retired key
+// lifetime is shorter than any of them, tokens signed just before a rotation
+// could not be verified otherwise.
+func WithSignedTokenLifetimes(lifetimes ...time.Duration) RotatingKeySetOption {
+	return func(k *RotatingKeySet) {
+		k.tokenLifetimes = append(k.tokenLifetimes, lifetimes...)
+	}
+}
+
+// create a rotating key set, the active and next keys for each algorithm are
+// generated immediately.
+func NewRotatingKeySet(ctx context.Context, generator KeyGenerator, opts ...RotatingKeySetOption) (*RotatingKeySet, error) {
+	k := &RotatingKeySet{
+		generator:       generator,
+		algorithms:      []string{SigningAlgRS256},
+		interval:        DefaultKeyRotationInterval,
+		retiredLifetime: DefaultRetiredKeyLifetime,
+		active:          make(map[string]*SigningKey),
+		next:            make(map[string]*SigningKey),
+	}
+	for _, opt := range opts {
+		opt(k)
+	}
+
+	for _, lifetime := range k.tokenLifetimes {
+		if k.retiredLifetime < lifetime {
+			return nil, fmt.Errorf("%w: %s is shorter than %s", ErrRetiredKeyLifetimeTooShort, k.retiredLifetime, lifetime)
+		}
+	}
+
+	for _, alg := range k.algorithms {
+		active, err := k.generate(ctx, alg)
+		if err != nil {
+			return nil, err
+		}
+		next, err := k.generate(ctx, alg)
+		if err != nil {
+			return nil, err
+		}
+		k.active[alg] = active
+		k.next[alg] = next
+	}
+
+	return k, nil
+}
+
+func (k *RotatingKeySet) generate(ctx context.Context, alg string) (*SigningKey, error) {
+	signer, err := k.generator.GenerateKey(ctx, alg)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := generateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{
+		ID:        id,
+		Algorithm: alg,
+		Signer:    signer,
+	}, nil
+}
+
+func (k *RotatingKeySet) SigningKey(ctx context.Context, alg string) (*SigningKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key, ok := k.active[alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSigningKeyNotFound, alg)
+	}
+
+	return key, nil
+}
+
+// the active, next, and unexpired retired keys.
+func (k *RotatingKeySet) PublicKeys(ctx context.Context) ([]JSONWebKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	now := time.Now()
+	keys := make([]JSONWebKey, 0, len(k.active)+len(k.next)+len(k.retired))
+	for _, alg := range k.algorithms {
+		keys = append(keys, k.active[alg].PublicKey(), k.next[alg].PublicKey())
+	}
+	for _, retired := range k.retired {
+		if now.Before(retired.expiresAt) {
+			keys = append(keys, retired.key.PublicKey())
+		}
+	}
+
+	return keys, nil
+}
+
+// retire the active keys, activate the next keys, and generate new next keys.
+// Expired retired keys are dropped.
+func (k *RotatingKeySet) Rotate(ctx context.Context) error {
+	next := make(map[string]*SigningKey, len(k.algorithms))
+	for _, alg := range k.algorithms {
+		key, err := k.generate(ctx, alg)
+		if err != nil {
+			return err
+		}
+		next[alg] = key
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	k.retired = slices.DeleteFunc(k.retired, func(retired retiredSigningKey) bool {
+		return !now.Before(retired.expiresAt)
+	})
+	for _, alg := range k.algorithms {
+		k.retired = append(k.retired, retiredSigningKey{
+			key:       k.active[alg],
+			expiresAt: now.Add(k.retiredLifetime),
+		})
+		k.active[alg] = k.next[alg]
+	}
+	k.next = next
+
+	return nil
+}
+
+// rotate the keys on the configured interval until the context is done or a
+// rotation fails.
+func (k *RotatingKeySet) Run(ctx context.Context) error {
+	ticker := time.NewTicker(k.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := k.Rotate(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
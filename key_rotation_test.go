@@ -0,0 +1,160 @@
+package oauth2server_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+func newTestRotatingKeySet(t *testing.T, opts ...oauth2server.RotatingKeySetOption) *oauth2server.RotatingKeySet {
+	t.Helper()
+
+	opts = append([]oauth2server.RotatingKeySetOption{
+		oauth2server.WithKeyRotationAlgorithms(oauth2server.SigningAlgEdDSA),
+	}, opts...)
+	keys, err := oauth2server.NewRotatingKeySet(context.Background(), oauth2server.NewInMemoryKeyGenerator(), opts...)
+	if err != nil {
+		t.Fatalf("unexpected error creating key set: %v", err)
+	}
+
+	return keys
+}
+
+func publicKeyIDs(t *testing.T, keys oauth2server.PublicKeySet) []string {
+	t.Helper()
+
+	published, err := keys.PublicKeys(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ids := make([]string, 0, len(published))
+	for _, key := range published {
+		ids = append(ids, key.KeyID)
+	}
+
+	return ids
+}
+
+func TestInMemoryKeyGenerator_GeneratesKeysThatCanSign(t *testing.T) {
+	generator := oauth2server.NewInMemoryKeyGenerator()
+
+	for _, alg := range []string{oauth2server.SigningAlgRS256, oauth2server.SigningAlgES256, oauth2server.SigningAlgEdDSA} {
+		signer, err := generator.GenerateKey(context.Background(), alg)
+		if err != nil {
+			t.Fatalf("unexpected error generating %s key: %v", alg, err)
+		}
+		key := &oauth2server.SigningKey{ID: "k", Algorithm: alg, Signer: signer}
+
+		jwt, err := oauth2server.SignJWT(key, map[string]any{"sub": "user"})
+		if err != nil {
+			t.Fatalf("unexpected error signing with %s: %v", alg, err)
+		}
+		var claims map[string]any
+		if err := oauth2server.VerifyJWT(jwt, []oauth2server.JSONWebKey{key.PublicKey()}, &claims); err != nil {
+			t.Errorf("could not verify %s JWT: %v", alg, err)
+		}
+	}
+}
+
+func TestInMemoryKeyGenerator_ErrorsOnUnsupportedAlgorithms(t *testing.T) {
+	_, err := oauth2server.NewInMemoryKeyGenerator().GenerateKey(context.Background(), "HS256")
+
+	if !errors.Is(err, oauth2server.ErrUnsupportedSigningAlg) {
+		t.Errorf("expected ErrUnsupportedSigningAlg, got %v", err)
+	}
+}
+
+func TestRotatingKeySet_PublishesActiveAndNextKeys(t *testing.T) {
+	keys := newTestRotatingKeySet(t)
+
+	active, err := keys.SigningKey(context.Background(), oauth2server.SigningAlgEdDSA)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ids := publicKeyIDs(t, keys)
+	if len(ids) != 2 || ids[0] != active.ID {
+		t.Errorf("expected active and next keys to be published, got %v", ids)
+	}
+}
+
+func TestRotatingKeySet_ErrorsIfNoKeyForAlgorithm(t *testing.T) {
+	keys := newTestRotatingKeySet(t)
+
+	_, err := keys.SigningKey(context.Background(), oauth2server.SigningAlgRS256)
+
+	if !errors.Is(err, oauth2server.ErrSigningKeyNotFound) {
+		t.Errorf("expected ErrSigningKeyNotFound, got %v", err)
+	}
+}
+
+func TestNewRotatingKeySet_ErrorsIfRetiredKeysExpireBeforeSignedTokens(t *testing.T) {
+	_, err := oauth2server.NewRotatingKeySet(
+		context.Background(),
+		oauth2server.NewInMemoryKeyGenerator(),
+		oauth2server.WithKeyRotationAlgorithms(oauth2server.SigningAlgEdDSA),
+		oauth2server.WithRetiredKeyLifetime(time.Hour),
+		oauth2server.WithSignedTokenLifetimes(oauth2server.DefaultAuthorizationResponseLifetime, 2*time.Hour),
+	)
+
+	if !errors.Is(err, oauth2server.ErrRetiredKeyLifetimeTooShort) {
+		t.Errorf("expected ErrRetiredKeyLifetimeTooShort, got %v", err)
+	}
+}
+
+func TestNewRotatingKeySet_DefaultRetiredKeyLifetimeCoversDefaultTokenLifetimes(t *testing.T) {
+	newTestRotatingKeySet(t, oauth2server.WithSignedTokenLifetimes(
+		oauth2server.DefaultIDTokenLifetime,
+		oauth2server.DefaultAuthorizationResponseLifetime,
+	))
+}
+
+func TestRotatingKeySet_Rotate_ActivatesNextKeyAndKeepsRetiredKeyPublished(t *testing.T) {
+	keys := newTestRotatingKeySet(t, oauth2server.WithRetiredKeyLifetime(time.Hour))
+	before := publicKeyIDs(t, keys)
+
+	if err := keys.Rotate(context.Background()); err != nil {
+		t.Fatalf("unexpected error rotating: %v", err)
+	}
+
+	active, _ := keys.SigningKey(context.Background(), oauth2server.SigningAlgEdDSA)
+	if active.ID != before[1] {
+		t.Errorf("expected next key %q to become active, got %q", before[1], active.ID)
+	}
+	after := publicKeyIDs(t, keys)
+	if len(after) != 3 || after[2] != before[0] {
+		t.Errorf("expected retired key %q to still be published, got %v", before[0], after)
+	}
+}
+
+func TestRotatingKeySet_Rotate_DropsExpiredRetiredKeys(t *testing.T) {
+	keys := newTestRotatingKeySet(t, oauth2server.WithRetiredKeyLifetime(time.Nanosecond))
+
+	keys.Rotate(context.Background())
+	time.Sleep(time.Millisecond)
+
+	if ids := publicKeyIDs(t, keys); len(ids) != 2 {
+		t.Errorf("expected retired key to be dropped, got %v", ids)
+	}
+}
+
+func TestRotatingKeySet_Run_RotatesOnInterval(t *testing.T) {
+	keys := newTestRotatingKeySet(t, oauth2server.WithKeyRotationInterval(10*time.Millisecond))
+	before, _ := keys.SigningKey(context.Background(), oauth2server.SigningAlgEdDSA)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := keys.Run(ctx)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected run to stop with the context, got %v", err)
+	}
+	after, _ := keys.SigningKey(context.Background(), oauth2server.SigningAlgEdDSA)
+	if after.ID == before.ID {
+		t.Error("expected keys to be rotated")
+	}
+}
@@ -0,0 +1,50 @@
+package oauth2server
+
+import (
+	"context"
+	"fmt"
+)
+
+// the set of keys the server uses to sign JWTs like signed authorization
+// responses.
+type KeySet interface {
+	// Get the key that should be used to sign with the given algorithm. Should
+	// return an error wrapping ErrSigningKeyNotFound if no key exists.
+	SigningKey(ctx context.Context, alg string) (*SigningKey, error)
+}
+
+// a key set whose public keys can be published, the `jwks_uri` for the server.
+// This should include any keys that signed JWTs that have not yet expired.
+type PublicKeySet interface {
+	PublicKeys(ctx context.Context) ([]JSONWebKey, error)
+}
+
+type staticKeySet struct {
+	keys []*SigningKey
+}
+
+// a key set that never changes, the first key for an algorithm is used to sign.
+func NewStaticKeySet(keys ...*SigningKey) KeySet {
+	return &staticKeySet{
+		keys: keys,
+	}
+}
+
+func (k *staticKeySet) SigningKey(ctx context.Context, alg string) (*SigningKey, error) {
+	for _, key := range k.keys {
+		if key.Algorithm == alg {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrSigningKeyNotFound, alg)
+}
+
+func (k *staticKeySet) PublicKeys(ctx context.Context) ([]JSONWebKey, error) {
+	keys := make([]JSONWebKey, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key.PublicKey())
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,36 @@
+package oauth2server_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+func TestStaticKeySet_ReturnsKeyForAlgorithm(t *testing.T) {
+	key := newTestSigningKey()
+	keys := oauth2server.NewStaticKeySet(key)
+
+	found, err := keys.SigningKey(context.Background(), oauth2server.SigningAlgRS256)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if found != key {
+		t.Errorf("bad key: %+v != %+v", found, key)
+	}
+}
+
+func TestStaticKeySet_ErrorsIfNoKeyForAlgorithm(t *testing.T) {
+	keys := oauth2server.NewStaticKeySet(newTestSigningKey())
+
+	found, err := keys.SigningKey(context.Background(), oauth2server.SigningAlgES256)
+
+	if found != nil {
+		t.Errorf("expected nil key, got %+v", found)
+	}
+	if !errors.Is(err, oauth2server.ErrSigningKeyNotFound) {
+		t.Errorf("expected ErrSigningKeyNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,362 @@
+package oauth2server
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// clients that implement this may send a `post_logout_redirect_uri` with
+// logout requests, only exact matches to these URIs are allowed.
+// See https://openid.net/specs/openid-connect-rpinitiated-1_0.html#ClientMetadata
+type ClientWithPostLogoutRedirectURIs interface {
+	PostLogoutRedirectURIs() []string
+}
+
+// clients that implement this are notified of logouts by loading their
+// `frontchannel_logout_uri` in an iframe on the logout page.
+// See https://openid.net/specs/openid-connect-frontchannel-1_0.html
+type ClientWithFrontChannelLogout interface {
+	// the frontchannel logout URI, empty if the client does not use it
+	FrontChannelLogoutURI() string
+
+	// whether `iss` and `sid` should be included with the logout URI
+	FrontChannelLogoutSessionRequired() bool
+}
+
+// A validated RP-initiated logout request.
+// See https://openid.net/specs/openid-connect-rpinitiated-1_0.html#RPLogout
+type LogoutRequest struct {
+	IDTokenHint string
+
+	// the client from the request or the audience of the ID token hint, empty
+	// if neither was sent.
+	ClientID string
+
+	PostLogoutRedirectURI string
+
+	State string
+
+	UILocales []string
+
+	// the `sub` and `sid` claims of the ID token hint, empty if no hint. The
+	// subject is the one the client sees, see SubjectIdentifierStrategy.
+	Subject   string
+	SessionID string
+}
+
+// the session that was ended by a logout request.
+type EndedSession struct {
+	UserID string
+
+	SessionID string
+
+	// the clients the user signed into during the session, these are sent
+	// logout notifications.
+	ClientIDs []string
+}
+
+// The hook into the authorization server's own sessions.
+type SessionManager interface {
+	// end the end-user's session for the logout request and return it. Return
+	// a nil session if the user was not signed in. Errors returned here are
+	// sent back as server errors.
+	EndSession(ctx context.Context, r *http.Request, req *LogoutRequest) (*EndedSession, error)
+}
+
+// what is shown to the end-user after their session ends.
+type LogoutPage struct {
+	// frontchannel logout URIs for each client in the session
+	FrontChannelLogoutURIs []string
+
+	// where to send the user after the logout page, empty if none
+	RedirectURI string
+}
+
+// renders the page shown after logout, the page must load each frontchannel
+// logout URI and, if set, send the user to the redirect URI afterwards.
+type LogoutPageRenderer func(w http.ResponseWriter, r *http.Request, page *LogoutPage) error
+
+type endSessionHandler struct {
+	issuer   string
+	clients  ClientRepository
+	keys     PublicKeySet
+	sessions SessionManager
+	render   LogoutPageRenderer
+	notifier BackChannelLogoutNotifier
+	onNotify func(ctx context.Context, session *EndedSession, err error)
+}
+
+type EndSessionOption func(*endSessionHandler)
+
+// send back-channel logout tokens when sessions end. Notifications are sent in
+// the background so slow clients do not hold up the logout page, onError is
+// called with the error from Notify if any client could not be notified. A nil
+// onError ignores failures.
+func WithBackChannelLogout(notifier BackChannelLogoutNotifier, onError func(ctx context.Context, session *EndedSession, err error)) EndSessionOption {
+	return func(h *endSessionHandler) {
+		h.notifier = notifier
+		h.onNotify = onError
+	}
+}
+
+func WithLogoutPageRenderer(render LogoutPageRenderer) EndSessionOption {
+	return func(h *endSessionHandler) {
+		h.render = render
+	}
+}
+
+// The OpenID Connect `end_session_endpoint`. ID token hints must be signed by
+// one of the keys in keys and issued by issuer.
+func NewEndSessionHandler(issuer string, clients ClientRepository, keys PublicKeySet, sessions SessionManager, opts ...EndSessionOption) http.Handler {
+	h := &endSessionHandler{
+		issuer:   issuer,
+		clients:  clients,
+		keys:     keys,
+		sessions: sessions,
+		render:   RenderLogoutPage,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+func (h *endSessionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		RespondWithError(w, OAuthError{
+			ErrorType:        ErrorTypeInvalidRequest,
+			ErrorDescription: "logout requests must be GET or POST requests",
+			StatusCode:       http.StatusMethodNotAllowed,
+			Cause:            ErrInvalidRequestMethod,
+		})
+		return
+	}
+
+	req, err := h.parseLogoutRequest(r)
+	if err != nil {
+		RespondWithError(w, *err)
+		return
+	}
+
+	session, sessionErr := h.sessions.EndSession(r.Context(), r, req)
+	if sessionErr != nil {
+		RespondWithError(w, *ServerError(sessionErr))
+		return
+	}
+
+	page := &LogoutPage{}
+	if req.PostLogoutRedirectURI != "" {
+		page.RedirectURI = postLogoutRedirect(req.PostLogoutRedirectURI, req.State)
+	}
+
+	if session != nil {
+		uris, err := h.frontChannelLogoutURIs(r.Context(), session)
+		if err != nil {
+			RespondWithError(w, *MaybeWrapError(err))
+			return
+		}
+		page.FrontChannelLogoutURIs = uris
+
+		if h.notifier != nil {
+			// the end-user leaving the logout page should not stop the notifications
+			go h.notify(context.WithoutCancel(r.Context()), session)
+		}
+	}
+
+	if len(page.FrontChannelLogoutURIs) == 0 && page.RedirectURI != "" {
+		redirect(w, page.RedirectURI)
+		return
+	}
+
+	h.render(w, r, page)
+}
+
+func (h *endSessionHandler) notify(ctx context.Context, session *EndedSession) {
+	if err := h.notifier.Notify(ctx, session); err != nil && h.onNotify != nil {
+		h.onNotify(ctx, session, err)
+	}
+}
+
+func (h *endSessionHandler) parseLogoutRequest(r *http.Request) (*LogoutRequest, *OAuthError) {
+	if err := r.ParseForm(); err != nil {
+		return nil, InvalidRequestWithCause(
+			fmt.Errorf("%w: %w", ErrCouldNotParseRequestBody, err),
+			ErrCouldNotParseRequestBody.Error(),
+		)
+	}
+
+	req := &LogoutRequest{
+		IDTokenHint:           r.Form.Get(ParamIDTokenHint),
+		ClientID:              r.Form.Get(ParamClientID),
+		PostLogoutRedirectURI: r.Form.Get(ParamPostLogoutRedirectURI),
+		State:                 r.Form.Get(ParamState),
+		UILocales:             ParseSpaceSeparatedParameter(r.Form.Get(ParamUILocales)),
+	}
+
+	if req.IDTokenHint != "" {
+		if err := h.verifyIDTokenHint(r.Context(), req); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.PostLogoutRedirectURI != "" {
+		if err := h.checkPostLogoutRedirectURI(r.Context(), req); err != nil {
+			return nil, err
+		}
+	}
+
+	return req, nil
+}
+
+// ID token hints may be expired, only the signature, issuer, and audience are
+// checked. See https://openid.net/specs/openid-connect-rpinitiated-1_0.html#RPLogout
+func (h *endSessionHandler) verifyIDTokenHint(ctx context.Context, req *LogoutRequest) *OAuthError {
+	keys, err := h.keys.PublicKeys(ctx)
+	if err != nil {
+		return ServerError(err)
+	}
+
+	var claims map[string]any
+	if err := VerifyJWT(req.IDTokenHint, keys, &claims); err != nil {
+		return InvalidRequestWithCause(fmt.Errorf("%w: %w", ErrInvalidIDTokenHint, err), ErrInvalidIDTokenHint.Error())
+	}
+
+	if claims["iss"] != h.issuer {
+		return InvalidRequestWithCause(fmt.Errorf("%w: bad issuer", ErrInvalidIDTokenHint), ErrInvalidIDTokenHint.Error())
+	}
+
+	if req.ClientID == "" {
+		req.ClientID = idTokenHintClientID(claims)
+	} else if !audienceContains(claims["aud"], req.ClientID) {
+		return InvalidRequestWithCause(ErrIDTokenHintClientMismatch, ErrIDTokenHintClientMismatch.Error())
+	}
+
+	req.Subject, _ = claims["sub"].(string)
+	req.SessionID, _ = claims[ParamSessionID].(string)
+
+	return nil
+}
+
+// the client the ID token was issued to: the authorized party, or the
+// audience when there is only one.
+func idTokenHintClientID(claims map[string]any) string {
+	if azp, ok := claims["azp"].(string); ok && azp != "" {
+		return azp
+	}
+
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud
+	case []any:
+		if len(aud) == 1 {
+			clientId, _ := aud[0].(string)
+			return clientId
+		}
+	}
+
+	return ""
+}
+
+func (h *endSessionHandler) checkPostLogoutRedirectURI(ctx context.Context, req *LogoutRequest) *OAuthError {
+	if req.ClientID == "" {
+		return InvalidRequestWithCause(ErrPostLogoutRedirectURIRequiresClient, ErrPostLogoutRedirectURIRequiresClient.Error())
+	}
+
+	client, err := GetClient(ctx, h.clients, req.ClientID)
+	if err != nil {
+		return err
+	}
+
+	allowed, ok := client.(ClientWithPostLogoutRedirectURIs)
+	if !ok || !slices.Contains(allowed.PostLogoutRedirectURIs(), req.PostLogoutRedirectURI) {
+		return InvalidRequestWithCause(ErrPostLogoutRedirectURINotAllowed, ErrPostLogoutRedirectURINotAllowed.Error())
+	}
+
+	return nil
+}
+
+func (h *endSessionHandler) frontChannelLogoutURIs(ctx context.Context, session *EndedSession) ([]string, error) {
+	var uris []string
+	for _, clientId := range session.ClientIDs {
+		client, err := h.clients.Get(ctx, clientId)
+		if err != nil {
+			return nil, err
+		}
+
+		frontChannel, ok := client.(ClientWithFrontChannelLogout)
+		if !ok || frontChannel.FrontChannelLogoutURI() == "" {
+			continue
+		}
+
+		u, err := url.Parse(frontChannel.FrontChannelLogoutURI())
+		if err != nil {
+			return nil, err
+		}
+		if frontChannel.FrontChannelLogoutSessionRequired() && session.SessionID != "" {
+			q := u.Query()
+			q.Set(ParamIssuer, h.issuer)
+			q.Set(ParamSessionID, session.SessionID)
+			u.RawQuery = q.Encode()
+		}
+
+		uris = append(uris, u.String())
+	}
+
+	return uris, nil
+}
+
+func postLogoutRedirect(redirectUri string, state string) string {
+	if state == "" {
+		return redirectUri
+	}
+
+	u, err := url.Parse(redirectUri)
+	if err != nil {
+		return redirectUri
+	}
+	q := u.Query()
+	q.Set(ParamState, state)
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+var logoutPageTemplate = template.Must(template.New("logout").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Signed Out</title>
+{{- if .RedirectURI }}
+<script>
+window.addEventListener("load", function () { window.location.replace({{ .RedirectURI }}); });
+</script>
+{{- end }}
+</head>
+<body>
+<p>You have been signed out.</p>
+{{- range .FrontChannelLogoutURIs }}
+<iframe src="{{ . }}" style="display:none"></iframe>
+{{- end }}
+{{- if .RedirectURI }}
+<noscript><a href="{{ .RedirectURI }}">Continue</a></noscript>
+{{- end }}
+</body>
+</html>
+`))
+
+// the default logout page, the redirect happens once every frontchannel logout
+// iframe has loaded.
+func RenderLogoutPage(w http.ResponseWriter, r *http.Request, page *LogoutPage) error {
+	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	return logoutPageTemplate.Execute(w, page)
+}
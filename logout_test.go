@@ -0,0 +1,268 @@
+package oauth2server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+const testPostLogoutRedirectUri = "https://client.example.com/signed-out"
+
+type logoutTestClient struct {
+	oauth2server.Client
+	postLogoutRedirectUris []string
+	frontChannelLogoutUri  string
+	sessionRequired        bool
+}
+
+func (c *logoutTestClient) PostLogoutRedirectURIs() []string {
+	return c.postLogoutRedirectUris
+}
+
+func (c *logoutTestClient) FrontChannelLogoutURI() string {
+	return c.frontChannelLogoutUri
+}
+
+func (c *logoutTestClient) FrontChannelLogoutSessionRequired() bool {
+	return c.sessionRequired
+}
+
+type spySessionManager struct {
+	endSessionCalls  []*oauth2server.LogoutRequest
+	endSessionReturn *oauth2server.EndedSession
+	endSessionError  error
+}
+
+func (s *spySessionManager) EndSession(ctx context.Context, r *http.Request, req *oauth2server.LogoutRequest) (*oauth2server.EndedSession, error) {
+	s.endSessionCalls = append(s.endSessionCalls, req)
+	return s.endSessionReturn, s.endSessionError
+}
+
+type logoutTestCase struct {
+	clients  *oauth2server.InMemoryClientRepository
+	sessions *spySessionManager
+	handler  http.Handler
+}
+
+func startLogoutTest(t *testing.T) *logoutTestCase {
+	t.Helper()
+
+	clients := oauth2server.NewInMemoryClientRepository()
+	clients.Add(&logoutTestClient{
+		Client:                 oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}),
+		postLogoutRedirectUris: []string{testPostLogoutRedirectUri},
+	})
+	sessions := &spySessionManager{}
+	keys := oauth2server.NewStaticKeySet(newTestSigningKey()).(oauth2server.PublicKeySet)
+
+	return &logoutTestCase{
+		clients:  clients,
+		sessions: sessions,
+		handler:  oauth2server.NewEndSessionHandler(testOIDCIssuer, clients, keys, sessions),
+	}
+}
+
+func (tc *logoutTestCase) serve(params url.Values) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	tc.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/logout?"+params.Encode(), nil))
+
+	return rec
+}
+
+func newTestIDTokenHint(t *testing.T, claims map[string]any) string {
+	t.Helper()
+
+	token, err := oauth2server.SignJWT(newTestSigningKey(), claims)
+	if err != nil {
+		t.Fatalf("could not sign ID token hint: %v", err)
+	}
+
+	return token
+}
+
+func TestEndSessionHandler_RedirectsToPostLogoutRedirectURIWithState(t *testing.T) {
+	tc := startLogoutTest(t)
+
+	rec := tc.serve(url.Values{
+		oauth2server.ParamClientID:              {testClientId},
+		oauth2server.ParamPostLogoutRedirectURI: {testPostLogoutRedirectUri},
+		oauth2server.ParamState:                 {"abc"},
+	})
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected redirect, got %d %s", rec.Code, rec.Body.String())
+	}
+	if loc := rec.Header().Get("Location"); loc != testPostLogoutRedirectUri+"?state=abc" {
+		t.Errorf("unexpected redirect location: %s", loc)
+	}
+	if len(tc.sessions.endSessionCalls) != 1 {
+		t.Errorf("expected session to be ended once, got %d calls", len(tc.sessions.endSessionCalls))
+	}
+}
+
+func TestEndSessionHandler_RejectsUnregisteredPostLogoutRedirectURI(t *testing.T) {
+	tc := startLogoutTest(t)
+
+	rec := tc.serve(url.Values{
+		oauth2server.ParamClientID:              {testClientId},
+		oauth2server.ParamPostLogoutRedirectURI: {"https://evil.example.com"},
+	})
+
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "not registered") {
+		t.Errorf("expected invalid_request, got %d %s", rec.Code, rec.Body.String())
+	}
+	if len(tc.sessions.endSessionCalls) != 0 {
+		t.Error("expected session to not be ended for invalid requests")
+	}
+}
+
+func TestEndSessionHandler_RequiresClientForPostLogoutRedirectURI(t *testing.T) {
+	tc := startLogoutTest(t)
+
+	rec := tc.serve(url.Values{
+		oauth2server.ParamPostLogoutRedirectURI: {testPostLogoutRedirectUri},
+	})
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected invalid_request, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestEndSessionHandler_UsesIDTokenHint(t *testing.T) {
+	tc := startLogoutTest(t)
+	hint := newTestIDTokenHint(t, map[string]any{
+		"iss": testOIDCIssuer,
+		"aud": testClientId,
+		"sub": "user",
+		"sid": "session-1",
+		"exp": 1,
+	})
+
+	rec := tc.serve(url.Values{
+		oauth2server.ParamIDTokenHint:           {hint},
+		oauth2server.ParamPostLogoutRedirectURI: {testPostLogoutRedirectUri},
+	})
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected expired hints to be accepted, got %d %s", rec.Code, rec.Body.String())
+	}
+	req := tc.sessions.endSessionCalls[0]
+	if req.ClientID != testClientId || req.Subject != "user" || req.SessionID != "session-1" {
+		t.Errorf("expected request details from the hint, got %+v", req)
+	}
+}
+
+func TestEndSessionHandler_FindsClientFromIDTokenHintAudienceArrays(t *testing.T) {
+	cases := []struct {
+		name   string
+		claims map[string]any
+	}{
+		{"single audience", map[string]any{"aud": []string{testClientId}}},
+		{"authorized party", map[string]any{"aud": []string{testClientId, "api"}, "azp": testClientId}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tc := startLogoutTest(t)
+			c.claims["iss"] = testOIDCIssuer
+			hint := newTestIDTokenHint(t, c.claims)
+
+			rec := tc.serve(url.Values{
+				oauth2server.ParamIDTokenHint:           {hint},
+				oauth2server.ParamPostLogoutRedirectURI: {testPostLogoutRedirectUri},
+			})
+
+			if rec.Code != http.StatusFound {
+				t.Fatalf("expected a redirect, got %d %s", rec.Code, rec.Body.String())
+			}
+			if req := tc.sessions.endSessionCalls[0]; req.ClientID != testClientId {
+				t.Errorf("expected the client from the hint, got %q", req.ClientID)
+			}
+		})
+	}
+}
+
+func TestEndSessionHandler_RejectsBadIDTokenHints(t *testing.T) {
+	cases := []struct {
+		name   string
+		params func(t *testing.T) url.Values
+	}{
+		{"malformed", func(t *testing.T) url.Values {
+			return url.Values{oauth2server.ParamIDTokenHint: {"nope"}}
+		}},
+		{"other issuer", func(t *testing.T) url.Values {
+			hint := newTestIDTokenHint(t, map[string]any{"iss": "https://other.example.com", "aud": testClientId})
+			return url.Values{oauth2server.ParamIDTokenHint: {hint}}
+		}},
+		{"other client", func(t *testing.T) url.Values {
+			hint := newTestIDTokenHint(t, map[string]any{"iss": testOIDCIssuer, "aud": "other"})
+			return url.Values{oauth2server.ParamIDTokenHint: {hint}, oauth2server.ParamClientID: {testClientId}}
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tc := startLogoutTest(t)
+
+			rec := tc.serve(c.params(t))
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("expected invalid_request, got %d %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestEndSessionHandler_RendersFrontChannelLogoutIframes(t *testing.T) {
+	tc := startLogoutTest(t)
+	tc.clients.Add(&logoutTestClient{
+		Client:                oauth2server.NewSimpleClient("withsession", "secret", nil),
+		frontChannelLogoutUri: "https://a.example.com/logout",
+		sessionRequired:       true,
+	})
+	tc.clients.Add(&logoutTestClient{
+		Client:                oauth2server.NewSimpleClient("nosession", "secret", nil),
+		frontChannelLogoutUri: "https://b.example.com/logout",
+	})
+	tc.sessions.endSessionReturn = &oauth2server.EndedSession{
+		UserID:    "user",
+		SessionID: "session-1",
+		ClientIDs: []string{"withsession", "nosession", testClientId},
+	}
+
+	rec := tc.serve(url.Values{
+		oauth2server.ParamClientID:              {testClientId},
+		oauth2server.ParamPostLogoutRedirectURI: {testPostLogoutRedirectUri},
+	})
+
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected logout page, got %d", rec.Code)
+	}
+	for _, expected := range []string{
+		`src="https://a.example.com/logout?iss=https%3A%2F%2Fid.example.com&amp;sid=session-1"`,
+		`src="https://b.example.com/logout"`,
+		testPostLogoutRedirectUri,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected page to contain %s, got:\n%s", expected, body)
+		}
+	}
+}
+
+func TestEndSessionHandler_ErrorsIfSessionCannotBeEnded(t *testing.T) {
+	tc := startLogoutTest(t)
+	tc.sessions.endSessionError = errors.New("oops")
+
+	rec := tc.serve(url.Values{})
+
+	if !strings.Contains(rec.Body.String(), oauth2server.ErrorTypeServerError) {
+		t.Errorf("expected server error, got %d %s", rec.Code, rec.Body.String())
+	}
+}
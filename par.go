@@ -0,0 +1,100 @@
+package oauth2server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Pushed Authorization Requests, see https://datatracker.ietf.org/doc/html/rfc9126
+const (
+	// prefix for request URIs generated for pushed authorization requests
+	RequestURIPrefix = "urn:ietf:params:oauth:request_uri:"
+
+	DefaultPushedAuthorizationRequestLifetime = time.Minute
+)
+
+// the response from the pushed authorization request endpoint
+type PushedAuthorizationResponse struct {
+	RequestURI string `json:"request_uri"`
+	ExpiresIn  int    `json:"expires_in"`
+}
+
+// extension point to let clients require that their authorization requests
+// are pushed.
+type ClientRequiresPushedAuthorizationRequests interface {
+	RequiresPushedAuthorizationRequests() bool
+}
+
+// storage for validated, pushed authorization requests.
+type PushedAuthorizationRequestRepository interface {
+	// store the authorization request so it can be found by its request URI
+	// until expiresAt.
+	Save(ctx context.Context, requestUri string, req *AuthorizationRequest, expiresAt time.Time) error
+
+	// Get the authorization request for the request URI and remove it, pushed
+	// requests may only be used once. Return a `nil` request, and leave it in
+	// place, if it was pushed by a client other than clientId. Return a `nil`
+	// request if it's not found or expired. Any errors returned here will be
+	// propagated as server errors.
+	Consume(ctx context.Context, clientId string, requestUri string) (*AuthorizationRequest, error)
+}
+
+type inMemoryPushedAuthorizationRequest struct {
+	req       *AuthorizationRequest
+	expiresAt time.Time
+}
+
+type InMemoryPushedAuthorizationRequestRepository struct {
+	lock     sync.Mutex
+	requests map[string]inMemoryPushedAuthorizationRequest
+}
+
+func NewInMemoryPushedAuthorizationRequestRepository() *InMemoryPushedAuthorizationRequestRepository {
+	return &InMemoryPushedAuthorizationRequestRepository{
+		requests: make(map[string]inMemoryPushedAuthorizationRequest),
+	}
+}
+
+func (r *InMemoryPushedAuthorizationRequestRepository) Save(ctx context.Context, requestUri string, req *AuthorizationRequest, expiresAt time.Time) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// requests that are pushed but never used would otherwise stay forever
+	now := time.Now()
+	for uri, pushed := range r.requests {
+		if now.After(pushed.expiresAt) {
+			delete(r.requests, uri)
+		}
+	}
+
+	r.requests[requestUri] = inMemoryPushedAuthorizationRequest{
+		req:       req,
+		expiresAt: expiresAt,
+	}
+
+	return nil
+}
+
+func (r *InMemoryPushedAuthorizationRequestRepository) Consume(ctx context.Context, clientId string, requestUri string) (*AuthorizationRequest, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	pushed, ok := r.requests[requestUri]
+	if !ok || pushed.req.ClientID != clientId {
+		return nil, nil
+	}
+	delete(r.requests, requestUri)
+
+	if time.Now().After(pushed.expiresAt) {
+		return nil, nil
+	}
+
+	return pushed.req, nil
+}
+
+// https://datatracker.ietf.org/doc/html/rfc9126#section-2.2 uses a 201 response
+func RespondWithPushedAuthorizationResponse(w http.ResponseWriter, resp *PushedAuthorizationResponse) error {
+	return jsonResponse(w, http.StatusCreated, resp)
+}
@@ -0,0 +1,295 @@
+package oauth2server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+type parClient struct {
+	oauth2server.Client
+}
+
+func (c *parClient) RequiresPushedAuthorizationRequests() bool {
+	return true
+}
+
+func startPARTest(t *testing.T, opts ...oauth2server.ServerOption) (*authorizationServerTestCase, *oauth2server.InMemoryPushedAuthorizationRequestRepository) {
+	t.Helper()
+
+	repo := oauth2server.NewInMemoryPushedAuthorizationRequestRepository()
+	opts = append(
+		opts,
+		oauth2server.WithAuthorizationHandler(&spyAuthorizationHandler{responseType: "code"}),
+		oauth2server.WithPushedAuthorizationRequests(repo, 0),
+	)
+	tc := startAuthorizationServerTest(t, opts...)
+
+	return tc, repo
+}
+
+func newPushedAuthorizationRequest(body map[string]string) *http.Request {
+	req := createRequestWithFormBody(http.MethodPost, "/par", body)
+	req.SetBasicAuth(testClientId, testClientSecret)
+
+	return req
+}
+
+func TestInMemoryPushedAuthorizationRequestRepository_RequestsCanOnlyBeConsumedOnce(t *testing.T) {
+	repo := oauth2server.NewInMemoryPushedAuthorizationRequestRepository()
+	req := &oauth2server.AuthorizationRequest{ClientID: testClientId}
+
+	if err := repo.Save(context.Background(), "uri", req, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	found, err := repo.Consume(context.Background(), testClientId, "uri")
+	if err != nil || found != req {
+		t.Errorf("expected stored request, got %v %v", found, err)
+	}
+
+	found, err = repo.Consume(context.Background(), testClientId, "uri")
+	if err != nil || found != nil {
+		t.Errorf("expected no request on second consume, got %v %v", found, err)
+	}
+}
+
+func TestInMemoryPushedAuthorizationRequestRepository_OtherClientsCannotConsumeRequests(t *testing.T) {
+	repo := oauth2server.NewInMemoryPushedAuthorizationRequestRepository()
+	req := &oauth2server.AuthorizationRequest{ClientID: testClientId}
+	repo.Save(context.Background(), "uri", req, time.Now().Add(time.Minute))
+
+	found, err := repo.Consume(context.Background(), "other", "uri")
+	if err != nil || found != nil {
+		t.Errorf("expected no request for another client, got %v %v", found, err)
+	}
+
+	found, err = repo.Consume(context.Background(), testClientId, "uri")
+	if err != nil || found != req {
+		t.Errorf("expected the request to still be stored, got %v %v", found, err)
+	}
+}
+
+func TestInMemoryPushedAuthorizationRequestRepository_ExpiredRequestsAreNotReturned(t *testing.T) {
+	repo := oauth2server.NewInMemoryPushedAuthorizationRequestRepository()
+	repo.Save(context.Background(), "uri", &oauth2server.AuthorizationRequest{ClientID: testClientId}, time.Now().Add(-time.Second))
+
+	found, err := repo.Consume(context.Background(), testClientId, "uri")
+
+	if err != nil || found != nil {
+		t.Errorf("expected no request once expired, got %v %v", found, err)
+	}
+}
+
+func TestRespondWithPushedAuthorizationResponse_SendsCreatedResponse(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	err := oauth2server.RespondWithPushedAuthorizationResponse(rec, &oauth2server.PushedAuthorizationResponse{
+		RequestURI: "urn:example",
+		ExpiresIn:  60,
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Errorf("expected a %d response, got %d", http.StatusCreated, rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"request_uri":"urn:example"`) {
+		t.Errorf("bad response body: %s", rec.Body.String())
+	}
+}
+
+func TestDefaultAuthorizationServer_PushAuthorizationRequest_ErrorsIfNotConfigured(t *testing.T) {
+	tc := startAuthorizationServerTest(t)
+
+	resp, err := tc.server.PushAuthorizationRequest(context.Background(), newPushedAuthorizationRequest(map[string]string{}))
+
+	if resp != nil {
+		t.Errorf("expected nil response, got %+v", resp)
+	}
+	if !errors.Is(err, oauth2server.ErrPushedAuthorizationRequestsNotSet) {
+		t.Errorf("expected ErrPushedAuthorizationRequestsNotSet, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_PushAuthorizationRequest_ErrorsIfClientCannotAuthenticate(t *testing.T) {
+	tc, _ := startPARTest(t)
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, "other", []string{testRedirectUri}))
+
+	resp, err := tc.server.PushAuthorizationRequest(context.Background(), newPushedAuthorizationRequest(map[string]string{
+		oauth2server.ParamResponseType: "code",
+	}))
+
+	if resp != nil {
+		t.Errorf("expected nil response, got %+v", resp)
+	}
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeInvalidClient {
+		t.Errorf("expected an invalid_client error, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_PushAuthorizationRequest_ErrorsIfRequestURIIsIncluded(t *testing.T) {
+	tc, _ := startPARTest(t)
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+
+	_, err := tc.server.PushAuthorizationRequest(context.Background(), newPushedAuthorizationRequest(map[string]string{
+		oauth2server.ParamResponseType: "code",
+		oauth2server.ParamRequestURI:   "urn:nope",
+	}))
+
+	if !errors.Is(err, oauth2server.ErrRequestURINotAllowed) {
+		t.Errorf("expected ErrRequestURINotAllowed, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_PushAuthorizationRequest_ValidatesTheAuthorizationRequest(t *testing.T) {
+	tc, _ := startPARTest(t)
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+
+	_, err := tc.server.PushAuthorizationRequest(context.Background(), newPushedAuthorizationRequest(map[string]string{
+		oauth2server.ParamResponseType: "code",
+		oauth2server.ParamRedirectURI:  "https://other.example.com",
+	}))
+
+	if !errors.Is(err, oauth2server.ErrClientInvalidRedirectURI) {
+		t.Errorf("expected ErrClientInvalidRedirectURI, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_PushedRequestsCanBeUsedOnceForAuthorization(t *testing.T) {
+	tc, _ := startPARTest(t)
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+
+	resp, err := tc.server.PushAuthorizationRequest(context.Background(), newPushedAuthorizationRequest(map[string]string{
+		oauth2server.ParamResponseType: "code",
+		oauth2server.ParamState:        "abc123",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(resp.RequestURI, oauth2server.RequestURIPrefix) {
+		t.Errorf("bad request URI: %q", resp.RequestURI)
+	}
+	if resp.ExpiresIn != int(oauth2server.DefaultPushedAuthorizationRequestLifetime.Seconds()) {
+		t.Errorf("bad expires in: %d", resp.ExpiresIn)
+	}
+
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamClientID:   testClientId,
+		oauth2server.ParamRequestURI: resp.RequestURI,
+	})
+	authReq, authErr := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+	if authErr != nil {
+		t.Fatalf("unexpected error: %v", authErr)
+	}
+	if authReq.State != "abc123" || authReq.FinalRedirectURI != testRedirectUri {
+		t.Errorf("expected the pushed request to be used, got %+v", authReq)
+	}
+	if authReq.RequestURI != resp.RequestURI {
+		t.Errorf("bad request URI: %q != %q", authReq.RequestURI, resp.RequestURI)
+	}
+
+	req = newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamClientID:   testClientId,
+		oauth2server.ParamRequestURI: resp.RequestURI,
+	})
+	authReq, authErr = tc.server.ValidateAuthorizationRequest(req.Context(), req)
+	tc.assertNilAuthRequest(t, authReq)
+	if !errors.Is(authErr, oauth2server.ErrInvalidRequestURI) {
+		t.Errorf("expected ErrInvalidRequestURI on reuse, got %v", authErr)
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_ErrorsIfRequestURIBelongsToAnotherClient(t *testing.T) {
+	tc, repo := startPARTest(t)
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	requestUri := oauth2server.RequestURIPrefix + "abc"
+	repo.Save(context.Background(), requestUri, &oauth2server.AuthorizationRequest{
+		ClientID:     "other",
+		ResponseType: []string{"code"},
+	}, time.Now().Add(time.Minute))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamClientID:   testClientId,
+		oauth2server.ParamRequestURI: requestUri,
+	})
+
+	authReq, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	tc.assertNilAuthRequest(t, authReq)
+	if !errors.Is(err, oauth2server.ErrInvalidRequestURI) {
+		t.Errorf("expected ErrInvalidRequestURI, got %v", err)
+	}
+	if pushed, _ := repo.Consume(context.Background(), "other", requestUri); pushed == nil {
+		t.Error("expected the pushed request to be left for the client that pushed it")
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_ErrorsIfPushedRequestsAreRequiredByServer(t *testing.T) {
+	tc, _ := startPARTest(t, oauth2server.WithRequirePushedAuthorizationRequests())
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamClientID:     testClientId,
+		oauth2server.ParamResponseType: "code",
+	})
+
+	authReq, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	tc.assertNilAuthRequest(t, authReq)
+	if !errors.Is(err, oauth2server.ErrPushedAuthorizationRequired) {
+		t.Errorf("expected ErrPushedAuthorizationRequired, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_ErrorsIfPushedRequestsAreRequiredByClient(t *testing.T) {
+	tc, _ := startPARTest(t)
+	tc.clients.Add(&parClient{
+		Client: oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}),
+	})
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamClientID:     testClientId,
+		oauth2server.ParamResponseType: "code",
+	})
+
+	authReq, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	tc.assertNilAuthRequest(t, authReq)
+	if !errors.Is(err, oauth2server.ErrPushedAuthorizationRequired) {
+		t.Errorf("expected ErrPushedAuthorizationRequired, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_PushAuthorizationRequest_DropsClientSecretFromTheRequest(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		opts := []oauth2server.ServerOption{oauth2server.WithRequestObjects(testIssuer, nil)}
+		if strict {
+			opts = append(opts, oauth2server.WithStrictRequestObjects())
+		}
+		tc, repo := startPARTest(t, opts...)
+		tc.clients.Add(&keyedClient{
+			Client: oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}),
+			keys:   testJSONWebKeys(),
+		})
+		req := createRequestWithFormBody(http.MethodPost, "/par", map[string]string{
+			oauth2server.ParamClientID:     testClientId,
+			oauth2server.ParamClientSecret: testClientSecret,
+			oauth2server.ParamRequest:      newTestRequestObject(t, nil),
+		})
+
+		resp, err := tc.server.PushAuthorizationRequest(context.Background(), req)
+
+		if err != nil {
+			t.Fatalf("strict=%v: unexpected error: %v", strict, err)
+		}
+		pushed, _ := repo.Consume(context.Background(), testClientId, resp.RequestURI)
+		if pushed == nil || pushed.QueryString.Has(oauth2server.ParamClientSecret) {
+			t.Errorf("strict=%v: expected the client secret to be dropped, got %+v", strict, pushed)
+		}
+	}
+}
@@ -5,16 +5,40 @@ import (
 )
 
 const (
-	ParamClientID            = "client_id"
-	ParamClientSecret        = "client_secret"
-	ParamGrantType           = "grant_type"
-	ParamRedirectURI         = "redirect_uri"
-	ParamState               = "state"
-	ParamScope               = "scope"
-	ParamCodeChallenge       = "code_challenge"
-	ParamCodeChallengeMethod = "code_challenge_method"
-	ParamCodeVerifier        = "code_verifier"
-	ParamResponseType        = "response_type"
+	ParamClientID              = "client_id"
+	ParamClientSecret          = "client_secret"
+	ParamGrantType             = "grant_type"
+	ParamRedirectURI           = "redirect_uri"
+	ParamState                 = "state"
+	ParamScope                 = "scope"
+	ParamCodeChallenge         = "code_challenge"
+	ParamCodeChallengeMethod   = "code_challenge_method"
+	ParamCodeVerifier          = "code_verifier"
+	ParamResponseType          = "response_type"
+	ParamResponseMode          = "response_mode"
+	ParamRequestURI            = "request_uri"
+	ParamCode                  = "code"
+	ParamToken                 = "token"
+	ParamRefreshToken          = "refresh_token"
+	ParamResource              = "resource"
+	ParamNonce                 = "nonce"
+	ParamPrompt                = "prompt"
+	ParamMaxAge                = "max_age"
+	ParamLoginHint             = "login_hint"
+	ParamIDTokenHint           = "id_token_hint"
+	ParamACRValues             = "acr_values"
+	ParamUILocales             = "ui_locales"
+	ParamDisplay               = "display"
+	ParamPostLogoutRedirectURI = "post_logout_redirect_uri"
+	ParamIssuer                = "iss"
+	ParamSessionID             = "sid"
+	ParamLogoutToken           = "logout_token"
+	ParamSectorIdentifierURI   = "sector_identifier_uri"
+	ParamClaims                = "claims"
+	ParamIncludeGrantedScopes  = "include_granted_scopes"
+	ParamError                 = "error"
+	ParamErrorDescription      = "error_description"
+	ParamErrorURI              = "error_uri"
 
 	spaceSeparator = " "
 )
@@ -0,0 +1,20 @@
+package oauth2server
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// the number of random bytes in tokens and other identifiers generated by the
+// server, 256 bits.
+const randomTokenBytes = 32
+
+// generate a random, URL safe, opaque string
+func generateRandomToken() (string, error) {
+	b := make([]byte, randomTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,304 @@
+package oauth2server
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	GrantTypeRefreshToken = "refresh_token"
+)
+
+// an issued refresh token and everything that was originally granted with it.
+type RefreshToken struct {
+	Token string
+
+	ClientID string
+
+	UserID string
+
+	Scope []string
+
+	// all resources that were granted, access tokens may be issued for any
+	// subset of these.
+	Resource []string
+
+	AuthorizationDetails []AuthorizationDetail
+
+	// the claims requested with the `claims` parameter, nil if none. These
+	// apply to every access token issued with the refresh token.
+	Claims *ClaimsRequest
+
+	// the AuthorizationRecord the token was issued under, empty if none
+	AuthorizationID string
+
+	IssuedAt time.Time
+
+	ExpiresAt time.Time
+}
+
+// A storage backend for refresh tokens
+type RefreshTokenRepository interface {
+	// store a newly issued refresh token
+	Create(ctx context.Context, token *RefreshToken) error
+
+	// Get a single refresh token by its value, return a `nil` token if not
+	// found or expired.
+	Get(ctx context.Context, token string) (*RefreshToken, error)
+
+	// remove every token issued under the authorization record, the ID is
+	// never empty.
+	RevokeAuthorization(ctx context.Context, authorizationId string) error
+
+	// every unexpired token issued on behalf of the user
+	ListForUser(ctx context.Context, userId string) ([]*RefreshToken, error)
+
+	// remove every token issued to the client on behalf of the user, an empty
+	// client ID removes the user's tokens for every client.
+	RevokeForUser(ctx context.Context, userId string, clientId string) error
+}
+
+type InMemoryRefreshTokenRepository struct {
+	lock   sync.RWMutex
+	tokens map[string]*RefreshToken
+}
+
+func NewInMemoryRefreshTokenRepository() *InMemoryRefreshTokenRepository {
+	return &InMemoryRefreshTokenRepository{
+		tokens: make(map[string]*RefreshToken),
+	}
+}
+
+func (r *InMemoryRefreshTokenRepository) Create(ctx context.Context, token *RefreshToken) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.tokens[token.Token] = token
+
+	return nil
+}
+
+func (r *InMemoryRefreshTokenRepository) Get(ctx context.Context, token string) (*RefreshToken, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	t, ok := r.tokens[token]
+	if !ok || !time.Now().Before(t.ExpiresAt) {
+		return nil, nil
+	}
+
+	return t, nil
+}
+
+func (r *InMemoryRefreshTokenRepository) RevokeAuthorization(ctx context.Context, authorizationId string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for value, token := range r.tokens {
+		if token.AuthorizationID == authorizationId {
+			delete(r.tokens, value)
+		}
+	}
+
+	return nil
+}
+
+func (r *InMemoryRefreshTokenRepository) ListForUser(ctx context.Context, userId string) ([]*RefreshToken, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	now := time.Now()
+	var tokens []*RefreshToken
+	for _, token := range r.tokens {
+		if token.UserID == userId && now.Before(token.ExpiresAt) {
+			tokens = append(tokens, token)
+		}
+	}
+
+	return tokens, nil
+}
+
+func (r *InMemoryRefreshTokenRepository) RevokeForUser(ctx context.Context, userId string, clientId string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for value, token := range r.tokens {
+		if token.UserID == userId && (clientId == "" || token.ClientID == clientId) {
+			delete(r.tokens, value)
+		}
+	}
+
+	return nil
+}
+
+type refreshTokenGrant struct {
+	clients       ClientRepository
+	refreshTokens RefreshTokenRepository
+	issuer        TokenIssuer
+	records       AuthorizationRecordRepository
+	idTokens      IDTokenIssuer
+}
+
+type RefreshTokenGrantOption func(*refreshTokenGrant)
+
+// refresh tokens issued under an authorization record are limited to what is
+// in the record rather than what was granted with the token, so access tokens
+// include anything the user granted the client since. Refresh tokens whose
+// record was removed are rejected.
+func WithRefreshTokenAuthorizationRecords(records AuthorizationRecordRepository) RefreshTokenGrantOption {
+	return func(g *refreshTokenGrant) {
+		g.records = records
+	}
+}
+
+// issue a new ID token with the access token when the refresh token was
+// granted the `openid` scope, the claims requested with the `claims` parameter
+// are honoured again.
+func WithRefreshTokenIDTokens(idTokens IDTokenIssuer) RefreshTokenGrantOption {
+	return func(g *refreshTokenGrant) {
+		g.idTokens = idTokens
+	}
+}
+
+// The refresh token grant. Requests may narrow the scope and resources of the
+// new access token, but never expand past what was originally granted.
+func NewRefreshTokenGrant(clients ClientRepository, refreshTokens RefreshTokenRepository, issuer TokenIssuer, opts ...RefreshTokenGrantOption) Grant {
+	g := &refreshTokenGrant{
+		clients:       clients,
+		refreshTokens: refreshTokens,
+		issuer:        issuer,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+func (g *refreshTokenGrant) GrantType() string {
+	return GrantTypeRefreshToken
+}
+
+func (g *refreshTokenGrant) Token(ctx context.Context, req *AccessTokenRequest) (*AccessTokenResponse, error) {
+	client, authErr := AuthenticateClient(ctx, g.clients, req.ClientID, req.ClientSecret)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	if allows, ok := client.(ClientAllowsGrantType); ok && !allows.AllowsGrantType(GrantTypeRefreshToken) {
+		return nil, &OAuthError{
+			ErrorType:        ErrorTypeUnauthorizedClient,
+			ErrorDescription: "client may not use the refresh_token grant",
+			Cause:            ErrGrantTypeNotAllowed,
+		}
+	}
+
+	tokenValue := req.Param(ParamRefreshToken)
+	if tokenValue == "" {
+		return nil, MissingRequestParameterWithCause(ErrMissingRefreshToken, ParamRefreshToken)
+	}
+
+	refreshToken, err := g.refreshTokens.Get(ctx, tokenValue)
+	if err != nil {
+		return nil, err
+	}
+
+	if refreshToken == nil {
+		return nil, InvalidGrantWithCause(ErrInvalidRefreshToken, ErrInvalidRefreshToken.Error())
+	}
+
+	if refreshToken.ClientID != client.ID() {
+		return nil, InvalidGrantWithCause(ErrRefreshTokenClientMismatch, ErrInvalidRefreshToken.Error())
+	}
+
+	granted, err := g.granted(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// https://datatracker.ietf.org/doc/html/rfc6749#section-6
+	scope := granted.Scope
+	if requested := normalizeScopes(req.scopeValidator, ParseSpaceSeparatedParameter(req.Param(ParamScope))); len(requested) > 0 {
+		if !isSubset(requested, granted.Scope) {
+			e := InvalidScope(requested)
+			e.Cause = ErrScopeNotGranted
+			return nil, e
+		}
+		scope = requested
+	}
+
+	if scopeErr := req.ValidateScopes(ctx, client, refreshToken.UserID, scope); scopeErr != nil {
+		return nil, scopeErr
+	}
+
+	audience, targetErr := tokenAudience(req.Resource, granted.Resource)
+	if targetErr != nil {
+		return nil, targetErr
+	}
+
+	details := granted.AuthorizationDetails
+	if len(req.AuthorizationDetails) > 0 {
+		if !authorizationDetailsSubset(req.AuthorizationDetails, granted.AuthorizationDetails) {
+			return nil, InvalidAuthorizationDetailsWithCause(
+				ErrAuthorizationDetailsNotGranted,
+				ErrAuthorizationDetailsNotGranted.Error(),
+			)
+		}
+		details = req.AuthorizationDetails
+	}
+
+	resp, err := g.issuer.IssueAccessToken(ctx, &IssueTokenRequest{
+		Client:               client,
+		UserID:               refreshToken.UserID,
+		Scope:                scope,
+		Resource:             granted.Resource,
+		Audience:             audience,
+		AuthorizationDetails: details,
+		Claims:               refreshToken.Claims,
+		AuthorizationID:      refreshToken.AuthorizationID,
+		RefreshToken:         refreshToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// https://openid.net/specs/openid-connect-core-1_0.html#RefreshTokenResponse
+	if g.idTokens != nil && slices.Contains(granted.Scope, ScopeOpenID) {
+		resp.IDToken, err = g.idTokens.IssueIDToken(ctx, &IDTokenRequest{
+			Client:      client,
+			UserID:      refreshToken.UserID,
+			Scope:       scope,
+			Claims:      refreshToken.Claims,
+			AccessToken: resp.AccessToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// what access tokens issued with the refresh token may include.
+func (g *refreshTokenGrant) granted(ctx context.Context, refreshToken *RefreshToken) (*AuthorizationRecord, error) {
+	if g.records == nil || refreshToken.AuthorizationID == "" {
+		return &AuthorizationRecord{
+			Scope:                refreshToken.Scope,
+			Resource:             refreshToken.Resource,
+			AuthorizationDetails: refreshToken.AuthorizationDetails,
+		}, nil
+	}
+
+	record, err := g.records.Get(ctx, refreshToken.AuthorizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if record == nil {
+		return nil, InvalidGrantWithCause(ErrAuthorizationRevoked, ErrInvalidRefreshToken.Error())
+	}
+
+	return record, nil
+}
@@ -0,0 +1,144 @@
+package oauth2server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+type refreshTokenTestCase struct {
+	refreshTokens *oauth2server.InMemoryRefreshTokenRepository
+	tokens        *oauth2server.InMemoryAccessTokenRepository
+	issuer        oauth2server.TokenIssuer
+	grant         oauth2server.Grant
+}
+
+func startRefreshTokenTest(t *testing.T) *refreshTokenTestCase {
+	t.Helper()
+
+	clients := oauth2server.NewInMemoryClientRepository()
+	clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	refreshTokens := oauth2server.NewInMemoryRefreshTokenRepository()
+	tokens := oauth2server.NewInMemoryAccessTokenRepository()
+	issuer := oauth2server.NewTokenIssuer(tokens, oauth2server.WithRefreshTokens(refreshTokens, 0))
+
+	return &refreshTokenTestCase{
+		refreshTokens: refreshTokens,
+		tokens:        tokens,
+		issuer:        issuer,
+		grant:         oauth2server.NewRefreshTokenGrant(clients, refreshTokens, issuer),
+	}
+}
+
+func (tc *refreshTokenTestCase) issueRefreshToken(t *testing.T) string {
+	t.Helper()
+
+	resp, err := tc.issuer.IssueAccessToken(context.Background(), &oauth2server.IssueTokenRequest{
+		Client:   oauth2server.NewSimpleClient(testClientId, testClientSecret, nil),
+		UserID:   "user",
+		Scope:    []string{"read", "write"},
+		Resource: []string{testResourceAPI, testResourceBilling},
+		Audience: []string{testResourceAPI, testResourceBilling},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.RefreshToken == "" {
+		t.Fatal("expected a refresh token to be issued")
+	}
+
+	return resp.RefreshToken
+}
+
+func newRefreshTokenRequest(t *testing.T, body map[string]string) *oauth2server.AccessTokenRequest {
+	t.Helper()
+
+	body[oauth2server.ParamGrantType] = oauth2server.GrantTypeRefreshToken
+	req := createRequestWithFormBody(http.MethodPost, "/token", body)
+	req.SetBasicAuth(testClientId, testClientSecret)
+
+	tokenReq, err := oauth2server.ParseAccessTokenRequest(req)
+	if err != nil {
+		t.Fatalf("unexpected error parsing token request: %v", err)
+	}
+
+	return tokenReq
+}
+
+func TestInMemoryRefreshTokenRepository_ExpiredTokensAreNotReturned(t *testing.T) {
+	repo := oauth2server.NewInMemoryRefreshTokenRepository()
+	repo.Create(context.Background(), &oauth2server.RefreshToken{Token: "token", ExpiresAt: time.Now().Add(-time.Second)})
+
+	found, err := repo.Get(context.Background(), "token")
+
+	if err != nil || found != nil {
+		t.Errorf("expected no token once expired, got %v %v", found, err)
+	}
+}
+
+func TestRefreshTokenGrant_Token_ErrorsIfRefreshTokenIsUnknown(t *testing.T) {
+	tc := startRefreshTokenTest(t)
+
+	_, err := tc.grant.Token(context.Background(), newRefreshTokenRequest(t, map[string]string{
+		oauth2server.ParamRefreshToken: "nope",
+	}))
+
+	if !errors.Is(err, oauth2server.ErrInvalidRefreshToken) {
+		t.Errorf("expected ErrInvalidRefreshToken, got %v", err)
+	}
+}
+
+func TestRefreshTokenGrant_Token_IssuesTokenForSubsetOfResources(t *testing.T) {
+	tc := startRefreshTokenTest(t)
+	refreshToken := tc.issueRefreshToken(t)
+
+	resp, err := tc.grant.Token(context.Background(), newRefreshTokenRequest(t, map[string]string{
+		oauth2server.ParamRefreshToken: refreshToken,
+		oauth2server.ParamResource:     testResourceBilling,
+		oauth2server.ParamScope:        "read",
+	}))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.RefreshToken != refreshToken {
+		t.Errorf("expected the same refresh token to be returned, got %q", resp.RefreshToken)
+	}
+	token, _ := tc.tokens.Get(context.Background(), resp.AccessToken)
+	if !slices.Equal(token.Audience, []string{testResourceBilling}) || !slices.Equal(token.Scope, []string{"read"}) {
+		t.Errorf("expected narrowed token, got %+v", token)
+	}
+}
+
+func TestRefreshTokenGrant_Token_ErrorsIfResourceWasNotGranted(t *testing.T) {
+	tc := startRefreshTokenTest(t)
+	refreshToken := tc.issueRefreshToken(t)
+
+	_, err := tc.grant.Token(context.Background(), newRefreshTokenRequest(t, map[string]string{
+		oauth2server.ParamRefreshToken: refreshToken,
+		oauth2server.ParamResource:     "https://other.example.com/",
+	}))
+
+	if !errors.Is(err, oauth2server.ErrResourceNotGranted) {
+		t.Errorf("expected ErrResourceNotGranted, got %v", err)
+	}
+}
+
+func TestRefreshTokenGrant_Token_ErrorsIfScopeWasNotGranted(t *testing.T) {
+	tc := startRefreshTokenTest(t)
+	refreshToken := tc.issueRefreshToken(t)
+
+	_, err := tc.grant.Token(context.Background(), newRefreshTokenRequest(t, map[string]string{
+		oauth2server.ParamRefreshToken: refreshToken,
+		oauth2server.ParamScope:        "read admin",
+	}))
+
+	if !errors.Is(err, oauth2server.ErrScopeNotGranted) {
+		t.Errorf("expected ErrScopeNotGranted, got %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package oauth2server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// JWT-Secured Authorization Requests, see https://datatracker.ietf.org/doc/html/rfc9101
+const (
+	ParamRequest = "request"
+)
+
+// extension point for clients that sign request objects, these are the keys
+// used to verify them. Request objects from clients that do not implement this
+// are rejected.
+type ClientWithJSONWebKeys interface {
+	JSONWebKeys(ctx context.Context) ([]JSONWebKey, error)
+}
+
+// parameters that are allowed outside of the request object when strict
+// request objects are enabled
+var strictRequestObjectParams = []string{ParamClientID, ParamRequest, ParamRequestURI}
+
+// claims from the request object JWT itself rather than the authorization request
+var requestObjectJWTClaims = []string{"iss", "aud", "exp", "iat", "nbf", "jti"}
+
+// enable request objects via the `request` parameter and by reference with
+// `request_uri` using the fetcher. A nil fetcher only enables `request`. The
+// issuer is this server's issuer identifier and must be in the request
+// object's `aud` claim, request objects are rejected if it is empty.
+func WithRequestObjects(issuer string, fetcher Fetcher) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.requestObjects = true
+		opts.requestObjectIssuer = issuer
+		opts.requestObjectFetcher = fetcher
+	}
+}
+
+// reject any authorization request parameters outside of the request object
+// rather than merging them into the request.
+func WithStrictRequestObjects() ServerOption {
+	return func(opts *ServerOptions) {
+		opts.strictRequestObjects = true
+	}
+}
+
+// swap the incoming authorization request values for those from its
+// request object. Values in the request object take precedence.
+func (s *defaultAuthorizationServer) resolveRequestObject(ctx context.Context, client Client, values url.Values) (url.Values, *OAuthError) {
+	hasRequest := values.Has(ParamRequest)
+	if !s.requestObjects {
+		if hasRequest {
+			return nil, RequestNotSupported()
+		}
+		return nil, RequestURINotSupported()
+	}
+
+	if hasRequest && values.Has(ParamRequestURI) {
+		return nil, InvalidRequest("%s and %s cannot both be used", ParamRequest, ParamRequestURI)
+	}
+
+	jwt := values.Get(ParamRequest)
+	if !hasRequest {
+		if s.requestObjectFetcher == nil {
+			return nil, RequestURINotSupported()
+		}
+
+		fetched, err := s.requestObjectFetcher.Fetch(ctx, values.Get(ParamRequestURI))
+		if err != nil {
+			return nil, InvalidRequestURI(err)
+		}
+		jwt = strings.TrimSpace(string(fetched))
+	}
+
+	objectValues, err := s.verifyRequestObject(ctx, client, jwt)
+	if err != nil {
+		return nil, err
+	}
+
+	// https://datatracker.ietf.org/doc/html/rfc9101#section-6.3 client_id in
+	// the request object must match the one in the request
+	if objectClientId := objectValues.Get(ParamClientID); objectClientId != "" && objectClientId != client.ID() {
+		return nil, InvalidRequestObject(fmt.Errorf("%s in request object does not match the request", ParamClientID))
+	}
+
+	merged := url.Values{}
+	for k, v := range values {
+		if slices.Contains(strictRequestObjectParams, k) {
+			continue
+		}
+		if s.strictRequestObjects {
+			return nil, InvalidRequest("%s must be included in the request object", k)
+		}
+		merged[k] = v
+	}
+	for k, v := range objectValues {
+		merged[k] = v
+	}
+	merged.Set(ParamClientID, client.ID())
+
+	return merged, nil
+}
+
+// verify the request object JWT with the client's keys and return its claims
+// as authorization request values.
+func (s *defaultAuthorizationServer) verifyRequestObject(ctx context.Context, client Client, jwt string) (url.Values, *OAuthError) {
+	withKeys, ok := client.(ClientWithJSONWebKeys)
+	if !ok {
+		return nil, InvalidRequestObject(fmt.Errorf("client %s has no keys to verify request objects", client.ID()))
+	}
+
+	keys, err := withKeys.JSONWebKeys(ctx)
+	if err != nil {
+		return nil, MaybeWrapError(err)
+	}
+
+	var claims map[string]any
+	if err := VerifyJWT(jwt, keys, &claims); err != nil {
+		return nil, InvalidRequestObject(err)
+	}
+
+	// https://datatracker.ietf.org/doc/html/rfc9101#section-10.2 the client
+	// issues request objects for this server
+	if iss := claims["iss"]; iss != client.ID() {
+		return nil, InvalidRequestObject(fmt.Errorf("request object issuer %v is not the client", iss))
+	}
+
+	if s.requestObjectIssuer == "" {
+		return nil, InvalidRequestObject(fmt.Errorf("no issuer configured to check the request object audience"))
+	}
+	if !audienceContains(claims["aud"], s.requestObjectIssuer) {
+		return nil, InvalidRequestObject(fmt.Errorf("request object audience does not include %s", s.requestObjectIssuer))
+	}
+
+	now := time.Now()
+	if exp, ok := numericDateClaim(claims, "exp"); ok && now.After(exp) {
+		return nil, InvalidRequestObject(fmt.Errorf("request object expired"))
+	}
+	if nbf, ok := numericDateClaim(claims, "nbf"); ok && now.Before(nbf) {
+		return nil, InvalidRequestObject(fmt.Errorf("request object is not yet valid"))
+	}
+
+	values := url.Values{}
+	for k, v := range claims {
+		if slices.Contains(requestObjectJWTClaims, k) {
+			continue
+		}
+
+		// resource is the only multi-valued parameter
+		if resources, ok := v.([]any); ok && k == ParamResource {
+			for _, r := range resources {
+				value, err := requestObjectClaimValue(r)
+				if err != nil {
+					return nil, InvalidRequestObject(err)
+				}
+				values.Add(k, value)
+			}
+			continue
+		}
+
+		value, err := requestObjectClaimValue(v)
+		if err != nil {
+			return nil, InvalidRequestObject(err)
+		}
+		values.Set(k, value)
+	}
+
+	return values, nil
+}
+
+// turn request object claims back into parameter values, JSON objects and
+// arrays like `claims` or `authorization_details` are re-encoded.
+func requestObjectClaimValue(v any) (string, error) {
+	switch value := v.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return fmt.Sprintf("%t", value), nil
+	}
+
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(encoded), nil
+}
+
+func audienceContains(aud any, expected string) bool {
+	switch a := aud.(type) {
+	case string:
+		return a == expected
+	case []any:
+		return slices.Contains(a, any(expected))
+	}
+
+	return false
+}
+
+func numericDateClaim(claims map[string]any, name string) (time.Time, bool) {
+	n, ok := claims[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	seconds, err := n.Int64()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(seconds, 0), true
+}
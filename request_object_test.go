@@ -0,0 +1,261 @@
+package oauth2server_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+const testIssuer = "https://issuer.example.com"
+
+func startRequestObjectTest(t *testing.T, opts ...oauth2server.ServerOption) *authorizationServerTestCase {
+	t.Helper()
+
+	opts = append(opts, oauth2server.WithAuthorizationHandler(&spyAuthorizationHandler{responseType: "code"}))
+	tc := startAuthorizationServerTest(t, opts...)
+	tc.clients.Add(&keyedClient{
+		Client: oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}),
+		keys:   testJSONWebKeys(),
+	})
+
+	return tc
+}
+
+func newTestRequestObject(t *testing.T, claims map[string]any) string {
+	t.Helper()
+
+	base := map[string]any{
+		"iss":                          testClientId,
+		"aud":                          testIssuer,
+		"exp":                          time.Now().Add(time.Minute).Unix(),
+		oauth2server.ParamClientID:     testClientId,
+		oauth2server.ParamResponseType: "code",
+	}
+	for k, v := range claims {
+		if v == nil {
+			delete(base, k)
+			continue
+		}
+		base[k] = v
+	}
+
+	token, err := oauth2server.SignJWT(newTestSigningKey(), base)
+	if err != nil {
+		t.Fatalf("could not sign request object: %v", err)
+	}
+
+	return token
+}
+
+func TestRequestObjects_AreNotSupportedUnlessEnabled(t *testing.T) {
+	tc := startRequestObjectTest(t)
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamClientID: testClientId,
+		oauth2server.ParamRequest:  newTestRequestObject(t, nil),
+	})
+
+	authReq, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	tc.assertNilAuthRequest(t, authReq)
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeRequestNotSupported {
+		t.Errorf("expected a %q error, got %v", oauth2server.ErrorTypeRequestNotSupported, err)
+	}
+}
+
+func TestRequestObjects_RequestObjectValuesTakePrecedence(t *testing.T) {
+	tc := startRequestObjectTest(t, oauth2server.WithRequestObjects(testIssuer, nil))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamClientID: testClientId,
+		oauth2server.ParamState:    "fromquery",
+		oauth2server.ParamScope:    "openid",
+		oauth2server.ParamRequest: newTestRequestObject(t, map[string]any{
+			oauth2server.ParamState: "fromobject",
+		}),
+	})
+
+	authReq, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if authReq.State != "fromobject" {
+		t.Errorf(`expected state from request object: %q != "fromobject"`, authReq.State)
+	}
+	if len(authReq.Scope) != 1 || authReq.Scope[0] != "openid" {
+		t.Errorf("expected scope to be merged from the query string, got %v", authReq.Scope)
+	}
+}
+
+func TestRequestObjects_StrictRequestObjectsRejectOutsideParameters(t *testing.T) {
+	tc := startRequestObjectTest(
+		t,
+		oauth2server.WithRequestObjects(testIssuer, nil),
+		oauth2server.WithStrictRequestObjects(),
+	)
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamClientID: testClientId,
+		oauth2server.ParamState:    "fromquery",
+		oauth2server.ParamRequest:  newTestRequestObject(t, nil),
+	})
+
+	authReq, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	tc.assertNilAuthRequest(t, authReq)
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeInvalidRequest {
+		t.Errorf("expected an invalid_request error, got %v", err)
+	}
+}
+
+func TestRequestObjects_ClientIDMustMatchRequest(t *testing.T) {
+	tc := startRequestObjectTest(t, oauth2server.WithRequestObjects(testIssuer, nil))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamClientID: testClientId,
+		oauth2server.ParamRequest: newTestRequestObject(t, map[string]any{
+			oauth2server.ParamClientID: "other",
+		}),
+	})
+
+	authReq, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	tc.assertNilAuthRequest(t, authReq)
+	if !errors.Is(err, oauth2server.ErrInvalidRequestObject) {
+		t.Errorf("expected ErrInvalidRequestObject, got %v", err)
+	}
+}
+
+func TestRequestObjects_AudienceMustIncludeIssuer(t *testing.T) {
+	tc := startRequestObjectTest(t, oauth2server.WithRequestObjects(testIssuer, nil))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamClientID: testClientId,
+		oauth2server.ParamRequest: newTestRequestObject(t, map[string]any{
+			"aud": "https://other.example.com",
+		}),
+	})
+
+	_, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	if !errors.Is(err, oauth2server.ErrInvalidRequestObject) {
+		t.Errorf("expected ErrInvalidRequestObject, got %v", err)
+	}
+	if err != nil && strings.Contains(err.ErrorDescription, testIssuer) {
+		t.Errorf("expected verification details to be left out of the description, got %q", err.ErrorDescription)
+	}
+}
+
+func TestRequestObjects_IssuerAndAudienceAreRequired(t *testing.T) {
+	cases := []struct {
+		name   string
+		issuer string
+		claims map[string]any
+	}{
+		{"missing issuer", testIssuer, map[string]any{"iss": nil}},
+		{"missing audience", testIssuer, map[string]any{"aud": nil}},
+		{"no server issuer configured", "", nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tc := startRequestObjectTest(t, oauth2server.WithRequestObjects(c.issuer, nil))
+			req := newAuthorizeRequestWithQueryString(t, map[string]string{
+				oauth2server.ParamClientID: testClientId,
+				oauth2server.ParamRequest:  newTestRequestObject(t, c.claims),
+			})
+
+			_, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+			if !errors.Is(err, oauth2server.ErrInvalidRequestObject) {
+				t.Errorf("expected ErrInvalidRequestObject, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRequestObjects_ExpiredRequestObjectsAreInvalid(t *testing.T) {
+	tc := startRequestObjectTest(t, oauth2server.WithRequestObjects(testIssuer, nil))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamClientID: testClientId,
+		oauth2server.ParamRequest: newTestRequestObject(t, map[string]any{
+			"exp": time.Now().Add(-time.Minute).Unix(),
+		}),
+	})
+
+	_, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	if !errors.Is(err, oauth2server.ErrInvalidRequestObject) {
+		t.Errorf("expected ErrInvalidRequestObject, got %v", err)
+	}
+}
+
+func TestRequestObjects_ClientsWithoutKeysCannotUseRequestObjects(t *testing.T) {
+	tc := startAuthorizationServerTest(t, oauth2server.WithRequestObjects(testIssuer, nil))
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamClientID: testClientId,
+		oauth2server.ParamRequest:  newTestRequestObject(t, nil),
+	})
+
+	_, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	if !errors.Is(err, oauth2server.ErrInvalidRequestObject) {
+		t.Errorf("expected ErrInvalidRequestObject, got %v", err)
+	}
+}
+
+func TestRequestObjects_RequestURIsAreFetchedByReference(t *testing.T) {
+	fetcher := &spyFetcher{}
+	tc := startRequestObjectTest(t, oauth2server.WithRequestObjects(testIssuer, fetcher))
+	fetcher.body = []byte(newTestRequestObject(t, map[string]any{
+		oauth2server.ParamState: "fetched",
+	}))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamClientID:   testClientId,
+		oauth2server.ParamRequestURI: "https://client.example.com/request.jwt",
+	})
+
+	authReq, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fetcher.calls) != 1 || fetcher.calls[0] != "https://client.example.com/request.jwt" {
+		t.Errorf("expected request URI to be fetched, got %v", fetcher.calls)
+	}
+	if authReq.State != "fetched" {
+		t.Errorf(`expected state from fetched request object: %q != "fetched"`, authReq.State)
+	}
+	if authReq.RequestURI != "https://client.example.com/request.jwt" {
+		t.Errorf("bad request URI: %q", authReq.RequestURI)
+	}
+}
+
+func TestRequestObjects_ReturnsInvalidRequestURIIfFetchFails(t *testing.T) {
+	fetcher := &spyFetcher{err: errors.New("oh noz")}
+	tc := startRequestObjectTest(t, oauth2server.WithRequestObjects(testIssuer, fetcher))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamClientID:   testClientId,
+		oauth2server.ParamRequestURI: "https://client.example.com/request.jwt",
+	})
+
+	_, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeInvalidRequestURI {
+		t.Errorf("expected a %q error, got %v", oauth2server.ErrorTypeInvalidRequestURI, err)
+	}
+}
+
+func TestRequestObjects_RequestURIsAreNotSupportedWithoutFetcher(t *testing.T) {
+	tc := startRequestObjectTest(t, oauth2server.WithRequestObjects(testIssuer, nil))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamClientID:   testClientId,
+		oauth2server.ParamRequestURI: "https://client.example.com/request.jwt",
+	})
+
+	_, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeRequestURINotSupported {
+		t.Errorf("expected a %q error, got %v", oauth2server.ErrorTypeRequestURINotSupported, err)
+	}
+}
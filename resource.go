@@ -0,0 +1,177 @@
+package oauth2server
+
+import (
+	"context"
+	"net/url"
+	"slices"
+)
+
+// Resource indicators, see https://datatracker.ietf.org/doc/html/rfc8707
+
+// parse the `resource` parameter values, each must be an absolute URI without
+// a fragment. See https://datatracker.ietf.org/doc/html/rfc8707#section-2
+func ParseResourceParameter(values []string) ([]string, *OAuthError) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	resources := make([]string, 0, len(values))
+	for _, v := range values {
+		u, err := url.Parse(v)
+		if err != nil || !u.IsAbs() || u.Fragment != "" || u.RawFragment != "" {
+			return nil, InvalidTargetWithCause(ErrInvalidResource, "%s is not a valid %s", v, ParamResource)
+		}
+
+		if !slices.Contains(resources, v) {
+			resources = append(resources, v)
+		}
+	}
+
+	return resources, nil
+}
+
+// validates requested resources along with the scopes requested for them.
+// Like scopes there are no resource entities here, this is the extension point.
+type ResourceValidator interface {
+	// Check the resources and scopes, this should return an invalid_target
+	// error if a resource is unknown or invalid_scope error if the scopes
+	// cannot be used with the resources. Any other, non OAuthError returned
+	// will be transformed to a server_error
+	ValidateResources(ctx context.Context, client Client, resources []string, scope []string) error
+}
+
+type allowedResourceValidator struct {
+	resources map[string][]string
+}
+
+// allow a fixed set of resources, the map is from the resource identifier to
+// the scopes that resource allows. A nil scope list allows any scope.
+func AllowResources(resources map[string][]string) ResourceValidator {
+	return &allowedResourceValidator{
+		resources: resources,
+	}
+}
+
+func (v *allowedResourceValidator) ValidateResources(ctx context.Context, client Client, resources []string, scope []string) error {
+	lookup := func(resource string) ([]string, bool) {
+		scopes, ok := v.resources[resource]
+		return scopes, ok
+	}
+	owned := func(sc string) (bool, error) {
+		for _, scopes := range v.resources {
+			if slices.Contains(scopes, sc) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	return validateResourceScopes(resources, scope, lookup, owned)
+}
+
+// check every resource is known and every scope is allowed by at least one of
+// the requested resources. Scopes that no resource owns, eg `openid`, are not
+// tied to resources and may always be requested.
+func validateResourceScopes(
+	resources []string,
+	scope []string,
+	lookup func(string) ([]string, bool),
+	owned func(string) (bool, error),
+) error {
+	allowed := []string{}
+	anyScope := false
+	for _, r := range resources {
+		scopes, ok := lookup(r)
+		if !ok {
+			return InvalidTargetWithCause(ErrUnknownResource, "%s is not a known resource", r)
+		}
+
+		if scopes == nil {
+			anyScope = true
+		}
+		allowed = append(allowed, scopes...)
+	}
+
+	if anyScope {
+		return nil
+	}
+
+	var invalid []string
+	for _, s := range scope {
+		if slices.Contains(allowed, s) {
+			continue
+		}
+
+		isOwned, err := owned(s)
+		if err != nil {
+			return err
+		}
+		if isOwned {
+			invalid = append(invalid, s)
+		}
+	}
+
+	if len(invalid) > 0 {
+		e := InvalidScope(invalid)
+		e.Cause = ErrScopeNotAllowedForResource
+		return e
+	}
+
+	return nil
+}
+
+func WithResourceValidator(v ResourceValidator) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.resourceValidator = v
+	}
+}
+
+func (s *defaultAuthorizationServer) validateResources(ctx context.Context, client Client, resources []string, scope []string) *OAuthError {
+	if len(resources) == 0 {
+		return nil
+	}
+
+	if s.resourceValidator == nil {
+		return InvalidTargetWithCause(
+			ErrResourceIndicatorsNotSupported,
+			"%s is not supported",
+			ParamResource,
+		)
+	}
+
+	if err := s.resourceValidator.ValidateResources(ctx, client, resources, scope); err != nil {
+		return MaybeWrapError(err)
+	}
+
+	return nil
+}
+
+// the audience for an access token: the resources requested in the token
+// request which must have been granted, or all granted resources.
+// See https://datatracker.ietf.org/doc/html/rfc8707#section-2.2
+func tokenAudience(requested []string, granted []string) ([]string, *OAuthError) {
+	if len(requested) == 0 {
+		return granted, nil
+	}
+
+	if !isSubset(requested, granted) {
+		return nil, InvalidTargetWithCause(
+			ErrResourceNotGranted,
+			"%s was not granted for the requested resources",
+			ParamResource,
+		)
+	}
+
+	return requested, nil
+}
+
+// true if every requested value was granted
+func isSubset(requested []string, granted []string) bool {
+	for _, r := range requested {
+		if !slices.Contains(granted, r) {
+			return false
+		}
+	}
+
+	return true
+}
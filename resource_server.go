@@ -0,0 +1,232 @@
+package oauth2server
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// A protected API that access tokens are issued for.
+type ResourceServer interface {
+	// the resource identifier, this is the value clients send in the `resource`
+	// parameter and what tokens are audience restricted to.
+	ID() string
+
+	// used by the resource server to authenticate itself, eg to the
+	// introspection endpoint
+	Secret() string
+
+	// the scopes the resource server owns, nil allows any scope.
+	Scopes() []string
+
+	// how long access tokens for the resource server are valid, zero uses the
+	// token issuer's default.
+	AccessTokenLifetime() time.Duration
+}
+
+// an extension point to let resource servers validate their own secrets
+type ResourceServerValidatesSecrets interface {
+	ValidSecret(secret string) bool
+}
+
+// A storage backend for resource servers.
+type ResourceServerRepository interface {
+	// Get a single resource server by its identifier, return a `nil` resource
+	// server if not found. Any errors returned here will be propagated as
+	// server errors.
+	Get(ctx context.Context, id string) (ResourceServer, error)
+
+	// whether any resource server owns the scope. Scopes no resource server
+	// owns, eg `openid`, may be requested alongside any resource.
+	OwnsScope(ctx context.Context, scope string) (bool, error)
+}
+
+// fetch a resource server and authenticate it with its credentials.
+func AuthenticateResourceServer(ctx context.Context, resourceServers ResourceServerRepository, id string, secret string) (ResourceServer, *OAuthError) {
+	if id == "" {
+		return nil, unauthenticatedClient(InvalidClientWithCause(ErrMissingClientID, ErrMissingClientID.Error()))
+	}
+
+	rs, err := resourceServers.Get(ctx, id)
+	if err != nil {
+		return nil, MaybeWrapError(err)
+	}
+
+	if rs == nil {
+		return nil, unauthenticatedClient(InvalidClientWithCause(ErrResourceServerNotFound, "resource server authentication failed"))
+	}
+
+	if secret == "" {
+		return nil, unauthenticatedClient(InvalidClientWithCause(ErrMissingClientSecret, ErrMissingClientSecret.Error()))
+	}
+
+	if !validResourceServerSecret(rs, secret) {
+		return nil, unauthenticatedClient(InvalidClientWithCause(ErrInvalidClientSecret, "resource server authentication failed"))
+	}
+
+	return rs, nil
+}
+
+func validResourceServerSecret(rs ResourceServer, secret string) bool {
+	if validates, ok := rs.(ResourceServerValidatesSecrets); ok {
+		return validates.ValidSecret(secret)
+	}
+
+	return constantTimeCompare(rs.Secret(), secret)
+}
+
+type resourceServerValidator struct {
+	resourceServers ResourceServerRepository
+}
+
+// validate requested resources against the resource servers in the repository,
+// scopes owned by any resource server must be owned by one of the requested
+// resource servers.
+func ValidateResourcesWithRepository(resourceServers ResourceServerRepository) ResourceValidator {
+	return &resourceServerValidator{
+		resourceServers: resourceServers,
+	}
+}
+
+func (v *resourceServerValidator) ValidateResources(ctx context.Context, client Client, resources []string, scope []string) error {
+	found := make(map[string]ResourceServer, len(resources))
+	for _, r := range resources {
+		rs, err := v.resourceServers.Get(ctx, r)
+		if err != nil {
+			return err
+		}
+		if rs != nil {
+			found[r] = rs
+		}
+	}
+
+	lookup := func(resource string) ([]string, bool) {
+		rs, ok := found[resource]
+		if !ok {
+			return nil, false
+		}
+		return rs.Scopes(), true
+	}
+	owned := func(sc string) (bool, error) {
+		return v.resourceServers.OwnsScope(ctx, sc)
+	}
+
+	return validateResourceScopes(resources, scope, lookup, owned)
+}
+
+// use the resource servers' token lifetimes for audience restricted access
+// tokens, the shortest lifetime of the resource servers in the audience wins.
+func WithResourceServerLifetimes(resourceServers ResourceServerRepository) TokenIssuerOption {
+	return func(i *defaultTokenIssuer) {
+		i.resourceServers = resourceServers
+	}
+}
+
+// configure the protected resource servers. They can authenticate to the
+// introspection endpoint and, if no other resource validator is set, are used
+// to validate resource indicators.
+func WithResourceServers(resourceServers ResourceServerRepository) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.resourceServers = resourceServers
+	}
+}
+
+type SimpleResourceServer struct {
+	id                  string
+	secret              string
+	scopes              []string
+	accessTokenLifetime time.Duration
+}
+
+func NewSimpleResourceServer(id string, secret string, scopes []string, accessTokenLifetime time.Duration) ResourceServer {
+	return &SimpleResourceServer{
+		id:                  id,
+		secret:              secret,
+		scopes:              scopes,
+		accessTokenLifetime: accessTokenLifetime,
+	}
+}
+
+func (r *SimpleResourceServer) ID() string {
+	return r.id
+}
+
+func (r *SimpleResourceServer) Secret() string {
+	return r.secret
+}
+
+func (r *SimpleResourceServer) Scopes() []string {
+	return r.scopes
+}
+
+func (r *SimpleResourceServer) AccessTokenLifetime() time.Duration {
+	return r.accessTokenLifetime
+}
+
+type InMemoryResourceServerRepository struct {
+	lock            sync.RWMutex
+	resourceServers map[string]ResourceServer
+}
+
+func NewInMemoryResourceServerRepository() *InMemoryResourceServerRepository {
+	return &InMemoryResourceServerRepository{
+		resourceServers: make(map[string]ResourceServer),
+	}
+}
+
+func (r *InMemoryResourceServerRepository) Get(ctx context.Context, id string) (ResourceServer, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	rs, _ := r.resourceServers[id]
+
+	return rs, nil
+}
+
+func (r *InMemoryResourceServerRepository) OwnsScope(ctx context.Context, scope string) (bool, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, rs := range r.resourceServers {
+		if slices.Contains(rs.Scopes(), scope) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *InMemoryResourceServerRepository) Add(rs ResourceServer) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.resourceServers[rs.ID()] = rs
+}
+
+func (r *InMemoryResourceServerRepository) Remove(id string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.resourceServers, id)
+}
+
+// the lifetime for an access token with the given audience, zero if none of
+// the resource servers set one.
+func resourceServerLifetime(ctx context.Context, resourceServers ResourceServerRepository, audience []string) (time.Duration, error) {
+	var lifetimes []time.Duration
+	for _, aud := range audience {
+		rs, err := resourceServers.Get(ctx, aud)
+		if err != nil {
+			return 0, err
+		}
+		if rs != nil && rs.AccessTokenLifetime() > 0 {
+			lifetimes = append(lifetimes, rs.AccessTokenLifetime())
+		}
+	}
+
+	if len(lifetimes) == 0 {
+		return 0, nil
+	}
+
+	return slices.Min(lifetimes), nil
+}
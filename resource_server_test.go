@@ -0,0 +1,157 @@
+package oauth2server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+const testResourceServerSecret = "rs-secret"
+
+func newTestResourceServers() *oauth2server.InMemoryResourceServerRepository {
+	repo := oauth2server.NewInMemoryResourceServerRepository()
+	repo.Add(oauth2server.NewSimpleResourceServer(testResourceAPI, testResourceServerSecret, []string{"read", "write"}, 5*time.Minute))
+	repo.Add(oauth2server.NewSimpleResourceServer(testResourceBilling, testResourceServerSecret, []string{"invoices"}, 0))
+
+	return repo
+}
+
+func newResourceServerIntrospectionRequest(resourceServer string, secret string, token string) *http.Request {
+	return createRequestWithFormBody(http.MethodPost, "/introspect", map[string]string{
+		oauth2server.ParamClientID:     resourceServer,
+		oauth2server.ParamClientSecret: secret,
+		oauth2server.ParamToken:        token,
+	})
+}
+
+func TestInMemoryResourceServerRepository_ResourceServersCanBeManagedInMemory(t *testing.T) {
+	repo := oauth2server.NewInMemoryResourceServerRepository()
+	rs := oauth2server.NewSimpleResourceServer(testResourceAPI, testResourceServerSecret, nil, 0)
+
+	repo.Add(rs)
+	found, err := repo.Get(context.Background(), testResourceAPI)
+	if err != nil || found != rs {
+		t.Errorf("expected resource server, got %v %v", found, err)
+	}
+
+	repo.Remove(testResourceAPI)
+	found, err = repo.Get(context.Background(), testResourceAPI)
+	if err != nil || found != nil {
+		t.Errorf("expected no resource server after remove, got %v %v", found, err)
+	}
+}
+
+func TestAuthenticateResourceServer_ErrorsIfResourceServerIsNotFound(t *testing.T) {
+	_, err := oauth2server.AuthenticateResourceServer(context.Background(), newTestResourceServers(), "https://other.example.com/", "secret")
+
+	if !errors.Is(err, oauth2server.ErrResourceServerNotFound) {
+		t.Errorf("expected ErrResourceServerNotFound, got %v", err)
+	}
+}
+
+func TestAuthenticateResourceServer_ErrorsIfSecretIsInvalid(t *testing.T) {
+	_, err := oauth2server.AuthenticateResourceServer(context.Background(), newTestResourceServers(), testResourceAPI, "wrong")
+
+	if !errors.Is(err, oauth2server.ErrInvalidClientSecret) {
+		t.Errorf("expected ErrInvalidClientSecret, got %v", err)
+	}
+	if err.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 status code, got %d", err.StatusCode)
+	}
+}
+
+func TestValidateResourcesWithRepository_UsesResourceServerScopes(t *testing.T) {
+	validator := oauth2server.ValidateResourcesWithRepository(newTestResourceServers())
+
+	err := validator.ValidateResources(context.Background(), nil, []string{testResourceBilling}, []string{"read"})
+	if !errors.Is(err, oauth2server.ErrScopeNotAllowedForResource) {
+		t.Errorf("expected ErrScopeNotAllowedForResource, got %v", err)
+	}
+
+	err = validator.ValidateResources(context.Background(), nil, []string{"https://other.example.com/"}, nil)
+	if !errors.Is(err, oauth2server.ErrUnknownResource) {
+		t.Errorf("expected ErrUnknownResource, got %v", err)
+	}
+
+	err = validator.ValidateResources(context.Background(), nil, []string{testResourceAPI}, []string{"read", "openid"})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTokenIssuer_IssueAccessToken_UsesShortestResourceServerLifetime(t *testing.T) {
+	tokens := oauth2server.NewInMemoryAccessTokenRepository()
+	issuer := oauth2server.NewTokenIssuer(tokens, oauth2server.WithResourceServerLifetimes(newTestResourceServers()))
+	client := oauth2server.NewSimpleClient(testClientId, testClientSecret, nil)
+
+	resp, err := issuer.IssueAccessToken(context.Background(), &oauth2server.IssueTokenRequest{
+		Client:   client,
+		Audience: []string{testResourceAPI, testResourceBilling},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ExpiresIn != 300 {
+		t.Errorf("expected resource server lifetime, got %d", resp.ExpiresIn)
+	}
+
+	resp, _ = issuer.IssueAccessToken(context.Background(), &oauth2server.IssueTokenRequest{
+		Client:   client,
+		Audience: []string{testResourceBilling},
+	})
+	if resp.ExpiresIn != int(oauth2server.DefaultAccessTokenLifetime.Seconds()) {
+		t.Errorf("expected default lifetime without a resource server lifetime, got %d", resp.ExpiresIn)
+	}
+}
+
+func TestDefaultAuthorizationServer_Introspect_ResourceServersCanIntrospectTheirTokens(t *testing.T) {
+	tokens := oauth2server.NewInMemoryAccessTokenRepository()
+	tc := startAuthorizationServerTest(
+		t,
+		oauth2server.WithIntrospection(tokens),
+		oauth2server.WithResourceServers(newTestResourceServers()),
+	)
+	tokens.Create(context.Background(), &oauth2server.AccessToken{
+		Token:     "api",
+		ClientID:  testClientId,
+		Audience:  []string{testResourceAPI},
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+
+	req := newResourceServerIntrospectionRequest(testResourceAPI, testResourceServerSecret, "api")
+	resp, err := tc.server.Introspect(req.Context(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Active {
+		t.Error("expected token to be active for its audience")
+	}
+
+	req = newResourceServerIntrospectionRequest(testResourceBilling, testResourceServerSecret, "api")
+	resp, err = tc.server.Introspect(req.Context(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Active {
+		t.Error("expected token to be inactive for other resource servers")
+	}
+}
+
+func TestDefaultAuthorizationServer_Introspect_ErrorsIfResourceServerSecretIsInvalid(t *testing.T) {
+	tc := startAuthorizationServerTest(
+		t,
+		oauth2server.WithIntrospection(oauth2server.NewInMemoryAccessTokenRepository()),
+		oauth2server.WithResourceServers(newTestResourceServers()),
+	)
+	req := newResourceServerIntrospectionRequest(testResourceAPI, "wrong", "api")
+
+	_, err := tc.server.Introspect(req.Context(), req)
+
+	if !errors.Is(err, oauth2server.ErrInvalidClientSecret) {
+		t.Errorf("expected ErrInvalidClientSecret, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package oauth2server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+const (
+	testResourceAPI     = "https://api.example.com/"
+	testResourceBilling = "https://billing.example.com/"
+)
+
+func newTestResourceValidator() oauth2server.ResourceValidator {
+	return oauth2server.AllowResources(map[string][]string{
+		testResourceAPI:     {"read", "write"},
+		testResourceBilling: {"invoices"},
+	})
+}
+
+func TestParseResourceParameter_ErrorsOnInvalidResources(t *testing.T) {
+	for _, resource := range []string{"/relative", "https://api.example.com/#fragment", "::"} {
+		_, err := oauth2server.ParseResourceParameter([]string{resource})
+
+		if !errors.Is(err, oauth2server.ErrInvalidResource) {
+			t.Errorf("expected ErrInvalidResource for %q, got %v", resource, err)
+		}
+		if err != nil && err.ErrorType != oauth2server.ErrorTypeInvalidTarget {
+			t.Errorf("expected invalid_target error, got %q", err.ErrorType)
+		}
+	}
+}
+
+func TestParseResourceParameter_RemovesDuplicates(t *testing.T) {
+	resources, err := oauth2server.ParseResourceParameter([]string{testResourceAPI, testResourceBilling, testResourceAPI})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(resources, []string{testResourceAPI, testResourceBilling}) {
+		t.Errorf("unexpected resources: %v", resources)
+	}
+}
+
+func TestAllowResources_ErrorsOnUnknownResource(t *testing.T) {
+	err := newTestResourceValidator().ValidateResources(context.Background(), nil, []string{"https://other.example.com/"}, nil)
+
+	if !errors.Is(err, oauth2server.ErrUnknownResource) {
+		t.Errorf("expected ErrUnknownResource, got %v", err)
+	}
+}
+
+func TestAllowResources_AllowsScopesNoResourceOwns(t *testing.T) {
+	err := newTestResourceValidator().ValidateResources(
+		context.Background(),
+		nil,
+		[]string{testResourceAPI},
+		[]string{"openid", "profile", "read"},
+	)
+
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAllowResources_ErrorsIfScopeIsNotAllowedByAnyResource(t *testing.T) {
+	err := newTestResourceValidator().ValidateResources(
+		context.Background(),
+		nil,
+		[]string{testResourceAPI},
+		[]string{"read", "invoices"},
+	)
+
+	if !errors.Is(err, oauth2server.ErrScopeNotAllowedForResource) {
+		t.Errorf("expected ErrScopeNotAllowedForResource, got %v", err)
+	}
+}
+
+func TestAllowResources_AllowsScopesFromAnyRequestedResource(t *testing.T) {
+	err := newTestResourceValidator().ValidateResources(
+		context.Background(),
+		nil,
+		[]string{testResourceAPI, testResourceBilling},
+		[]string{"read", "invoices"},
+	)
+
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_ErrorsOnResourcesWithoutValidator(t *testing.T) {
+	tc := startAuthorizationServerTest(t, oauth2server.WithAuthorizationHandler(&spyAuthorizationHandler{responseType: "code"}))
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamResponseType: "code",
+		oauth2server.ParamClientID:     testClientId,
+		oauth2server.ParamResource:     testResourceAPI,
+	})
+
+	authReq, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	tc.assertNotNilAuthRequest(t, authReq)
+	if !errors.Is(err, oauth2server.ErrResourceIndicatorsNotSupported) {
+		t.Errorf("expected ErrResourceIndicatorsNotSupported, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_IncludesValidatedResources(t *testing.T) {
+	tc := startAuthorizationServerTest(
+		t,
+		oauth2server.WithAuthorizationHandler(&spyAuthorizationHandler{responseType: "code"}),
+		oauth2server.WithResourceValidator(newTestResourceValidator()),
+	)
+	tc.clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	query := url.Values{}
+	query.Set(oauth2server.ParamResponseType, "code")
+	query.Set(oauth2server.ParamClientID, testClientId)
+	query.Set(oauth2server.ParamScope, "read invoices")
+	query.Add(oauth2server.ParamResource, testResourceAPI)
+	query.Add(oauth2server.ParamResource, testResourceBilling)
+	req, _ := http.NewRequest(http.MethodGet, "/authorize?"+query.Encode(), nil)
+
+	authReq, err := tc.server.ValidateAuthorizationRequest(req.Context(), req)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(authReq.Resource, []string{testResourceAPI, testResourceBilling}) {
+		t.Errorf("unexpected resources: %v", authReq.Resource)
+	}
+}
+
+func TestAuthorizationCodeGrant_Token_BindsAudienceToRequestedResource(t *testing.T) {
+	tc := startAuthorizationCodeTest(t)
+	code := tc.issueCode(t, &oauth2server.AuthorizationRequest{
+		ClientID: testClientId,
+		Resource: []string{testResourceAPI, testResourceBilling},
+	})
+
+	resp, err := tc.grant.Token(context.Background(), newCodeTokenRequest(t, map[string]string{
+		oauth2server.ParamCode:     code,
+		oauth2server.ParamResource: testResourceBilling,
+	}))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	token, _ := tc.tokens.Get(context.Background(), resp.AccessToken)
+	if !slices.Equal(token.Audience, []string{testResourceBilling}) {
+		t.Errorf("expected audience restricted token, got %v", token.Audience)
+	}
+}
+
+func TestAuthorizationCodeGrant_Token_ErrorsIfResourceWasNotGranted(t *testing.T) {
+	tc := startAuthorizationCodeTest(t)
+	code := tc.issueCode(t, &oauth2server.AuthorizationRequest{
+		ClientID: testClientId,
+		Resource: []string{testResourceAPI},
+	})
+
+	_, err := tc.grant.Token(context.Background(), newCodeTokenRequest(t, map[string]string{
+		oauth2server.ParamCode:     code,
+		oauth2server.ParamResource: testResourceBilling,
+	}))
+
+	if !errors.Is(err, oauth2server.ErrResourceNotGranted) {
+		t.Errorf("expected ErrResourceNotGranted, got %v", err)
+	}
+}
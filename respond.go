@@ -29,3 +29,12 @@ func RespondWithError(w http.ResponseWriter, e OAuthError) error {
 func ResponseWithAccessToken(w http.ResponseWriter, token *AccessTokenResponse) error {
 	return jsonResponse(w, 200, token)
 }
+
+func redirect(w http.ResponseWriter, location string) error {
+	w.Header().Set("Location", location)
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusFound)
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package oauth2server
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+const (
+	ResponseModeQuery    = "query"
+	ResponseModeFragment = "fragment"
+	ResponseModeFormPost = "form_post"
+)
+
+// Sends authorization responses (and errors) back to the client for a
+// specific `response_mode`. See
+// https://openid.net/specs/oauth-v2-multiple-response-types-1_0.html#ResponseModes
+type ResponseModeHandler interface {
+	// the response mode the handler responds to
+	ResponseMode() string
+
+	// whether or not the response mode can be used with the given response types
+	SupportsResponseType(responseType []string) bool
+
+	// send the authorization response parameters to the client. This is used
+	// for both successful responses and errors.
+	Respond(
+		ctx context.Context,
+		w http.ResponseWriter,
+		client Client,
+		req *AuthorizationRequest,
+		params url.Values,
+	) error
+}
+
+// extension point to allow clients to restrict which response modes they use
+type ClientAllowsResponseMode interface {
+	AllowsResponseMode(responseMode string) bool
+}
+
+// the default response mode for a set of response types, as defined in
+// https://openid.net/specs/oauth-v2-multiple-response-types-1_0.html#Combinations
+// any response type that issues a token in the authorization response defaults
+// to the fragment response mode. Only `code` and `none` default to query.
+func DefaultResponseMode(responseType []string) string {
+	if issuesTokenInAuthorizationResponse(responseType) {
+		return ResponseModeFragment
+	}
+
+	return ResponseModeQuery
+}
+
+// true if the response types contain a type that would issue a token in the
+// authorization response.
+func issuesTokenInAuthorizationResponse(responseType []string) bool {
+	return slices.ContainsFunc(responseType, func(t string) bool {
+		return t != ResponseTypeCode && t != ResponseTypeNone
+	})
+}
+
+type queryResponseMode struct {
+}
+
+// send authorization responses in the query string of the redirect URI
+func QueryResponseMode() ResponseModeHandler {
+	return &queryResponseMode{}
+}
+
+func (m *queryResponseMode) ResponseMode() string {
+	return ResponseModeQuery
+}
+
+// https://openid.net/specs/oauth-v2-multiple-response-types-1_0.html#Security
+// says tokens must not be sent in the query string
+func (m *queryResponseMode) SupportsResponseType(responseType []string) bool {
+	return !issuesTokenInAuthorizationResponse(responseType)
+}
+
+func (m *queryResponseMode) Respond(
+	ctx context.Context,
+	w http.ResponseWriter,
+	client Client,
+	req *AuthorizationRequest,
+	params url.Values,
+) error {
+	return RedirectWithQuery(w, req.FinalRedirectURI, params)
+}
+
+type fragmentResponseMode struct {
+}
+
+// send authorization responses in the fragment of the redirect URI
+func FragmentResponseMode() ResponseModeHandler {
+	return &fragmentResponseMode{}
+}
+
+func (m *fragmentResponseMode) ResponseMode() string {
+	return ResponseModeFragment
+}
+
+func (m *fragmentResponseMode) SupportsResponseType(responseType []string) bool {
+	return true
+}
+
+func (m *fragmentResponseMode) Respond(
+	ctx context.Context,
+	w http.ResponseWriter,
+	client Client,
+	req *AuthorizationRequest,
+	params url.Values,
+) error {
+	return RedirectWithFragment(w, req.FinalRedirectURI, params)
+}
+
+type formPostResponseMode struct {
+}
+
+// send authorization responses with an auto submitting HTML form, see
+// https://openid.net/specs/oauth-v2-form-post-response-mode-1_0.html
+func FormPostResponseMode() ResponseModeHandler {
+	return &formPostResponseMode{}
+}
+
+func (m *formPostResponseMode) ResponseMode() string {
+	return ResponseModeFormPost
+}
+
+func (m *formPostResponseMode) SupportsResponseType(responseType []string) bool {
+	return true
+}
+
+func (m *formPostResponseMode) Respond(
+	ctx context.Context,
+	w http.ResponseWriter,
+	client Client,
+	req *AuthorizationRequest,
+	params url.Values,
+) error {
+	return RespondWithFormPost(w, req.FinalRedirectURI, params)
+}
+
+// redirect to the redirect URI with the parameters added to its query string,
+// any existing query string values in the redirect URI are preserved.
+func RedirectWithQuery(w http.ResponseWriter, redirectUri string, params url.Values) error {
+	u, err := url.Parse(redirectUri)
+	if err != nil {
+		return err
+	}
+
+	query := u.Query()
+	for k, vals := range params {
+		query[k] = vals
+	}
+	u.RawQuery = query.Encode()
+
+	return redirect(w, u.String())
+}
+
+// redirect to the redirect URI with the parameters in the fragment.
+func RedirectWithFragment(w http.ResponseWriter, redirectUri string, params url.Values) error {
+	u, err := url.Parse(redirectUri)
+	if err != nil {
+		return err
+	}
+
+	// https://datatracker.ietf.org/doc/html/rfc6749#section-3.1.2
+	// redirect URIs must not include a fragment, so this replaces anything there
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	return redirect(w, u.String()+"#"+params.Encode())
+}
+
+var formPostTemplate = template.Must(template.New("form_post").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Submit This Form</title>
+</head>
+<body onload="document.forms[0].submit()">
+<form method="post" action="{{ .Action }}">
+{{- range $name, $values := .Params }}{{ range $values }}
+<input type="hidden" name="{{ $name }}" value="{{ . }}">
+{{- end }}{{ end }}
+<noscript><button type="submit">Continue</button></noscript>
+</form>
+</body>
+</html>
+`))
+
+// render an HTML page that auto submits the parameters to the redirect URI
+func RespondWithFormPost(w http.ResponseWriter, redirectUri string, params url.Values) error {
+	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	return formPostTemplate.Execute(w, struct {
+		Action string
+		Params url.Values
+	}{
+		// escaped by the template, unsafe schemes like `javascript:` are replaced
+		Action: redirectUri,
+		Params: params,
+	})
+}
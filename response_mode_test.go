@@ -0,0 +1,149 @@
+package oauth2server_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+func TestDefaultResponseMode_ReturnsExpectedModeForResponseTypes(t *testing.T) {
+	cases := []struct {
+		responseType []string
+		expected     string
+	}{
+		{[]string{"code"}, oauth2server.ResponseModeQuery},
+		{[]string{"none"}, oauth2server.ResponseModeQuery},
+		{[]string{"id_token"}, oauth2server.ResponseModeFragment},
+		{[]string{"code", "id_token"}, oauth2server.ResponseModeFragment},
+		{[]string{"code", "token"}, oauth2server.ResponseModeFragment},
+	}
+
+	for _, c := range cases {
+		mode := oauth2server.DefaultResponseMode(c.responseType)
+		if mode != c.expected {
+			t.Errorf("bad default response mode for %v: %q != %q", c.responseType, mode, c.expected)
+		}
+	}
+}
+
+func TestQueryResponseMode_DoesNotSupportResponseTypesThatIssueTokens(t *testing.T) {
+	mode := oauth2server.QueryResponseMode()
+
+	if !mode.SupportsResponseType([]string{"code"}) {
+		t.Error("query response mode should support the code response type")
+	}
+	if mode.SupportsResponseType([]string{"code", "id_token"}) {
+		t.Error("query response mode should not support the id_token response type")
+	}
+}
+
+func TestQueryResponseMode_RedirectsWithParametersInQueryString(t *testing.T) {
+	rec := httptest.NewRecorder()
+	params := url.Values{}
+	params.Set("code", "abc")
+	params.Set("state", "123")
+
+	err := oauth2server.QueryResponseMode().Respond(
+		context.Background(),
+		rec,
+		oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}),
+		&oauth2server.AuthorizationRequest{FinalRedirectURI: testRedirectUri + "?existing=yes"},
+		params,
+	)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusFound {
+		t.Errorf("expected a %d response, got %d", http.StatusFound, rec.Code)
+	}
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("could not parse location header: %v", err)
+	}
+	query := location.Query()
+	if query.Get("existing") != "yes" {
+		t.Errorf("expected existing query string values to be kept: %q", location)
+	}
+	if query.Get("code") != "abc" || query.Get("state") != "123" {
+		t.Errorf("expected response params in the query string: %q", location)
+	}
+}
+
+func TestFragmentResponseMode_RedirectsWithParametersInFragment(t *testing.T) {
+	rec := httptest.NewRecorder()
+	params := url.Values{}
+	params.Set("id_token", "abc")
+
+	err := oauth2server.FragmentResponseMode().Respond(
+		context.Background(),
+		rec,
+		oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}),
+		&oauth2server.AuthorizationRequest{FinalRedirectURI: testRedirectUri},
+		params,
+	)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusFound {
+		t.Errorf("expected a %d response, got %d", http.StatusFound, rec.Code)
+	}
+	location := rec.Header().Get("Location")
+	if location != testRedirectUri+"#id_token=abc" {
+		t.Errorf("bad location header: %q", location)
+	}
+}
+
+func TestFormPostResponseMode_RendersAutoSubmittingForm(t *testing.T) {
+	rec := httptest.NewRecorder()
+	params := url.Values{}
+	params.Set("code", `"><script>`)
+	params.Set("state", "123")
+
+	err := oauth2server.FormPostResponseMode().Respond(
+		context.Background(),
+		rec,
+		oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}),
+		&oauth2server.AuthorizationRequest{FinalRedirectURI: testRedirectUri},
+		params,
+	)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected a %d response, got %d", http.StatusOK, rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.Contains(ct, "text/html") {
+		t.Errorf("expected an HTML response, got %q", ct)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `action="`+testRedirectUri+`"`) {
+		t.Errorf("expected form to post to the redirect URI: %s", body)
+	}
+	if !strings.Contains(body, `name="state" value="123"`) {
+		t.Errorf("expected state input in form: %s", body)
+	}
+	if strings.Contains(body, "<script>") {
+		t.Errorf("expected params to be escaped: %s", body)
+	}
+}
+
+func TestRespondWithFormPost_DoesNotRenderUnsafeActions(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	err := oauth2server.RespondWithFormPost(rec, "javascript:alert(1)", url.Values{"state": {"123"}})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body := rec.Body.String(); strings.Contains(body, "javascript:") {
+		t.Errorf("expected the javascript: action to be sanitized: %s", body)
+	}
+}
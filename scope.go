@@ -2,6 +2,7 @@ package oauth2server
 
 import (
 	"context"
+	"slices"
 )
 
 // rather than having scopes be an "entity" this is the extension point that
@@ -53,3 +54,113 @@ func (v *allowedScopeValidator) ValidateScopes(ctx context.Context, scopes []str
 
 	return nil
 }
+
+// an extension of ScopeValidator for validators that depend on who the scopes
+// are for, eg scopes tied to projects the user is a member of.
+type ScopeValidatorWithSubject interface {
+	ScopeValidator
+
+	// like ValidateScopes, but with the client and user. The user is nil when
+	// no user is involved, eg the `client_credentials` grant. Authorization
+	// requests are checked with ValidateScopes before the end-user logs in,
+	// then with this once they approve the request.
+	ValidateScopesFor(ctx context.Context, client Client, user User, scopes []string) error
+}
+
+// extension point to let clients allowlist scopes
+type ClientAllowsScopes interface {
+	AllowsScope(scope string) bool
+}
+
+// extension point for the scopes a client is given when an authorization
+// request has no `scope` parameter.
+type ClientWithDefaultScopes interface {
+	DefaultScopes() []string
+}
+
+// validators that implement this, like ScopeRegistry, have requested scopes
+// normalized after they are validated.
+type scopeNormalizer interface {
+	Normalize(scopes []string) []string
+}
+
+// check the scopes against the client's allowlist, then validate them with the
+// client and user if the validator supports it. A nil validator only checks
+// the allowlist. Non OAuthErrors are transformed to a server_error.
+func ValidateScopesFor(ctx context.Context, validator ScopeValidator, client Client, user User, scopes []string) *OAuthError {
+	if err := validateClientScopes(validator, client, scopes); err != nil {
+		return err
+	}
+
+	if validator == nil {
+		return nil
+	}
+
+	if v, ok := validator.(ScopeValidatorWithSubject); ok {
+		return MaybeWrapError(v.ValidateScopesFor(ctx, client, user, scopes))
+	}
+
+	return MaybeWrapError(validator.ValidateScopes(ctx, scopes))
+}
+
+// the scopes along with every scope they imply if the validator is a
+// ScopeRegistry, so checks on individual scopes cannot be skipped by asking
+// for a parent scope or an alias.
+func expandScopes(validator ScopeValidator, scopes []string) []string {
+	if registry, ok := validator.(ScopeRegistry); ok {
+		return registry.Expand(scopes)
+	}
+
+	return scopes
+}
+
+// with a ScopeRegistry the client must allow every implied scope as well
+func validateClientScopes(validator ScopeValidator, client Client, scopes []string) *OAuthError {
+	allows, ok := client.(ClientAllowsScopes)
+	if !ok {
+		return nil
+	}
+
+	var notAllowed []string
+	for _, s := range expandScopes(validator, scopes) {
+		if !allows.AllowsScope(s) {
+			notAllowed = append(notAllowed, s)
+		}
+	}
+
+	if len(notAllowed) > 0 {
+		e := InvalidScope(notAllowed)
+		e.Cause = ErrScopeNotAllowed
+		return e
+	}
+
+	return nil
+}
+
+// apply the client's default scopes if none were requested, then validate and
+// normalize them before checking the client's allowlist. The user is not known
+// yet so ValidateScopesFor is left until the request is approved.
+func (s *defaultAuthorizationServer) validateAuthorizationScopes(ctx context.Context, client Client, authReq *AuthorizationRequest) *OAuthError {
+	if len(authReq.Scope) == 0 {
+		if defaults, ok := client.(ClientWithDefaultScopes); ok {
+			authReq.Scope = slices.Clone(defaults.DefaultScopes())
+		}
+	}
+
+	// validated before normalizing so deprecated aliases are reported
+	if err := MaybeWrapError(s.scopeValidator.ValidateScopes(ctx, authReq.Scope)); err != nil {
+		return err
+	}
+
+	authReq.Scope = normalizeScopes(s.scopeValidator, authReq.Scope)
+
+	return validateClientScopes(s.scopeValidator, client, authReq.Scope)
+}
+
+func normalizeScopes(validator ScopeValidator, scopes []string) []string {
+	if normalizer, ok := validator.(scopeNormalizer); ok && len(scopes) > 0 {
+		return normalizer.Normalize(scopes)
+	}
+
+	return scopes
+}
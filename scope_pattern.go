@@ -0,0 +1,191 @@
+package oauth2server
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// the types of parameters allowed in scope patterns, eg `project:{id:int}:read`
+const (
+	// anything other than whitespace or `:`, the default
+	ScopeParamString = "string"
+
+	// an unsigned integer
+	ScopeParamInt = "int"
+
+	// an email address, eg `mailbox:{address:email}`
+	ScopeParamEmail = "email"
+)
+
+var scopeParamPatterns = map[string]string{
+	ScopeParamString: `[^\s:]+`,
+	ScopeParamInt:    `[0-9]+`,
+	ScopeParamEmail:  `[^\s:@]+@[^\s:@]+`,
+}
+
+var scopeParamPlaceholder = regexp.MustCompile(`\{([a-zA-Z_][a-zA-Z0-9_]*)(?::([a-z]+))?\}`)
+
+// the parameter values parsed from a scope, keyed by parameter name.
+type ScopeParams map[string]string
+
+// the parameter as an integer, zero if it's missing or not an integer.
+func (p ScopeParams) Int(name string) int64 {
+	i, _ := strconv.ParseInt(p[name], 10, 64)
+	return i
+}
+
+// checks whether the client or user may access the parameter values in a
+// scope. The user is nil when no user is involved, see ScopeValidatorWithSubject.
+// Any error returned will be propagated as a server error.
+type ScopeAccessCheck func(ctx context.Context, client Client, user User, params ScopeParams) (bool, error)
+
+// a scope template with typed parameters, eg `project:{id:int}:read` or
+// `mailbox:{address:email}`. Templates without parameters match themselves.
+type ScopePattern struct {
+	template string
+	re       *regexp.Regexp
+	check    ScopeAccessCheck
+}
+
+// check may be nil if the scope only needs to match the template.
+func NewScopePattern(template string, check ScopeAccessCheck) (*ScopePattern, error) {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+
+	seen := map[string]bool{}
+	last := 0
+	for _, m := range scopeParamPlaceholder.FindAllStringSubmatchIndex(template, -1) {
+		name := template[m[2]:m[3]]
+		paramType := ScopeParamString
+		if m[4] >= 0 {
+			paramType = template[m[4]:m[5]]
+		}
+
+		paramPattern, ok := scopeParamPatterns[paramType]
+		if !ok {
+			return nil, fmt.Errorf("scope template %q: unknown parameter type %q", template, paramType)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("scope template %q: duplicate parameter %q", template, name)
+		}
+		seen[name] = true
+
+		pattern.WriteString(regexp.QuoteMeta(template[last:m[0]]))
+		pattern.WriteString(fmt.Sprintf("(?P<%s>%s)", name, paramPattern))
+		last = m[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(template[last:]))
+	pattern.WriteString("$")
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, fmt.Errorf("scope template %q: %w", template, err)
+	}
+
+	return &ScopePattern{
+		template: template,
+		re:       re,
+		check:    check,
+	}, nil
+}
+
+// like NewScopePattern, but panics if the template is invalid.
+func MustScopePattern(template string, check ScopeAccessCheck) *ScopePattern {
+	p, err := NewScopePattern(template, check)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+func (p *ScopePattern) Template() string {
+	return p.template
+}
+
+// parse the parameters from scope, false if the scope does not match the template.
+func (p *ScopePattern) Match(scope string) (ScopeParams, bool) {
+	m := p.re.FindStringSubmatch(scope)
+	if m == nil {
+		return nil, false
+	}
+
+	params := ScopeParams{}
+	for i, name := range p.re.SubexpNames() {
+		if name != "" {
+			params[name] = m[i]
+		}
+	}
+
+	return params, true
+}
+
+type scopePatternValidator struct {
+	patterns []*ScopePattern
+}
+
+// allow scopes that match one of the patterns. Access checks only run when
+// validating with ValidateScopesFor, ValidateScopes only checks that scopes
+// match a template.
+func AllowScopePatterns(patterns ...*ScopePattern) ScopeValidatorWithSubject {
+	return &scopePatternValidator{
+		patterns: patterns,
+	}
+}
+
+func (v *scopePatternValidator) ValidateScopes(ctx context.Context, scopes []string) error {
+	var invalidScopes []string
+	for _, s := range scopes {
+		if _, _, ok := v.match(s); !ok {
+			invalidScopes = append(invalidScopes, s)
+		}
+	}
+
+	if len(invalidScopes) > 0 {
+		return InvalidScope(invalidScopes)
+	}
+
+	return nil
+}
+
+func (v *scopePatternValidator) ValidateScopesFor(ctx context.Context, client Client, user User, scopes []string) error {
+	var invalidScopes []string
+	for _, s := range scopes {
+		pattern, params, ok := v.match(s)
+		if !ok {
+			invalidScopes = append(invalidScopes, s)
+			continue
+		}
+		if pattern.check == nil {
+			continue
+		}
+
+		allowed, err := pattern.check(ctx, client, user, params)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			invalidScopes = append(invalidScopes, s)
+		}
+	}
+
+	if len(invalidScopes) > 0 {
+		return InvalidScope(invalidScopes)
+	}
+
+	return nil
+}
+
+// the first pattern that matches the scope
+func (v *scopePatternValidator) match(scope string) (*ScopePattern, ScopeParams, bool) {
+	for _, p := range v.patterns {
+		if params, ok := p.Match(scope); ok {
+			return p, params, true
+		}
+	}
+
+	return nil, nil, false
+}
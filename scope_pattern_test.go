@@ -0,0 +1,120 @@
+package oauth2server_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+// the user is a member of project 1234 only
+func projectMember(ctx context.Context, client oauth2server.Client, user oauth2server.User, params oauth2server.ScopeParams) (bool, error) {
+	return user != nil && user.ID() == "user" && params.Int("id") == 1234, nil
+}
+
+func newTestScopePatternValidator() oauth2server.ScopeValidatorWithSubject {
+	return oauth2server.AllowScopePatterns(
+		oauth2server.MustScopePattern("openid", nil),
+		oauth2server.MustScopePattern("project:{id:int}:{access}", projectMember),
+		oauth2server.MustScopePattern("mailbox:{address:email}", nil),
+	)
+}
+
+func TestNewScopePattern_ErrorsWithInvalidTemplates(t *testing.T) {
+	templates := []string{
+		"project:{id:float}",
+		"project:{id}:{id}",
+	}
+
+	for _, template := range templates {
+		t.Run(template, func(t *testing.T) {
+			_, err := oauth2server.NewScopePattern(template, nil)
+
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestScopePattern_Match_ParsesTypedParams(t *testing.T) {
+	cases := []struct {
+		template string
+		scope    string
+		expected oauth2server.ScopeParams
+	}{
+		{"project:{id:int}:read", "project:1234:read", oauth2server.ScopeParams{"id": "1234"}},
+		{"project:{id:int}:read", "project:abc:read", nil},
+		{"project:{id:int}:read", "project:1234:write", nil},
+		{"mailbox:{address:email}", "mailbox:user@example.com", oauth2server.ScopeParams{"address": "user@example.com"}},
+		{"mailbox:{address:email}", "mailbox:user", nil},
+		{"org.{org}:admin", "org.acme:admin", oauth2server.ScopeParams{"org": "acme"}},
+		{"org.{org}:admin", "orgXacme:admin", nil},
+		{"openid", "openid", oauth2server.ScopeParams{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.scope, func(t *testing.T) {
+			params, ok := oauth2server.MustScopePattern(c.template, nil).Match(c.scope)
+
+			if ok != (c.expected != nil) {
+				t.Fatalf("expected match %v, got %v", c.expected != nil, ok)
+			}
+			if diff := cmp.Diff(c.expected, params); diff != "" {
+				t.Errorf("unexpected params (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAllowScopePatterns_ValidateScopes_OnlyChecksTemplates(t *testing.T) {
+	validator := newTestScopePatternValidator()
+
+	err := validator.ValidateScopes(context.Background(), []string{"openid", "project:99:read", "mailbox:user@example.com", "nope"})
+
+	oauthErr, ok := oauth2server.AsOAuthError(err)
+	if !ok {
+		t.Fatalf("Expected an oauth error, got %T", err)
+	}
+	if oauthErr.ErrorType != oauth2server.ErrorTypeInvalidScope || oauthErr.ErrorDescription != "invalid scopes: nope" {
+		t.Errorf("expected only the unmatched scope to be invalid, got %v", oauthErr)
+	}
+}
+
+func TestAllowScopePatterns_ValidateScopesFor_ChecksAccess(t *testing.T) {
+	validator := newTestScopePatternValidator()
+	client := oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri})
+
+	err := validator.ValidateScopesFor(context.Background(), client, &testUser{id: "user"}, []string{"openid", "project:1234:read"})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err = validator.ValidateScopesFor(context.Background(), client, &testUser{id: "user"}, []string{"project:1234:read", "project:99:read"})
+	oauthErr, ok := oauth2server.AsOAuthError(err)
+	if !ok || !strings.HasSuffix(oauthErr.ErrorDescription, ": project:99:read") {
+		t.Errorf("expected the inaccessible scope to be invalid, got %v", err)
+	}
+
+	err = validator.ValidateScopesFor(context.Background(), client, nil, []string{"project:1234:read"})
+	if err == nil {
+		t.Error("expected an error without a user")
+	}
+}
+
+func TestAllowScopePatterns_ValidateScopesFor_PropagatesCheckErrors(t *testing.T) {
+	checkErr := errors.New("oops")
+	validator := oauth2server.AllowScopePatterns(oauth2server.MustScopePattern("project:{id:int}", func(ctx context.Context, client oauth2server.Client, user oauth2server.User, params oauth2server.ScopeParams) (bool, error) {
+		return false, checkErr
+	}))
+
+	err := oauth2server.ValidateScopesFor(context.Background(), validator, nil, nil, []string{"project:1"})
+
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeServerError || !errors.Is(err, checkErr) {
+		t.Errorf("expected a server error caused by the check, got %v", err)
+	}
+}
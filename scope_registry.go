@@ -0,0 +1,160 @@
+package oauth2server
+
+import (
+	"context"
+	"slices"
+)
+
+// A registry of known scopes, a scope may imply other scopes, eg `repo`
+// implies `repo:read` and `repo:write`, and old scope names may be aliased to
+// new ones. Validation rejects any scope that is not registered or an alias.
+type ScopeRegistry interface {
+	ScopeValidator
+
+	// resolve aliases and remove duplicates, order is kept. Unknown scopes are
+	// left as they are.
+	Normalize(scopes []string) []string
+
+	// the normalized scopes along with every scope they imply
+	Expand(scopes []string) []string
+
+	// whether the held scopes, or the scopes they imply, include every
+	// required scope. Eg holding `repo` satisfies `repo:read`.
+	Satisfies(held []string, required ...string) bool
+
+	// like Satisfies, but returns an `insufficient_scope` error for resource
+	// servers to send back if the held scopes are not enough.
+	Require(held []string, required ...string) *OAuthError
+}
+
+type scopeRegistry struct {
+	implies    map[string][]string
+	aliases    map[string]string
+	deprecated func(ctx context.Context, alias string, scope string)
+}
+
+type ScopeRegistryOption func(*scopeRegistry)
+
+// register a scope along with the scopes it implies. Implied scopes are
+// registered as well.
+func WithRegisteredScope(scope string, implies ...string) ScopeRegistryOption {
+	return func(r *scopeRegistry) {
+		r.implies[scope] = append(r.implies[scope], implies...)
+		for _, implied := range implies {
+			if _, ok := r.implies[implied]; !ok {
+				r.implies[implied] = nil
+			}
+		}
+	}
+}
+
+// accept alias in place of scope, the alias is replaced when scopes are
+// normalized.
+func WithScopeAlias(alias string, scope string) ScopeRegistryOption {
+	return func(r *scopeRegistry) {
+		r.aliases[alias] = scope
+	}
+}
+
+// called for each alias found when validating scopes so clients still using
+// old scope names can be found.
+func WithDeprecatedScopeReporter(report func(ctx context.Context, alias string, scope string)) ScopeRegistryOption {
+	return func(r *scopeRegistry) {
+		r.deprecated = report
+	}
+}
+
+func NewScopeRegistry(opts ...ScopeRegistryOption) ScopeRegistry {
+	r := &scopeRegistry{
+		implies: make(map[string][]string),
+		aliases: make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+func (r *scopeRegistry) ValidateScopes(ctx context.Context, scopes []string) error {
+	var invalidScopes []string
+	for _, s := range scopes {
+		resolved := r.resolve(s)
+		if resolved != s && r.deprecated != nil {
+			r.deprecated(ctx, s, resolved)
+		}
+
+		if _, ok := r.implies[resolved]; !ok {
+			invalidScopes = append(invalidScopes, s)
+		}
+	}
+
+	if len(invalidScopes) > 0 {
+		return InvalidScope(invalidScopes)
+	}
+
+	return nil
+}
+
+// follow aliases until a scope that is not an alias is found
+func (r *scopeRegistry) resolve(scope string) string {
+	seen := []string{scope}
+	for {
+		next, ok := r.aliases[scope]
+		if !ok || slices.Contains(seen, next) {
+			return scope
+		}
+		seen = append(seen, next)
+		scope = next
+	}
+}
+
+func (r *scopeRegistry) Normalize(scopes []string) []string {
+	normalized := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		resolved := r.resolve(s)
+		if !slices.Contains(normalized, resolved) {
+			normalized = append(normalized, resolved)
+		}
+	}
+
+	return normalized
+}
+
+func (r *scopeRegistry) Expand(scopes []string) []string {
+	expanded := r.Normalize(scopes)
+	for i := 0; i < len(expanded); i++ {
+		for _, implied := range r.implies[expanded[i]] {
+			if !slices.Contains(expanded, implied) {
+				expanded = append(expanded, implied)
+			}
+		}
+	}
+
+	return expanded
+}
+
+func (r *scopeRegistry) Satisfies(held []string, required ...string) bool {
+	return len(r.missing(held, required)) == 0
+}
+
+func (r *scopeRegistry) Require(held []string, required ...string) *OAuthError {
+	if missing := r.missing(held, required); len(missing) > 0 {
+		return InsufficientScope(missing...)
+	}
+
+	return nil
+}
+
+func (r *scopeRegistry) missing(held []string, required []string) []string {
+	expanded := r.Expand(held)
+
+	var missing []string
+	for _, s := range r.Normalize(required) {
+		if !slices.Contains(expanded, s) {
+			missing = append(missing, s)
+		}
+	}
+
+	return missing
+}
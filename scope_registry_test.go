@@ -0,0 +1,130 @@
+package oauth2server_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+func newTestScopeRegistry(opts ...oauth2server.ScopeRegistryOption) oauth2server.ScopeRegistry {
+	return oauth2server.NewScopeRegistry(append([]oauth2server.ScopeRegistryOption{
+		oauth2server.WithRegisteredScope("repo", "repo:read", "repo:write"),
+		oauth2server.WithRegisteredScope("admin", "repo", "user"),
+		oauth2server.WithScopeAlias("repository", "repo"),
+		oauth2server.WithScopeAlias("public_repo", "repo:read"),
+	}, opts...)...)
+}
+
+func TestScopeRegistry_ValidateScopes_AllowsRegisteredImpliedAndAliasedScopes(t *testing.T) {
+	registry := newTestScopeRegistry()
+
+	err := registry.ValidateScopes(context.Background(), []string{"repo", "repo:write", "user", "repository"})
+
+	if err != nil {
+		t.Errorf("expected scopes to be valid, got %v", err)
+	}
+}
+
+func TestScopeRegistry_ValidateScopes_ReturnsErrorForUnknownScopes(t *testing.T) {
+	registry := newTestScopeRegistry()
+
+	err := registry.ValidateScopes(context.Background(), []string{"repo", "unknown"})
+
+	oauthErr, ok := oauth2server.AsOAuthError(err)
+	if !ok {
+		t.Fatalf("Expected an oauth error, got %T", err)
+	}
+	if oauthErr.ErrorType != oauth2server.ErrorTypeInvalidScope {
+		t.Errorf("expected %q error type, got %q", oauth2server.ErrorTypeInvalidScope, oauthErr.ErrorType)
+	}
+	if !strings.Contains(oauthErr.ErrorDescription, "unknown") || strings.Contains(oauthErr.ErrorDescription, "repo") {
+		t.Errorf("error description should contain only the invalid scope, got %q", oauthErr.ErrorDescription)
+	}
+}
+
+func TestScopeRegistry_ValidateScopes_ReportsDeprecatedAliases(t *testing.T) {
+	reported := map[string]string{}
+	registry := newTestScopeRegistry(oauth2server.WithDeprecatedScopeReporter(func(ctx context.Context, alias string, scope string) {
+		reported[alias] = scope
+	}))
+
+	err := registry.ValidateScopes(context.Background(), []string{"repository", "public_repo", "user"})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"repository": "repo", "public_repo": "repo:read"}
+	if diff := cmp.Diff(expected, reported); diff != "" {
+		t.Errorf("unexpected deprecated scopes (-want +got):\n%s", diff)
+	}
+}
+
+func TestScopeRegistry_Normalize_ResolvesAliasesAndRemovesDuplicates(t *testing.T) {
+	registry := newTestScopeRegistry()
+
+	normalized := registry.Normalize([]string{"repository", "user", "repo", "public_repo", "user", "other"})
+
+	expected := []string{"repo", "user", "repo:read", "other"}
+	if diff := cmp.Diff(expected, normalized); diff != "" {
+		t.Errorf("unexpected scopes (-want +got):\n%s", diff)
+	}
+}
+
+func TestScopeRegistry_Expand_IncludesImpliedScopes(t *testing.T) {
+	registry := newTestScopeRegistry()
+
+	expanded := registry.Expand([]string{"admin"})
+
+	expected := []string{"admin", "repo", "user", "repo:read", "repo:write"}
+	if diff := cmp.Diff(expected, expanded); diff != "" {
+		t.Errorf("unexpected scopes (-want +got):\n%s", diff)
+	}
+}
+
+func TestScopeRegistry_Satisfies(t *testing.T) {
+	registry := newTestScopeRegistry()
+
+	cases := []struct {
+		name     string
+		held     []string
+		required []string
+		expected bool
+	}{
+		{"exact", []string{"repo:read"}, []string{"repo:read"}, true},
+		{"implied", []string{"repo"}, []string{"repo:read", "repo:write"}, true},
+		{"transitively implied", []string{"admin"}, []string{"repo:write"}, true},
+		{"aliased held", []string{"repository"}, []string{"repo:write"}, true},
+		{"aliased required", []string{"repo:read"}, []string{"public_repo"}, true},
+		{"narrower held", []string{"repo:read"}, []string{"repo"}, false},
+		{"missing", []string{"user"}, []string{"repo:read"}, false},
+		{"nothing required", nil, nil, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := registry.Satisfies(c.held, c.required...); got != c.expected {
+				t.Errorf("expected %v, got %v", c.expected, got)
+			}
+		})
+	}
+}
+
+func TestScopeRegistry_Require_ReturnsInsufficientScopeWithMissingScopes(t *testing.T) {
+	registry := newTestScopeRegistry()
+
+	err := registry.Require([]string{"repo:read"}, "repo:read", "repo:write", "user")
+
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeInsufficientScope {
+		t.Fatalf("expected insufficient_scope error, got %v", err)
+	}
+	if !strings.Contains(err.ErrorDescription, "repo:write user") || strings.Contains(err.ErrorDescription, "repo:read") {
+		t.Errorf("expected description to name the missing scopes, got %q", err.ErrorDescription)
+	}
+	if err := registry.Require([]string{"admin"}, "repo:read", "user"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -2,9 +2,14 @@ package oauth2server_test
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/chrisguitarguy/oauth2server"
 )
 
@@ -47,3 +52,242 @@ func TestAllowScopes_ReturnsErrorIfInvalidScopesArePassed(t *testing.T) {
 		t.Errorf("error description should contain invalid scope, got %q", oauthErr.ErrorDescription)
 	}
 }
+
+func TestValidateScopesFor_FallsBackToValidateScopes(t *testing.T) {
+	validator := oauth2server.AllowScopes("one")
+
+	err := oauth2server.ValidateScopesFor(context.Background(), validator, nil, nil, []string{"one", "two"})
+
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeInvalidScope {
+		t.Errorf("expected %q error, got %v", oauth2server.ErrorTypeInvalidScope, err)
+	}
+}
+
+type scopedClient struct {
+	oauth2server.Client
+	allowed  []string
+	defaults []string
+}
+
+func (c *scopedClient) AllowsScope(scope string) bool {
+	return slices.Contains(c.allowed, scope)
+}
+
+func (c *scopedClient) DefaultScopes() []string {
+	return c.defaults
+}
+
+func startScopeTest(t *testing.T, opts ...oauth2server.ServerOption) (*authorizationServerTestCase, *scopedClient) {
+	t.Helper()
+
+	client := &scopedClient{
+		Client:   oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}),
+		allowed:  []string{"read", "write", "project:1234", "project:99"},
+		defaults: []string{"read"},
+	}
+	tc := startAuthorizationServerTest(t, append([]oauth2server.ServerOption{
+		oauth2server.WithAuthorizationHandler(&spyAuthorizationHandler{responseType: "code"}),
+	}, opts...)...)
+	tc.clients.Add(client)
+
+	return tc, client
+}
+
+func validateScopeTestRequest(t *testing.T, tc *authorizationServerTestCase, scope string) (*oauth2server.AuthorizationRequest, *oauth2server.OAuthError) {
+	t.Helper()
+
+	req := newAuthorizeRequestWithQueryString(t, map[string]string{
+		oauth2server.ParamResponseType: "code",
+		oauth2server.ParamClientID:     testClientId,
+		oauth2server.ParamScope:        scope,
+	})
+
+	return tc.server.ValidateAuthorizationRequest(req.Context(), req)
+}
+
+func TestValidateScopesFor_RejectsScopesTheClientDoesNotAllow(t *testing.T) {
+	client := &scopedClient{allowed: []string{"read"}}
+
+	err := oauth2server.ValidateScopesFor(context.Background(), nil, client, nil, []string{"read", "admin"})
+
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeInvalidScope || !errors.Is(err, oauth2server.ErrScopeNotAllowed) {
+		t.Fatalf("expected invalid_scope caused by ErrScopeNotAllowed, got %v", err)
+	}
+	if err.ErrorDescription != "invalid scopes: admin" {
+		t.Errorf("expected description to name the scope, got %q", err.ErrorDescription)
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_AppliesDefaultScopes(t *testing.T) {
+	tc, _ := startScopeTest(t)
+
+	authReq, err := validateScopeTestRequest(t, tc, "")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"read"}, authReq.Scope); diff != "" {
+		t.Errorf("expected default scopes (-want +got):\n%s", diff)
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_ValidatesScopes(t *testing.T) {
+	cases := []struct {
+		name  string
+		scope string
+		cause error
+	}{
+		{"not allowed for client", "read admin", oauth2server.ErrScopeNotAllowed},
+		{"rejected by validator", "read write", nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tc, _ := startScopeTest(t, oauth2server.WithScopeValidator(oauth2server.AllowScopes("read", "admin")))
+
+			authReq, err := validateScopeTestRequest(t, tc, c.scope)
+
+			tc.assertNotNilAuthRequest(t, authReq)
+			if err == nil || err.ErrorType != oauth2server.ErrorTypeInvalidScope {
+				t.Fatalf("expected invalid_scope error, got %v", err)
+			}
+			if c.cause != nil && !errors.Is(err, c.cause) {
+				t.Errorf("expected error caused by %v, got %v", c.cause, err.Cause)
+			}
+		})
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_NormalizesScopesWithRegistry(t *testing.T) {
+	tc, client := startScopeTest(t, oauth2server.WithScopeValidator(oauth2server.NewScopeRegistry(
+		oauth2server.WithRegisteredScope("read"),
+		oauth2server.WithScopeAlias("view", "read"),
+	)))
+	client.allowed = append(client.allowed, "view")
+
+	authReq, err := validateScopeTestRequest(t, tc, "view read")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"read"}, authReq.Scope); diff != "" {
+		t.Errorf("expected normalized scopes (-want +got):\n%s", diff)
+	}
+}
+
+func TestDefaultAuthorizationServer_ApproveAuthorizationRequest_ValidatesScopesWithTheUser(t *testing.T) {
+	tc, _ := startScopeTest(t, oauth2server.WithScopeValidator(oauth2server.AllowScopePatterns(
+		oauth2server.MustScopePattern("project:{id:int}", projectMember),
+	)))
+	authReq, err := validateScopeTestRequest(t, tc, "project:99")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = tc.server.CompleteAuthorizationRequest(context.Background(), authReq, &testUser{id: "user"})
+
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeInvalidScope {
+		t.Errorf("expected invalid_scope error, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_Token_ValidatesScopes(t *testing.T) {
+	clients := oauth2server.NewInMemoryClientRepository()
+	client := &scopedClient{
+		Client:  oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}),
+		allowed: []string{"read", "write"},
+	}
+	clients.Add(client)
+	issuer := oauth2server.NewTokenIssuer(oauth2server.NewInMemoryAccessTokenRepository())
+	server := oauth2server.NewAuthorizationServer(
+		clients,
+		oauth2server.WithGrant(oauth2server.NewAuthorizationCodeGrant(clients, oauth2server.NewInMemoryAuthorizationCodeRepository(), issuer)),
+	)
+	params, authErr := server.CompleteAuthorizationRequest(context.Background(), &oauth2server.AuthorizationRequest{
+		ClientID:     testClientId,
+		RedirectURI:  testRedirectUri,
+		ResponseType: []string{oauth2server.ResponseTypeCode},
+		Scope:        []string{"read", "write"},
+	}, &testUser{id: "user"})
+	if authErr != nil {
+		t.Fatalf("unexpected error: %v", authErr)
+	}
+
+	// the client lost access to `write` after the user approved it
+	client.allowed = []string{"read"}
+	req := createRequestWithFormBody(http.MethodPost, "/token", map[string]string{
+		oauth2server.ParamGrantType:   oauth2server.GrantTypeAuthorizationCode,
+		oauth2server.ParamCode:        params.Get(oauth2server.ResponseTypeCode),
+		oauth2server.ParamRedirectURI: testRedirectUri,
+	})
+	req.SetBasicAuth(testClientId, testClientSecret)
+
+	resp, err := server.Token(context.Background(), req)
+
+	if resp != nil {
+		t.Errorf("expected no token, got %+v", resp)
+	}
+	if err == nil || err.ErrorType != oauth2server.ErrorTypeInvalidScope || !errors.Is(err, oauth2server.ErrScopeNotAllowed) {
+		t.Errorf("expected invalid_scope caused by ErrScopeNotAllowed, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_ClientMustAllowImpliedScopes(t *testing.T) {
+	tc, client := startScopeTest(t, oauth2server.WithScopeValidator(oauth2server.NewScopeRegistry(
+		oauth2server.WithRegisteredScope("repo", "repo:read", "repo:write"),
+	)))
+	client.allowed = []string{"repo", "repo:read"}
+
+	_, err := validateScopeTestRequest(t, tc, "repo")
+
+	if err == nil || !errors.Is(err, oauth2server.ErrScopeNotAllowed) || err.ErrorDescription != "invalid scopes: repo:write" {
+		t.Errorf("expected the implied scope to not be allowed, got %v", err)
+	}
+}
+
+func TestDefaultAuthorizationServer_ValidateAuthorizationRequest_ChecksAllowlistAfterNormalizing(t *testing.T) {
+	tc, client := startScopeTest(t, oauth2server.WithScopeValidator(oauth2server.NewScopeRegistry(
+		oauth2server.WithRegisteredScope("read"),
+		oauth2server.WithScopeAlias("view", "read"),
+	)))
+	client.allowed = []string{"read"}
+
+	authReq, err := validateScopeTestRequest(t, tc, "view")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"read"}, authReq.Scope); diff != "" {
+		t.Errorf("expected normalized scopes (-want +got):\n%s", diff)
+	}
+}
+
+func TestDefaultAuthorizationServer_Token_NormalizesRefreshTokenScopes(t *testing.T) {
+	tc := startRefreshTokenTest(t)
+	clients := oauth2server.NewInMemoryClientRepository()
+	clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+	server := oauth2server.NewAuthorizationServer(
+		clients,
+		oauth2server.WithGrant(tc.grant),
+		oauth2server.WithScopeValidator(oauth2server.NewScopeRegistry(
+			oauth2server.WithRegisteredScope("read"),
+			oauth2server.WithRegisteredScope("write"),
+			oauth2server.WithScopeAlias("view", "read"),
+		)),
+	)
+	req := createRequestWithFormBody(http.MethodPost, "/token", map[string]string{
+		oauth2server.ParamGrantType:    oauth2server.GrantTypeRefreshToken,
+		oauth2server.ParamRefreshToken: tc.issueRefreshToken(t),
+		oauth2server.ParamScope:        "view",
+	})
+	req.SetBasicAuth(testClientId, testClientSecret)
+
+	resp, err := server.Token(context.Background(), req)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Scope != "read" {
+		t.Errorf("expected the aliased scope to be issued, got %q", resp.Scope)
+	}
+}
@@ -0,0 +1,199 @@
+package oauth2server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// how long a fetched sector identifier document is used before it is fetched again
+const DefaultSectorIdentifierLifetime = time.Hour
+
+// subject identifier types, see https://openid.net/specs/openid-connect-core-1_0.html#SubjectIDTypes
+const (
+	SubjectTypePublic   = "public"
+	SubjectTypePairwise = "pairwise"
+)
+
+// clients that implement this use the hosts listed in their sector identifier
+// document for pairwise subject identifiers instead of their redirect URI host.
+// See https://openid.net/specs/openid-connect-registration-1_0.html#SectorIdentifierValidation
+type ClientWithSectorIdentifierURI interface {
+	// the `sector_identifier_uri`, empty if the client does not have one
+	SectorIdentifierURI() string
+}
+
+// decides the `sub` value each client sees for a user. This is used everywhere
+// a subject is sent to a client: ID tokens, UserInfo, introspection, and
+// logout tokens.
+type SubjectIdentifierStrategy interface {
+	SubjectIdentifier(ctx context.Context, client Client, userId string) (string, error)
+}
+
+type publicSubjectIdentifiers struct{}
+
+// every client sees the user's ID as the subject, this is the default.
+func NewPublicSubjectIdentifiers() SubjectIdentifierStrategy {
+	return &publicSubjectIdentifiers{}
+}
+
+func (s *publicSubjectIdentifiers) SubjectIdentifier(ctx context.Context, client Client, userId string) (string, error) {
+	return userId, nil
+}
+
+type pairwiseSubjectIdentifiers struct {
+	secret         []byte
+	fetcher        Fetcher
+	sectorLifetime time.Duration
+
+	mu      sync.Mutex
+	sectors map[string]sectorIdentifierDocument
+}
+
+type sectorIdentifierDocument struct {
+	redirectUris []string
+	fetchedAt    time.Time
+}
+
+type PairwiseSubjectIdentifiersOption func(*pairwiseSubjectIdentifiers)
+
+// how long fetched sector identifier documents are kept, defaults to
+// DefaultSectorIdentifierLifetime.
+func WithSectorIdentifierLifetime(lifetime time.Duration) PairwiseSubjectIdentifiersOption {
+	return func(s *pairwiseSubjectIdentifiers) {
+		s.sectorLifetime = lifetime
+	}
+}
+
+// each sector sees a different subject for the same user. The subject is an
+// HMAC-SHA256 keyed with secret of the sector identifier and user ID. The
+// sector is the host of the client's `sector_identifier_uri` which is fetched
+// with fetcher and must list every redirect URI of the client. Documents are
+// kept for the sector identifier lifetime rather than fetched for every
+// subject. Clients without one use the host of their redirect URIs, which must
+// all share a host.
+//
+// Changing the secret changes every subject, keep it stable.
+func NewPairwiseSubjectIdentifiers(secret []byte, fetcher Fetcher, opts ...PairwiseSubjectIdentifiersOption) SubjectIdentifierStrategy {
+	s := &pairwiseSubjectIdentifiers{
+		secret:         secret,
+		fetcher:        fetcher,
+		sectorLifetime: DefaultSectorIdentifierLifetime,
+		sectors:        make(map[string]sectorIdentifierDocument),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *pairwiseSubjectIdentifiers) SubjectIdentifier(ctx context.Context, client Client, userId string) (string, error) {
+	sector, err := s.sectorIdentifier(ctx, client)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(sector))
+	mac.Write([]byte{0})
+	mac.Write([]byte(userId))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (s *pairwiseSubjectIdentifiers) sectorIdentifier(ctx context.Context, client Client) (string, error) {
+	if withSector, ok := client.(ClientWithSectorIdentifierURI); ok && withSector.SectorIdentifierURI() != "" {
+		return s.validateSectorIdentifierURI(ctx, client, withSector.SectorIdentifierURI())
+	}
+
+	var host string
+	for _, redirectUri := range client.RedirectURIs() {
+		u, err := url.Parse(redirectUri)
+		if err != nil {
+			return "", err
+		}
+		if host != "" && u.Hostname() != host {
+			return "", ErrSectorIdentifierRequired
+		}
+		host = u.Hostname()
+	}
+
+	if host == "" {
+		return "", ErrSectorIdentifierRequired
+	}
+
+	return host, nil
+}
+
+// the document is a JSON array of redirect URIs, every redirect URI of the
+// client must be in it.
+func (s *pairwiseSubjectIdentifiers) validateSectorIdentifierURI(ctx context.Context, client Client, sectorUri string) (string, error) {
+	u, err := url.Parse(sectorUri)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidSectorIdentifier, err)
+	}
+	if u.Scheme != "https" {
+		return "", ErrSectorIdentifierNotHTTPS
+	}
+
+	listed, err := s.sectorRedirectURIs(ctx, sectorUri)
+	if err != nil {
+		return "", err
+	}
+
+	for _, redirectUri := range client.RedirectURIs() {
+		if !slices.Contains(listed, redirectUri) {
+			return "", fmt.Errorf("%w: %s", ErrSectorIdentifierMismatch, redirectUri)
+		}
+	}
+
+	return u.Hostname(), nil
+}
+
+// the redirect URIs listed in the sector identifier document, fetched again
+// once the cached copy is older than the sector identifier lifetime. Failed
+// fetches are not cached.
+func (s *pairwiseSubjectIdentifiers) sectorRedirectURIs(ctx context.Context, sectorUri string) ([]string, error) {
+	s.mu.Lock()
+	doc, ok := s.sectors[sectorUri]
+	s.mu.Unlock()
+	if ok && time.Since(doc.fetchedAt) < s.sectorLifetime {
+		return doc.redirectUris, nil
+	}
+
+	body, err := s.fetcher.Fetch(ctx, sectorUri)
+	if err != nil {
+		return nil, err
+	}
+
+	var listed []string
+	if err := json.Unmarshal(body, &listed); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSectorIdentifier, err)
+	}
+
+	s.mu.Lock()
+	s.sectors[sectorUri] = sectorIdentifierDocument{
+		redirectUris: listed,
+		fetchedAt:    time.Now(),
+	}
+	s.mu.Unlock()
+
+	return listed, nil
+}
+
+// the subject for the client, the user ID if no strategy is configured
+func subjectIdentifier(ctx context.Context, subjects SubjectIdentifierStrategy, client Client, userId string) (string, error) {
+	if subjects == nil {
+		return userId, nil
+	}
+
+	return subjects.SubjectIdentifier(ctx, client, userId)
+}
@@ -0,0 +1,266 @@
+package oauth2server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+const testSectorIdentifierUri = "https://sector.example.com/redirect_uris.json"
+
+var testPairwiseSecret = []byte("pairwise-secret")
+
+type sectorClient struct {
+	oauth2server.Client
+	sectorIdentifierUri string
+}
+
+func (c *sectorClient) SectorIdentifierURI() string {
+	return c.sectorIdentifierUri
+}
+
+func TestPublicSubjectIdentifiers_UsesUserID(t *testing.T) {
+	client := oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri})
+
+	sub, err := oauth2server.NewPublicSubjectIdentifiers().SubjectIdentifier(context.Background(), client, "user")
+
+	if err != nil || sub != "user" {
+		t.Errorf("expected user ID as subject, got %q %v", sub, err)
+	}
+}
+
+func TestPairwiseSubjectIdentifiers_DiffersBetweenSectors(t *testing.T) {
+	subjects := oauth2server.NewPairwiseSubjectIdentifiers(testPairwiseSecret, &spyFetcher{})
+	one := oauth2server.NewSimpleClient("one", testClientSecret, []string{"https://one.example.com/callback"})
+	oneAgain := oauth2server.NewSimpleClient("oneagain", testClientSecret, []string{"https://one.example.com/other"})
+	two := oauth2server.NewSimpleClient("two", testClientSecret, []string{"https://two.example.com/callback"})
+
+	subOne, _ := subjects.SubjectIdentifier(context.Background(), one, "user")
+	subOneAgain, _ := subjects.SubjectIdentifier(context.Background(), oneAgain, "user")
+	subTwo, _ := subjects.SubjectIdentifier(context.Background(), two, "user")
+
+	if subOne == "user" || subOne == subTwo {
+		t.Errorf("expected different subjects for each sector, got %q and %q", subOne, subTwo)
+	}
+	if subOne != subOneAgain {
+		t.Errorf("expected clients in the same sector to share subjects, got %q and %q", subOne, subOneAgain)
+	}
+	other, _ := subjects.SubjectIdentifier(context.Background(), one, "other")
+	if other == subOne {
+		t.Error("expected different users to have different subjects")
+	}
+}
+
+func TestPairwiseSubjectIdentifiers_IgnoresPortsInTheSector(t *testing.T) {
+	subjects := oauth2server.NewPairwiseSubjectIdentifiers(testPairwiseSecret, &spyFetcher{})
+	ports := oauth2server.NewSimpleClient("ports", testClientSecret, []string{
+		"https://one.example.com:8443/callback",
+		"https://one.example.com/callback",
+	})
+	noPort := oauth2server.NewSimpleClient("noport", testClientSecret, []string{"https://one.example.com/callback"})
+
+	sub, err := subjects.SubjectIdentifier(context.Background(), ports, "user")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	noPortSub, _ := subjects.SubjectIdentifier(context.Background(), noPort, "user")
+	if sub != noPortSub {
+		t.Errorf("expected the port to be left out of the sector, got %q != %q", sub, noPortSub)
+	}
+}
+
+func TestPairwiseSubjectIdentifiers_RequiresSectorIdentifierForMultipleHosts(t *testing.T) {
+	subjects := oauth2server.NewPairwiseSubjectIdentifiers(testPairwiseSecret, &spyFetcher{})
+	client := oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{
+		"https://one.example.com/callback",
+		"https://two.example.com/callback",
+	})
+
+	_, err := subjects.SubjectIdentifier(context.Background(), client, "user")
+
+	if !errors.Is(err, oauth2server.ErrSectorIdentifierRequired) {
+		t.Errorf("expected ErrSectorIdentifierRequired, got %v", err)
+	}
+}
+
+func TestPairwiseSubjectIdentifiers_UsesSectorIdentifierURIHost(t *testing.T) {
+	redirectUris := []string{"https://one.example.com/callback", "https://two.example.com/callback"}
+	fetcher := &spyFetcher{body: []byte(`["https://one.example.com/callback", "https://two.example.com/callback"]`)}
+	subjects := oauth2server.NewPairwiseSubjectIdentifiers(testPairwiseSecret, fetcher)
+	client := &sectorClient{
+		Client:              oauth2server.NewSimpleClient(testClientId, testClientSecret, redirectUris),
+		sectorIdentifierUri: testSectorIdentifierUri,
+	}
+	sectorHostClient := oauth2server.NewSimpleClient("sector", testClientSecret, []string{"https://sector.example.com/callback"})
+
+	sub, err := subjects.SubjectIdentifier(context.Background(), client, "user")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(fetcher.calls) != 1 || fetcher.calls[0] != testSectorIdentifierUri {
+		t.Errorf("expected sector identifier URI to be fetched, got %v", fetcher.calls)
+	}
+	sectorSub, _ := subjects.SubjectIdentifier(context.Background(), sectorHostClient, "user")
+	if sub != sectorSub {
+		t.Errorf("expected the sector identifier URI host to be the sector, got %q != %q", sub, sectorSub)
+	}
+}
+
+func TestPairwiseSubjectIdentifiers_ErrorsIfRedirectURIsAreNotListed(t *testing.T) {
+	cases := []struct {
+		name     string
+		body     string
+		expected error
+	}{
+		{"missing redirect URI", `["https://one.example.com/callback"]`, oauth2server.ErrSectorIdentifierMismatch},
+		{"not a JSON array", `{"redirect_uris": []}`, oauth2server.ErrInvalidSectorIdentifier},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			subjects := oauth2server.NewPairwiseSubjectIdentifiers(testPairwiseSecret, &spyFetcher{body: []byte(c.body)})
+			client := &sectorClient{
+				Client:              oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{"https://one.example.com/callback", "https://two.example.com/callback"}),
+				sectorIdentifierUri: testSectorIdentifierUri,
+			}
+
+			_, err := subjects.SubjectIdentifier(context.Background(), client, "user")
+
+			if !errors.Is(err, c.expected) {
+				t.Errorf("expected %v, got %v", c.expected, err)
+			}
+		})
+	}
+}
+
+func TestPairwiseSubjectIdentifiers_RequiresHTTPSSectorIdentifierURI(t *testing.T) {
+	fetcher := &spyFetcher{body: []byte(`["https://one.example.com/callback"]`)}
+	subjects := oauth2server.NewPairwiseSubjectIdentifiers(testPairwiseSecret, fetcher)
+	client := &sectorClient{
+		Client:              oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{"https://one.example.com/callback"}),
+		sectorIdentifierUri: "http://sector.example.com/redirect_uris.json",
+	}
+
+	_, err := subjects.SubjectIdentifier(context.Background(), client, "user")
+
+	if !errors.Is(err, oauth2server.ErrSectorIdentifierNotHTTPS) {
+		t.Errorf("expected ErrSectorIdentifierNotHTTPS, got %v", err)
+	}
+	if len(fetcher.calls) != 0 {
+		t.Errorf("expected nothing to be fetched, got %v", fetcher.calls)
+	}
+}
+
+func TestPairwiseSubjectIdentifiers_KeepsSectorIdentifierDocuments(t *testing.T) {
+	cases := []struct {
+		name     string
+		lifetime time.Duration
+		expected int
+	}{
+		{"within lifetime", time.Hour, 1},
+		{"expired", 0, 2},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fetcher := &spyFetcher{body: []byte(`["https://one.example.com/callback"]`)}
+			subjects := oauth2server.NewPairwiseSubjectIdentifiers(
+				testPairwiseSecret,
+				fetcher,
+				oauth2server.WithSectorIdentifierLifetime(c.lifetime),
+			)
+			client := &sectorClient{
+				Client:              oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{"https://one.example.com/callback"}),
+				sectorIdentifierUri: testSectorIdentifierUri,
+			}
+
+			for range 2 {
+				if _, err := subjects.SubjectIdentifier(context.Background(), client, "user"); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if len(fetcher.calls) != c.expected {
+				t.Errorf("expected %d fetches, got %d", c.expected, len(fetcher.calls))
+			}
+		})
+	}
+}
+
+func TestIDTokenIssuer_IssueIDToken_UsesSubjectIdentifiers(t *testing.T) {
+	users := oauth2server.NewInMemoryUserRepository()
+	users.Add(newTestOIDCUser())
+	subjects := oauth2server.NewPairwiseSubjectIdentifiers(testPairwiseSecret, &spyFetcher{})
+	issuer := oauth2server.NewIDTokenIssuer(
+		testOIDCIssuer,
+		oauth2server.NewStaticKeySet(newTestSigningKey()),
+		users,
+		oauth2server.WithIDTokenSubjectIdentifiers(subjects),
+	)
+	client := oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri})
+
+	token, err := issuer.IssueIDToken(context.Background(), &oauth2server.IDTokenRequest{
+		Client: client,
+		UserID: "user",
+		Scope:  []string{oauth2server.ScopeOpenID},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected, _ := subjects.SubjectIdentifier(context.Background(), client, "user")
+	if claims := decodeTestJWT(t, token); claims["sub"] != expected {
+		t.Errorf("expected pairwise subject %q, got %v", expected, claims["sub"])
+	}
+}
+
+func TestUserInfoHandler_UsesSubjectIdentifiers(t *testing.T) {
+	tc := startUserInfoTest(t)
+	subjects := oauth2server.NewPairwiseSubjectIdentifiers(testPairwiseSecret, &spyFetcher{})
+	users := oauth2server.NewInMemoryUserRepository()
+	users.Add(newTestOIDCUser())
+	tc.handler = oauth2server.NewUserInfoHandler(tc.tokens, users, oauth2server.WithUserInfoSubjectIdentifiers(tc.clients, subjects))
+	tc.addToken("token", oauth2server.ScopeOpenID)
+
+	rec := tc.serve(http.MethodGet, "token")
+
+	client, _ := tc.clients.Get(context.Background(), testClientId)
+	expected, _ := subjects.SubjectIdentifier(context.Background(), client, "user")
+	var claims map[string]any
+	json.Unmarshal(rec.Body.Bytes(), &claims)
+	if rec.Code != http.StatusOK || claims["sub"] != expected {
+		t.Errorf("expected pairwise subject %q, got %d %s", expected, rec.Code, rec.Body.String())
+	}
+}
+
+func TestDefaultAuthorizationServer_Introspect_UsesSubjectIdentifiers(t *testing.T) {
+	tokens := oauth2server.NewInMemoryAccessTokenRepository()
+	subjects := oauth2server.NewPairwiseSubjectIdentifiers(testPairwiseSecret, &spyFetcher{})
+	tc := startAuthorizationServerTest(t, oauth2server.WithIntrospection(tokens), oauth2server.WithSubjectIdentifiers(subjects))
+	client := oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri})
+	tc.clients.Add(client)
+	tokens.Create(context.Background(), &oauth2server.AccessToken{
+		Token:     "token",
+		ClientID:  testClientId,
+		UserID:    "user",
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	req := newIntrospectionRequest("token")
+
+	resp, err := tc.server.Introspect(req.Context(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected, _ := subjects.SubjectIdentifier(context.Background(), client, "user")
+	if resp.Sub != expected {
+		t.Errorf("expected pairwise subject %q, got %q", expected, resp.Sub)
+	}
+}
@@ -0,0 +1,327 @@
+package oauth2server
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	TokenTypeBearer = "Bearer"
+
+	DefaultAccessTokenLifetime  = time.Hour
+	DefaultRefreshTokenLifetime = 14 * 24 * time.Hour
+)
+
+// An issued, opaque access token.
+type AccessToken struct {
+	// the token value itself
+	Token string
+
+	// the client the token was issued to
+	ClientID string
+
+	// the user the token was issued on behalf of, empty for tokens not
+	// associated with a user.
+	UserID string
+
+	// the scopes granted to the token
+	Scope []string
+
+	// the resources the token may be used at, empty for tokens that are not
+	// audience restricted.
+	Audience []string
+
+	// the authorization details granted to the token
+	AuthorizationDetails []AuthorizationDetail
+
+	// the claims requested with the `claims` parameter, used for UserInfo
+	// responses. Nil if none.
+	Claims *ClaimsRequest
+
+	// the AuthorizationRecord the token was issued under, empty if none
+	AuthorizationID string
+
+	IssuedAt time.Time
+
+	ExpiresAt time.Time
+}
+
+// whether the token is still usable at the given time
+func (t *AccessToken) IsActive(now time.Time) bool {
+	return now.Before(t.ExpiresAt)
+}
+
+// A storage backend for access tokens
+type AccessTokenRepository interface {
+	// store a newly issued token.
+	Create(ctx context.Context, token *AccessToken) error
+
+	// Get a single token by its value, return a `nil` token if not found. Any
+	// errors returned here will be propagated as server errors.
+	Get(ctx context.Context, token string) (*AccessToken, error)
+
+	// remove every token issued under the authorization record, the ID is
+	// never empty.
+	RevokeAuthorization(ctx context.Context, authorizationId string) error
+
+	// every active token issued on behalf of the user
+	ListForUser(ctx context.Context, userId string) ([]*AccessToken, error)
+
+	// remove every token issued to the client on behalf of the user, an empty
+	// client ID removes the user's tokens for every client.
+	RevokeForUser(ctx context.Context, userId string, clientId string) error
+}
+
+type InMemoryAccessTokenRepository struct {
+	lock   sync.RWMutex
+	tokens map[string]*AccessToken
+}
+
+func NewInMemoryAccessTokenRepository() *InMemoryAccessTokenRepository {
+	return &InMemoryAccessTokenRepository{
+		tokens: make(map[string]*AccessToken),
+	}
+}
+
+func (r *InMemoryAccessTokenRepository) Create(ctx context.Context, token *AccessToken) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.tokens[token.Token] = token
+
+	return nil
+}
+
+func (r *InMemoryAccessTokenRepository) Get(ctx context.Context, token string) (*AccessToken, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	t, _ := r.tokens[token]
+
+	return t, nil
+}
+
+func (r *InMemoryAccessTokenRepository) RevokeAuthorization(ctx context.Context, authorizationId string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for value, token := range r.tokens {
+		if token.AuthorizationID == authorizationId {
+			delete(r.tokens, value)
+		}
+	}
+
+	return nil
+}
+
+func (r *InMemoryAccessTokenRepository) ListForUser(ctx context.Context, userId string) ([]*AccessToken, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	now := time.Now()
+	var tokens []*AccessToken
+	for _, token := range r.tokens {
+		if token.UserID == userId && token.IsActive(now) {
+			tokens = append(tokens, token)
+		}
+	}
+
+	return tokens, nil
+}
+
+func (r *InMemoryAccessTokenRepository) RevokeForUser(ctx context.Context, userId string, clientId string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for value, token := range r.tokens {
+		if token.UserID == userId && (clientId == "" || token.ClientID == clientId) {
+			delete(r.tokens, value)
+		}
+	}
+
+	return nil
+}
+
+// what an access token is being issued for. Grants build this from an
+// authorization code or other credentials.
+type IssueTokenRequest struct {
+	Client Client
+
+	// empty if the token is not issued on behalf of a user
+	UserID string
+
+	Scope []string
+
+	// all the resources that were granted, refresh tokens may later be used to
+	// issue access tokens for any of these.
+	Resource []string
+
+	// the resources this access token is for, a subset of `Resource`.
+	Audience []string
+
+	AuthorizationDetails []AuthorizationDetail
+
+	// everything that was granted when the token request may have narrowed
+	// the scope or authorization details of this access token. Refresh tokens
+	// keep the full grant, empty uses `Scope` and `AuthorizationDetails`.
+	GrantedScope                []string
+	GrantedAuthorizationDetails []AuthorizationDetail
+
+	// the claims requested with the `claims` parameter, nil if none
+	Claims *ClaimsRequest
+
+	// the AuthorizationRecord the token is issued under, empty if none
+	AuthorizationID string
+
+	// the refresh token used to request the access token, if any. No new refresh
+	// token is issued when this is set.
+	RefreshToken *RefreshToken
+}
+
+// Issues and stores access tokens, this is shared between grants.
+type TokenIssuer interface {
+	IssueAccessToken(ctx context.Context, req *IssueTokenRequest) (*AccessTokenResponse, error)
+}
+
+type defaultTokenIssuer struct {
+	accessTokens         AccessTokenRepository
+	accessTokenLifetime  time.Duration
+	refreshTokens        RefreshTokenRepository
+	refreshTokenLifetime time.Duration
+	resourceServers      ResourceServerRepository
+}
+
+type TokenIssuerOption func(*defaultTokenIssuer)
+
+func WithAccessTokenLifetime(lifetime time.Duration) TokenIssuerOption {
+	return func(i *defaultTokenIssuer) {
+		i.accessTokenLifetime = lifetime
+	}
+}
+
+// issue refresh tokens alongside access tokens, a zero lifetime uses
+// DefaultRefreshTokenLifetime.
+func WithRefreshTokens(refreshTokens RefreshTokenRepository, lifetime time.Duration) TokenIssuerOption {
+	return func(i *defaultTokenIssuer) {
+		i.refreshTokens = refreshTokens
+		i.refreshTokenLifetime = lifetime
+	}
+}
+
+// a token issuer that generates random, opaque access tokens
+func NewTokenIssuer(accessTokens AccessTokenRepository, opts ...TokenIssuerOption) TokenIssuer {
+	issuer := &defaultTokenIssuer{
+		accessTokens:        accessTokens,
+		accessTokenLifetime: DefaultAccessTokenLifetime,
+	}
+	for _, opt := range opts {
+		opt(issuer)
+	}
+
+	if issuer.refreshTokenLifetime <= 0 {
+		issuer.refreshTokenLifetime = DefaultRefreshTokenLifetime
+	}
+
+	return issuer
+}
+
+func (i *defaultTokenIssuer) IssueAccessToken(ctx context.Context, req *IssueTokenRequest) (*AccessTokenResponse, error) {
+	value, err := generateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	lifetime, err := i.lifetime(ctx, req.Audience)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	token := &AccessToken{
+		Token:                value,
+		ClientID:             req.Client.ID(),
+		UserID:               req.UserID,
+		Scope:                req.Scope,
+		Audience:             req.Audience,
+		AuthorizationDetails: req.AuthorizationDetails,
+		Claims:               req.Claims,
+		AuthorizationID:      req.AuthorizationID,
+		IssuedAt:             now,
+		ExpiresAt:            now.Add(lifetime),
+	}
+
+	if err := i.accessTokens.Create(ctx, token); err != nil {
+		return nil, err
+	}
+
+	resp := &AccessTokenResponse{
+		AccessToken:          token.Token,
+		TokenType:            TokenTypeBearer,
+		ExpiresIn:            int(lifetime.Seconds()),
+		Scope:                strings.Join(token.Scope, spaceSeparator),
+		AuthorizationDetails: token.AuthorizationDetails,
+	}
+
+	switch {
+	case req.RefreshToken != nil:
+		resp.RefreshToken = req.RefreshToken.Token
+	case i.refreshTokens != nil:
+		refreshToken, err := i.issueRefreshToken(ctx, req, now)
+		if err != nil {
+			return nil, err
+		}
+		resp.RefreshToken = refreshToken.Token
+	}
+
+	return resp, nil
+}
+
+func (i *defaultTokenIssuer) lifetime(ctx context.Context, audience []string) (time.Duration, error) {
+	if i.resourceServers == nil {
+		return i.accessTokenLifetime, nil
+	}
+
+	lifetime, err := resourceServerLifetime(ctx, i.resourceServers, audience)
+	if err != nil || lifetime == 0 {
+		return i.accessTokenLifetime, err
+	}
+
+	return lifetime, nil
+}
+
+func (i *defaultTokenIssuer) issueRefreshToken(ctx context.Context, req *IssueTokenRequest, now time.Time) (*RefreshToken, error) {
+	value, err := generateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	scope := req.GrantedScope
+	if len(scope) == 0 {
+		scope = req.Scope
+	}
+	details := req.GrantedAuthorizationDetails
+	if len(details) == 0 {
+		details = req.AuthorizationDetails
+	}
+
+	token := &RefreshToken{
+		Token:                value,
+		ClientID:             req.Client.ID(),
+		UserID:               req.UserID,
+		Scope:                scope,
+		Resource:             req.Resource,
+		AuthorizationDetails: details,
+		Claims:               req.Claims,
+		AuthorizationID:      req.AuthorizationID,
+		IssuedAt:             now,
+		ExpiresAt:            now.Add(i.refreshTokenLifetime),
+	}
+
+	if err := i.refreshTokens.Create(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
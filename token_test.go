@@ -0,0 +1,55 @@
+package oauth2server_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+func TestInMemoryAccessTokenRepository_ReturnsNilForUnknownTokens(t *testing.T) {
+	repo := oauth2server.NewInMemoryAccessTokenRepository()
+
+	token, err := repo.Get(context.Background(), "nope")
+
+	if err != nil || token != nil {
+		t.Errorf("expected no token, got %v %v", token, err)
+	}
+}
+
+func TestAccessToken_IsActive_FalseOnceExpired(t *testing.T) {
+	now := time.Now()
+	token := &oauth2server.AccessToken{ExpiresAt: now}
+
+	if !token.IsActive(now.Add(-time.Second)) {
+		t.Error("expected token to be active before it expires")
+	}
+	if token.IsActive(now) {
+		t.Error("expected token to be inactive once expired")
+	}
+}
+
+func TestTokenIssuer_IssueAccessToken_StoresToken(t *testing.T) {
+	repo := oauth2server.NewInMemoryAccessTokenRepository()
+	issuer := oauth2server.NewTokenIssuer(repo, oauth2server.WithAccessTokenLifetime(time.Minute))
+	client := oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri})
+
+	resp, err := issuer.IssueAccessToken(context.Background(), &oauth2server.IssueTokenRequest{
+		Client: client,
+		UserID: "user",
+		Scope:  []string{"read"},
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ExpiresIn != 60 || resp.Scope != "read" || resp.TokenType != oauth2server.TokenTypeBearer {
+		t.Errorf("unexpected token response: %+v", resp)
+	}
+
+	token, _ := repo.Get(context.Background(), resp.AccessToken)
+	if token == nil || token.ClientID != testClientId || token.UserID != "user" {
+		t.Errorf("expected token to be stored, got %+v", token)
+	}
+}
@@ -1,7 +1,9 @@
 package oauth2server
 
 import (
+	"context"
 	"net/url"
+	"sync"
 	"time"
 )
 
@@ -12,6 +14,70 @@ type User interface {
 	ID() string
 }
 
+// User entities that implement this provide details about how and when the
+// user authenticated, these are included in ID tokens as `auth_time`, `acr`,
+// and `amr`. See https://openid.net/specs/openid-connect-core-1_0.html#IDToken
+type UserWithAuthentication interface {
+	// when the user last authenticated, zero if unknown
+	AuthTime() time.Time
+
+	// the authentication context class reference satisfied, empty if none
+	ACR() string
+
+	// the authentication methods used, eg `pwd` or `otp`
+	AMR() []string
+}
+
+// User entities that implement this are tied to a session at the authorization
+// server. The session ID is included in ID tokens as `sid` so clients can
+// match logout requests to their own sessions.
+// See https://openid.net/specs/openid-connect-frontchannel-1_0.html#ClaimsContents
+type UserWithSession interface {
+	// the identifier of the user's session, empty if none
+	SessionID() string
+}
+
+// A storage backend for users, used to look up the user an access token was
+// issued to.
+type UserRepository interface {
+	// Get a single user by its identifier, return a `nil` user if not found.
+	// Any errors returned here will be propagated as server errors.
+	Get(ctx context.Context, id string) (User, error)
+}
+
+type InMemoryUserRepository struct {
+	lock  sync.RWMutex
+	users map[string]User
+}
+
+func NewInMemoryUserRepository() *InMemoryUserRepository {
+	return &InMemoryUserRepository{
+		users: make(map[string]User),
+	}
+}
+
+func (r *InMemoryUserRepository) Get(ctx context.Context, id string) (User, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	user, _ := r.users[id]
+
+	return user, nil
+}
+
+func (r *InMemoryUserRepository) Add(u User) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.users[u.ID()] = u
+}
+
+func (r *InMemoryUserRepository) Remove(id string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.users, id)
+}
+
 type UserEmail struct {
 	// The users email address
 	Email string
@@ -63,8 +129,10 @@ type UserProfile struct {
 	// set the year to zeros if the user does not wish to share their birth year.
 	Birthdate time.Time
 
-	// the end users time zone
-	Zoneinfo time.Location
+	// the end users time zone, this should be loaded from the IANA time zone
+	// database with `time.LoadLocation` so its name can be used as `zoneinfo`.
+	// nil if unknown.
+	Zoneinfo *time.Location
 
 	// the end users preferred locale, probaboy in {lang}_{CountryCode} format
 	Locale string
@@ -76,3 +144,46 @@ type UserProfile struct {
 type UserWithProfile interface {
 	UserProfile() UserProfile
 }
+
+// the `address` claim values, empty fields are left out of the claim.
+// See https://openid.net/specs/openid-connect-core-1_0.html#AddressClaim
+type UserAddress struct {
+	// the full mailing address, may contain newlines
+	Formatted string
+
+	// street address, may contain newlines for multiple lines
+	StreetAddress string
+
+	// city or locality
+	Locality string
+
+	// state, province, prefecture or region
+	Region string
+
+	// zip or postal code
+	PostalCode string
+
+	// the country name
+	Country string
+}
+
+// User entities that implement this support the OpenID `address` scope.
+type UserWithAddress interface {
+	UserAddress() UserAddress
+}
+
+type UserPhone struct {
+	// the user's phone number in E.164 format, eg +15555550100. Numbers in
+	// other formats are not included in claims.
+	Number string
+
+	// whether the user has proven they own the phone number, usually with a
+	// code sent by SMS or a call.
+	Verified bool
+}
+
+// User entities that implement this support the OpenID `phone` scope, return
+// an empty number to leave out the `phone_number` claim.
+type UserWithPhone interface {
+	UserPhone() UserPhone
+}
@@ -0,0 +1,201 @@
+package oauth2server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// extension point to let clients receive signed UserInfo responses, the
+// `userinfo_signed_response_alg` client metadata. Responses are plain JSON if
+// not implemented or empty.
+type ClientSignsUserInfo interface {
+	UserInfoSignedResponseAlg() string
+}
+
+type userInfoHandler struct {
+	tokens   AccessTokenRepository
+	users    UserRepository
+	clients  ClientRepository
+	issuer   string
+	keys     KeySet
+	subjects SubjectIdentifierStrategy
+}
+
+type UserInfoOption func(*userInfoHandler)
+
+// sign responses for clients that implement ClientSignsUserInfo, issuer is
+// used as the `iss` claim.
+func WithSignedUserInfo(issuer string, clients ClientRepository, keys KeySet) UserInfoOption {
+	return func(h *userInfoHandler) {
+		h.issuer = issuer
+		h.clients = clients
+		h.keys = keys
+	}
+}
+
+// decide the `sub` of responses, defaults to the user's ID. Clients are needed
+// to look up the client each token was issued to.
+func WithUserInfoSubjectIdentifiers(clients ClientRepository, subjects SubjectIdentifierStrategy) UserInfoOption {
+	return func(h *userInfoHandler) {
+		h.clients = clients
+		h.subjects = subjects
+	}
+}
+
+// The OpenID Connect UserInfo endpoint. Requests must include a bearer access
+// token that was granted the `openid` scope, the response includes the claims
+// its scopes allow. See https://openid.net/specs/openid-connect-core-1_0.html#UserInfo
+func NewUserInfoHandler(tokens AccessTokenRepository, users UserRepository, opts ...UserInfoOption) http.Handler {
+	h := &userInfoHandler{
+		tokens: tokens,
+		users:  users,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+func (h *userInfoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		RespondWithError(w, OAuthError{
+			ErrorType:        ErrorTypeInvalidRequest,
+			ErrorDescription: "userinfo requests must be GET or POST requests",
+			StatusCode:       http.StatusMethodNotAllowed,
+			Cause:            ErrInvalidRequestMethod,
+		})
+		return
+	}
+
+	token, err := h.accessToken(r)
+	if err != nil {
+		RespondWithBearerError(w, err)
+		return
+	}
+
+	user, userErr := h.users.Get(r.Context(), token.UserID)
+	if userErr != nil {
+		RespondWithError(w, *ServerError(userErr))
+		return
+	}
+	if user == nil {
+		RespondWithBearerError(w, InvalidToken(ErrUserNotFound))
+		return
+	}
+
+	client, clientErr := h.client(r.Context(), token.ClientID)
+	if clientErr != nil {
+		RespondWithError(w, *MaybeWrapError(clientErr))
+		return
+	}
+
+	claims := ClaimsFor(user, token.Scope, token.Claims.UserInfoClaims())
+	if err := h.addSubject(r.Context(), client, user, claims); err != nil {
+		RespondWithError(w, *MaybeWrapError(err))
+		return
+	}
+
+	if err := h.respond(r.Context(), w, client, token, claims); err != nil {
+		RespondWithError(w, *MaybeWrapError(err))
+	}
+}
+
+// the client the token was issued to, nil if no clients are configured
+func (h *userInfoHandler) client(ctx context.Context, clientId string) (Client, error) {
+	if h.clients == nil {
+		return nil, nil
+	}
+
+	client, err := h.clients.Get(ctx, clientId)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, fmt.Errorf("%w: %s", ErrClientNotFound, clientId)
+	}
+
+	return client, nil
+}
+
+func (h *userInfoHandler) addSubject(ctx context.Context, client Client, user User, claims map[string]any) error {
+	if h.subjects == nil {
+		claims["sub"] = user.ID()
+		return nil
+	}
+
+	sub, err := h.subjects.SubjectIdentifier(ctx, client, user.ID())
+	if err != nil {
+		return err
+	}
+	claims["sub"] = sub
+
+	return nil
+}
+
+func (h *userInfoHandler) accessToken(r *http.Request) (*AccessToken, *OAuthError) {
+	value, err := BearerToken(r)
+	if err != nil {
+		return nil, err
+	}
+
+	token, tokenErr := h.tokens.Get(r.Context(), value)
+	if tokenErr != nil {
+		return nil, ServerError(tokenErr)
+	}
+
+	if token == nil || !token.IsActive(time.Now()) {
+		return nil, InvalidToken(ErrInvalidAccessToken)
+	}
+
+	if token.UserID == "" {
+		return nil, InvalidToken(ErrAccessTokenHasNoUser)
+	}
+
+	if !slices.Contains(token.Scope, ScopeOpenID) {
+		return nil, InsufficientScope(ScopeOpenID)
+	}
+
+	return token, nil
+}
+
+func (h *userInfoHandler) respond(ctx context.Context, w http.ResponseWriter, client Client, token *AccessToken, claims map[string]any) error {
+	alg := signedUserInfoAlg(client)
+	if alg == "" || h.keys == nil {
+		return jsonResponse(w, http.StatusOK, claims)
+	}
+
+	key, err := h.keys.SigningKey(ctx, alg)
+	if err != nil {
+		return err
+	}
+
+	claims["iss"] = h.issuer
+	claims["aud"] = token.ClientID
+
+	jwt, err := SignJWT(key, claims)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/jwt")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write([]byte(jwt))
+
+	return err
+}
+
+// the algorithm to sign the response with, empty for unsigned responses
+func signedUserInfoAlg(client Client) string {
+	signs, ok := client.(ClientSignsUserInfo)
+	if !ok {
+		return ""
+	}
+
+	return signs.UserInfoSignedResponseAlg()
+}
@@ -0,0 +1,154 @@
+package oauth2server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chrisguitarguy/oauth2server"
+)
+
+type signedUserInfoClient struct {
+	oauth2server.Client
+}
+
+func (c *signedUserInfoClient) UserInfoSignedResponseAlg() string {
+	return oauth2server.SigningAlgRS256
+}
+
+type userInfoTestCase struct {
+	tokens  *oauth2server.InMemoryAccessTokenRepository
+	clients *oauth2server.InMemoryClientRepository
+	handler http.Handler
+}
+
+func startUserInfoTest(t *testing.T) *userInfoTestCase {
+	t.Helper()
+
+	tokens := oauth2server.NewInMemoryAccessTokenRepository()
+	users := oauth2server.NewInMemoryUserRepository()
+	users.Add(newTestOIDCUser())
+	clients := oauth2server.NewInMemoryClientRepository()
+	clients.Add(oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}))
+
+	return &userInfoTestCase{
+		tokens:  tokens,
+		clients: clients,
+		handler: oauth2server.NewUserInfoHandler(
+			tokens,
+			users,
+			oauth2server.WithSignedUserInfo(testOIDCIssuer, clients, oauth2server.NewStaticKeySet(newTestSigningKey())),
+		),
+	}
+}
+
+func (tc *userInfoTestCase) addToken(value string, scope ...string) {
+	tc.tokens.Create(context.Background(), &oauth2server.AccessToken{
+		Token:     value,
+		ClientID:  testClientId,
+		UserID:    "user",
+		Scope:     scope,
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+}
+
+func (tc *userInfoTestCase) serve(method string, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/userinfo", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	tc.handler.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestUserInfoHandler_ChallengesRequestsWithoutToken(t *testing.T) {
+	tc := startUserInfoTest(t)
+
+	rec := tc.serve(http.MethodGet, "")
+
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") != "Bearer" {
+		t.Errorf("expected bearer challenge, got %d %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestUserInfoHandler_RejectsUnknownTokens(t *testing.T) {
+	tc := startUserInfoTest(t)
+
+	rec := tc.serve(http.MethodGet, "nope")
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestUserInfoHandler_RequiresOpenIDScope(t *testing.T) {
+	tc := startUserInfoTest(t)
+	tc.addToken("token", oauth2server.ScopeProfile)
+
+	rec := tc.serve(http.MethodGet, "token")
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", rec.Code)
+	}
+}
+
+func TestUserInfoHandler_ReturnsClaimsForScopes(t *testing.T) {
+	tc := startUserInfoTest(t)
+	tc.addToken("token", oauth2server.ScopeOpenID, oauth2server.ScopeEmail)
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		rec := tc.serve(method, "token")
+
+		var claims map[string]any
+		json.Unmarshal(rec.Body.Bytes(), &claims)
+		if rec.Code != http.StatusOK || claims["sub"] != "user" || claims["email"] != "user@example.com" {
+			t.Errorf("unexpected %s response %d %s", method, rec.Code, rec.Body.String())
+		}
+		if _, ok := claims["name"]; ok {
+			t.Errorf("expected no profile claims without the profile scope, got %v", claims)
+		}
+	}
+}
+
+func TestUserInfoHandler_SignsResponsesForClientsThatRequestIt(t *testing.T) {
+	tc := startUserInfoTest(t)
+	tc.clients.Add(&signedUserInfoClient{
+		Client: oauth2server.NewSimpleClient(testClientId, testClientSecret, []string{testRedirectUri}),
+	})
+	tc.addToken("token", oauth2server.ScopeOpenID)
+
+	rec := tc.serve(http.MethodGet, "token")
+
+	if rec.Header().Get("Content-Type") != "application/jwt" {
+		t.Fatalf("expected a JWT response, got %q", rec.Header().Get("Content-Type"))
+	}
+	claims := decodeTestJWT(t, rec.Body.String())
+	if claims["sub"] != "user" || claims["iss"] != testOIDCIssuer || claims["aud"] != testClientId {
+		t.Errorf("unexpected claims: %v", claims)
+	}
+}
+
+func TestUserInfoHandler_ReturnsAddressAndPhoneClaims(t *testing.T) {
+	tc := startUserInfoTest(t)
+	user := newTestOIDCUser()
+	user.address = oauth2server.UserAddress{Formatted: "123 Main St\nSpringfield"}
+	user.phone = oauth2server.UserPhone{Number: "+15555550100"}
+	users := oauth2server.NewInMemoryUserRepository()
+	users.Add(user)
+	tc.handler = oauth2server.NewUserInfoHandler(tc.tokens, users)
+	tc.addToken("token", oauth2server.ScopeOpenID, oauth2server.ScopeAddress, oauth2server.ScopePhone)
+
+	rec := tc.serve(http.MethodGet, "token")
+
+	var claims map[string]any
+	json.Unmarshal(rec.Body.Bytes(), &claims)
+	address, _ := claims["address"].(map[string]any)
+	if address["formatted"] != "123 Main St\nSpringfield" || claims["phone_number"] != "+15555550100" || claims["phone_number_verified"] != false {
+		t.Errorf("unexpected claims: %v", claims)
+	}
+}